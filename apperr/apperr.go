@@ -0,0 +1,87 @@
+// Package apperr defines application errors that know the HTTP status they
+// are answered with and a message that is safe to show to users. The cause
+// is kept for logging.
+package apperr
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Error is an application error
+type Error struct {
+	// Status is the HTTP status code of the response
+	Status int
+	// Message is shown to the user
+	Message string
+	// Err is the cause, which is logged but not shown
+	Err error
+}
+
+// New returns an error answered with status
+func New(status int, message string, err error) *Error {
+	return &Error{Status: status, Message: message, Err: err}
+}
+
+// BadRequest is returned for malformed requests, like unknown query values
+func BadRequest(message string, err error) *Error {
+	return New(http.StatusBadRequest, message, err)
+}
+
+// Forbidden is returned for requests the user may not make
+func Forbidden(message string) *Error {
+	return New(http.StatusForbidden, message, nil)
+}
+
+// NotFound is returned for resources that don't exist
+func NotFound(message string) *Error {
+	return New(http.StatusNotFound, message, nil)
+}
+
+// Conflict is returned for changes that conflict with the stored state
+func Conflict(message string, err error) *Error {
+	return New(http.StatusConflict, message, err)
+}
+
+// Invalid is returned for submitted data that fails validation
+func Invalid(message string) *Error {
+	return New(http.StatusUnprocessableEntity, message, nil)
+}
+
+// Internal is returned for failures that aren't the user's fault
+func Internal(message string, err error) *Error {
+	return New(http.StatusInternalServerError, message, err)
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Title returns the heading of the error page for the status
+func (e *Error) Title() string {
+	switch e.Status {
+	case http.StatusNotFound:
+		return "Page not found"
+	case http.StatusForbidden:
+		return "Access denied"
+	case http.StatusConflict:
+		return "Conflicting change"
+	case http.StatusUnprocessableEntity:
+		return "Invalid submission"
+	case http.StatusInternalServerError:
+		return "Something went wrong"
+	}
+	return http.StatusText(e.Status)
+}
+
+// As returns err as an *Error when it is one or wraps one
+func As(err error) (*Error, bool) {
+	var appErr *Error
+	ok := errors.As(err, &appErr)
+	return appErr, ok
+}
@@ -0,0 +1,57 @@
+//go:build unit
+
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestError(t *testing.T) {
+	cause := errors.New("connection refused")
+	err := Internal("Failed to fetch post", cause)
+
+	if err.Error() != "Failed to fetch post: connection refused" {
+		t.Errorf("Expected the message and the cause, got %q", err.Error())
+	}
+	if !errors.Is(err, cause) {
+		t.Error("Expected the error to wrap its cause")
+	}
+	if NotFound("Post not found").Error() != "Post not found" {
+		t.Errorf("Expected the message alone without a cause, got %q", NotFound("Post not found").Error())
+	}
+}
+
+func TestError_Title(t *testing.T) {
+	tests := []struct {
+		err  *Error
+		want string
+	}{
+		{NotFound("x"), "Page not found"},
+		{Forbidden("x"), "Access denied"},
+		{Conflict("x", nil), "Conflicting change"},
+		{Invalid("x"), "Invalid submission"},
+		{Internal("x", nil), "Something went wrong"},
+		{BadRequest("x", nil), "Bad Request"},
+		{New(http.StatusMethodNotAllowed, "x", nil), "Method Not Allowed"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.err.Title(); got != tt.want {
+			t.Errorf("Expected title %q for status %d, got %q", tt.want, tt.err.Status, got)
+		}
+	}
+}
+
+func TestAs(t *testing.T) {
+	appErr := Forbidden("Not yours")
+
+	if got, ok := As(fmt.Errorf("deleting: %w", appErr)); !ok || got != appErr {
+		t.Errorf("Expected the wrapped application error, got %v, %v", got, ok)
+	}
+	if _, ok := As(errors.New("plain")); ok {
+		t.Error("Expected a plain error not to be an application error")
+	}
+}
@@ -0,0 +1,143 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Backends of NewBackend
+const (
+	BackendMemory = "memory"
+	BackendNone   = "none"
+)
+
+// ErrUnknownBackend is returned by NewBackend for unsupported backends
+var ErrUnknownBackend = errors.New("unknown cache backend")
+
+// Cache stores byte values by key. Implementations must be safe for
+// concurrent use. A backend that can fail, like a network cache, reports
+// failed reads as misses and drops failed writes.
+type Cache interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte)
+	Delete(key string)
+}
+
+// NewBackend returns the named cache backend holding up to size entries for
+// at most ttl, or for as long as there is room when ttl is zero. The "none"
+// backend returns a nil Cache, which disables caching.
+func NewBackend(name string, size int, ttl time.Duration) (Cache, error) {
+	switch name {
+	case BackendMemory, "":
+		return NewLRU(size, ttl), nil
+	case BackendNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownBackend, name)
+	}
+}
+
+// Stats counts the lookups of a Store
+type Stats struct {
+	// Hits were answered from the cache
+	Hits int64 `json:"hits"`
+	// Misses weren't in the cache, and either loaded the value or shared
+	// another caller's load
+	Misses int64 `json:"misses"`
+	// Shared waited for a load already running for the same key instead of
+	// starting another one
+	Shared int64 `json:"shared"`
+	// Invalidations dropped every entry
+	Invalidations int64 `json:"invalidations"`
+}
+
+// Store puts a Cache in front of slow loads. Concurrent misses for one key
+// share a single load, so an expired popular entry doesn't send a stampede
+// to the database.
+//
+// Keys are scoped to a generation and Invalidate starts a new one, which
+// drops every entry at once without the backend having to list its keys.
+// Entries of old generations are never read again and age out.
+//
+// A nil *Store is valid and loads every time.
+type Store struct {
+	backend    Cache
+	group      singleflight.Group
+	generation atomic.Uint64
+
+	hits          atomic.Int64
+	misses        atomic.Int64
+	shared        atomic.Int64
+	invalidations atomic.Int64
+}
+
+// NewStore returns a Store over the backend, or nil when backend is nil
+func NewStore(backend Cache) *Store {
+	if backend == nil {
+		return nil
+	}
+	return &Store{backend: backend}
+}
+
+// Fetch returns the value cached for key, calling load and caching its result
+// on a miss. Errors are returned to every caller sharing the load and are not
+// cached.
+func (s *Store) Fetch(key string, load func() ([]byte, error)) ([]byte, error) {
+	if s == nil {
+		return load()
+	}
+
+	// The generation is read once, so a load that races with Invalidate
+	// stores its possibly stale value under the old generation
+	key = strconv.FormatUint(s.generation.Load(), 10) + ":" + key
+	if value, ok := s.backend.Get(key); ok {
+		s.hits.Add(1)
+		return value, nil
+	}
+
+	s.misses.Add(1)
+	leader := false
+	value, err, shared := s.group.Do(key, func() (interface{}, error) {
+		leader = true
+		value, err := load()
+		if err != nil {
+			return nil, err
+		}
+		s.backend.Set(key, value)
+		return value, nil
+	})
+	if shared && !leader {
+		s.shared.Add(1)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return value.([]byte), nil
+}
+
+// Invalidate drops every cached entry
+func (s *Store) Invalidate() {
+	if s == nil {
+		return
+	}
+	s.generation.Add(1)
+	s.invalidations.Add(1)
+}
+
+// Stats returns the lookup counts so far
+func (s *Store) Stats() Stats {
+	if s == nil {
+		return Stats{}
+	}
+	return Stats{
+		Hits:          s.hits.Load(),
+		Misses:        s.misses.Load(),
+		Shared:        s.shared.Load(),
+		Invalidations: s.invalidations.Load(),
+	}
+}
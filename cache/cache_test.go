@@ -0,0 +1,153 @@
+//go:build unit
+
+package cache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRU_Evicts(t *testing.T) {
+	c := NewLRU(2, 0)
+	c.Set("a", []byte("1"))
+	c.Set("b", []byte("2"))
+
+	// Reading a makes b the least recently used
+	_, ok := c.Get("a")
+	require.True(t, ok)
+	c.Set("c", []byte("3"))
+
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+	value, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+	assert.Equal(t, 2, c.Len())
+
+	c.Set("a", []byte("updated"))
+	value, _ = c.Get("a")
+	assert.Equal(t, []byte("updated"), value)
+
+	c.Delete("a")
+	_, ok = c.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 1, c.Len())
+}
+
+func TestLRU_Expires(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewLRU(10, time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", []byte("1"))
+	now = now.Add(59 * time.Second)
+	_, ok := c.Get("a")
+	assert.True(t, ok)
+
+	now = now.Add(time.Second)
+	_, ok = c.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestNewBackend(t *testing.T) {
+	backend, err := NewBackend(BackendMemory, 10, 0)
+	require.NoError(t, err)
+	assert.IsType(t, &LRU{}, backend)
+
+	backend, err = NewBackend(BackendNone, 10, 0)
+	require.NoError(t, err)
+	assert.Nil(t, backend)
+	assert.Nil(t, NewStore(backend))
+
+	_, err = NewBackend("redis", 10, 0)
+	assert.ErrorIs(t, err, ErrUnknownBackend)
+}
+
+func TestStore_Fetch(t *testing.T) {
+	s := NewStore(NewLRU(10, 0))
+	loads := 0
+	load := func() ([]byte, error) {
+		loads++
+		return []byte("value"), nil
+	}
+
+	for i := 0; i < 3; i++ {
+		value, err := s.Fetch("key", load)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("value"), value)
+	}
+	assert.Equal(t, 1, loads)
+
+	// Errors aren't cached
+	failure := errors.New("load failed")
+	_, err := s.Fetch("failing", func() ([]byte, error) { return nil, failure })
+	assert.ErrorIs(t, err, failure)
+	value, err := s.Fetch("failing", load)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), value)
+
+	s.Invalidate()
+	_, err = s.Fetch("key", load)
+	require.NoError(t, err)
+	assert.Equal(t, 3, loads)
+
+	assert.Equal(t, Stats{Hits: 2, Misses: 4, Invalidations: 1}, s.Stats())
+}
+
+func TestStore_FetchShared(t *testing.T) {
+	s := NewStore(NewLRU(10, 0))
+	var loads atomic.Int32
+	release := make(chan struct{})
+
+	const callers = 10
+	var started, done sync.WaitGroup
+	started.Add(callers)
+	done.Add(callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			defer done.Done()
+			started.Done()
+			value, err := s.Fetch("key", func() ([]byte, error) {
+				loads.Add(1)
+				<-release
+				return []byte("value"), nil
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, []byte("value"), value)
+		}()
+	}
+
+	started.Wait()
+	// Give the callers time to join the load before it finishes
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	done.Wait()
+
+	assert.Equal(t, int32(1), loads.Load())
+	stats := s.Stats()
+	assert.Equal(t, int64(callers), stats.Hits+stats.Misses)
+	assert.Equal(t, stats.Misses-1, stats.Shared)
+}
+
+func TestStore_Nil(t *testing.T) {
+	var s *Store
+	loads := 0
+	for i := 0; i < 2; i++ {
+		_, err := s.Fetch("key", func() ([]byte, error) {
+			loads++
+			return nil, nil
+		})
+		require.NoError(t, err)
+	}
+	s.Invalidate()
+
+	assert.Equal(t, 2, loads)
+	assert.Equal(t, Stats{}, s.Stats())
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gekich/news-app/exporter"
+	"github.com/gekich/news-app/repository"
+)
+
+// runExport handles "newsctl export"
+func runExport(ctx context.Context, env *environment, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", "jsonl", "output format: jsonl, csv or markdown (a zip of Markdown files)")
+	output := fs.String("o", "-", "output file, - for stdout")
+	search := fs.String("search", "", "only export posts whose title or content contains this text")
+	from := fs.String("from", "", "only export posts created on or after this date (YYYY-MM-DD)")
+	to := fs.String("to", "", "only export posts created on or before this date (YYYY-MM-DD)")
+	status := fs.String("status", "", "only export posts with this status: published or draft")
+	fs.Parse(args)
+
+	parsedFormat, err := exporter.ParseFormat(*format)
+	if err != nil {
+		return err
+	}
+
+	filter, err := exporter.ParseFilter(*search, *from, *to, *status, nil)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "-" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+
+	buffered := bufio.NewWriter(out)
+	count, err := exporter.Export(ctx, repository.NewPostRepository(env.database), buffered, parsedFormat, filter)
+	if err != nil {
+		return err
+	}
+	if err := buffered.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Exported %d post(s)\n", count)
+	return nil
+}
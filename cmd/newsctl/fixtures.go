@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/gekich/news-app/repository"
+	"github.com/gekich/news-app/seeder"
+)
+
+// runFixtures handles "newsctl fixtures <file>", inserting a fixture file's posts next to the existing ones
+func runFixtures(ctx context.Context, env *environment, args []string) error {
+	fs := flag.NewFlagSet("fixtures", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: newsctl fixtures <file.yaml|file.json>")
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	fixture, err := seeder.LoadFixtureFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	ids, err := seeder.LoadFixture(ctx, repository.NewPostRepository(env.database), fixture)
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(ids))
+	for key := range ids {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fmt.Printf("%s\t%s\n", key, ids[key])
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/gekich/news-app/importer"
+	"github.com/gekich/news-app/repository"
+)
+
+// runImport handles "newsctl import <file>"
+func runImport(ctx context.Context, env *environment, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	format := fs.String("format", "", "input format: jsonl, csv, markdown or wxr (guessed from the file extension by default)")
+	dryRun := fs.Bool("dry-run", false, "validate every row without writing anything")
+	batchSize := fs.Int("batch", importer.DefaultBatchSize, "number of posts written per batch")
+	columns := fs.String("columns", "", "CSV column mapping, e.g. headline=title,body=content")
+	reportPath := fs.String("report", "", "write the per-row error report as CSV to this file")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: newsctl import [flags] <file|->")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	opts := importer.Options{
+		DryRun:       *dryRun,
+		BatchSize:    *batchSize,
+		MaxFileBytes: env.config.App.ImportMaxFileBytes,
+	}
+
+	var err error
+	if opts.Columns, err = importer.ParseColumns(*columns); err != nil {
+		return err
+	}
+
+	if *format != "" {
+		opts.Format, err = importer.ParseFormat(*format)
+	} else {
+		opts.Format, err = importer.FormatFromFilename(fs.Arg(0))
+	}
+	if err != nil {
+		return err
+	}
+
+	var input io.Reader = os.Stdin
+	if fs.Arg(0) != "-" {
+		file, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		input = file
+	}
+
+	report, importErr := importer.Import(ctx, repository.NewPostRepository(env.database), input, opts)
+
+	verb := "Imported"
+	if report.DryRun {
+		verb = "Validated"
+	}
+	fmt.Printf("%s %d of %d row(s), %d failed, %d skipped\n", verb, report.Imported, report.Total, report.Failed, report.Skipped)
+	for _, skipped := range report.SkippedRows {
+		fmt.Printf("  skipped row %d %q: %s\n", skipped.Row, skipped.Title, skipped.Reason)
+	}
+
+	if len(report.Errors) > 0 {
+		out := os.Stderr
+		if *reportPath != "" {
+			file, err := os.Create(*reportPath)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			out = file
+		}
+		if err := importer.WriteErrorsCSV(out, report); err != nil {
+			return err
+		}
+	}
+
+	return importErr
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"time"
+
+	"github.com/gekich/news-app/config"
+	"github.com/gekich/news-app/db"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// command is a newsctl subcommand
+type command struct {
+	summary string
+	run     func(ctx context.Context, env *environment, args []string) error
+}
+
+// environment holds the dependencies shared by all subcommands
+type environment struct {
+	config   config.Config
+	database *mongo.Database
+}
+
+var commands = map[string]command{
+	"migrate":  {summary: "Apply, roll back or list database migrations", run: runMigrate},
+	"export":   {summary: "Export posts as JSON Lines, CSV or a Markdown zip", run: runExport},
+	"fixtures": {summary: "Insert the posts described by a fixture file", run: runFixtures},
+	"import":   {summary: "Import posts from a JSON Lines, CSV or Markdown zip file", run: runImport},
+	"indexes":  {summary: "Create missing indexes on all collections", run: runIndexes},
+	"media":    {summary: "Delete files no post refers to, or resize images missing their copies", run: runMedia},
+	"seed":     {summary: "Seed the database with sample posts", run: runSeed},
+	"site":     {summary: "Render published posts into a static HTML site", run: runSite},
+}
+
+func main() {
+	log.SetFlags(0)
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	connectCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Mongo.Timeout)*time.Second)
+	defer cancel()
+
+	mongoDB, err := db.ConnectMongoDB(cfg.Mongo.URI, connectCtx)
+	if err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+	defer mongoDB.Disconnect(context.Background())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	env := &environment{
+		config:   cfg,
+		database: mongoDB.Database(cfg.Mongo.DB),
+	}
+
+	if err := cmd.run(ctx, env, os.Args[2:]); err != nil {
+		log.Fatalf("%s: %v", os.Args[1], err)
+	}
+}
+
+// usage prints the list of available subcommands
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: newsctl <command> [arguments]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", name, commands[name].summary)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/gekich/news-app/imaging"
+	"github.com/gekich/news-app/media"
+	"github.com/gekich/news-app/models"
+	"github.com/gekich/news-app/repository"
+)
+
+// runMedia handles "newsctl media prune|thumbnails"
+func runMedia(ctx context.Context, env *environment, args []string) error {
+	fs := flag.NewFlagSet("media", flag.ExitOnError)
+	minAge := fs.Duration("min-age", time.Hour, "only prune files uploaded at least this long ago, sparing uploads whose post isn't saved yet")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: newsctl media [-min-age d] prune|thumbnails")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	store, err := openMedia(env)
+	if err != nil {
+		return err
+	}
+	repo := repository.NewPostRepository(env.database)
+
+	var attachments []models.Attachment
+	err = repo.Stream(ctx, repository.PostFilter{}, func(post models.Post) error {
+		attachments = append(attachments, post.Attachments...)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list attachments: %w", err)
+	}
+
+	switch fs.Arg(0) {
+	case "prune":
+		referenced := make(map[string]bool)
+		for _, attachment := range attachments {
+			for _, id := range attachment.MediaIDs() {
+				referenced[id] = true
+			}
+		}
+
+		count, err := media.Prune(ctx, store, referenced, time.Now().Add(-*minAge))
+		if err != nil {
+			return fmt.Errorf("deleted %d file(s) before failing: %w", count, err)
+		}
+		fmt.Printf("Deleted %d orphaned file(s)\n", count)
+	case "thumbnails":
+		// Makes the copies of images whose upload didn't get them, like
+		// those queued when the server stopped
+		processor := imaging.NewProcessor(store, repo, env.config.Media.ImageWidths, env.config.Media.MaxPixels)
+		count := 0
+		for _, attachment := range attachments {
+			if !imaging.Resizable(attachment.ContentType) || len(attachment.Variants) > 0 {
+				continue
+			}
+			if err := processor.Process(ctx, attachment); err != nil {
+				log.Printf("Failed to resize image %s: %v", attachment.ID, err)
+				continue
+			}
+			count++
+		}
+		fmt.Printf("Processed %d image(s)\n", count)
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	return nil
+}
+
+// openMedia returns the media store the configuration selects
+func openMedia(env *environment) (media.Store, error) {
+	store, err := media.NewStore(env.config.Media.Backend, env.database, env.config.Media.Directory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open media store: %w", err)
+	}
+	return store, nil
+}
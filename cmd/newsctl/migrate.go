@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gekich/news-app/migrations"
+	"github.com/gekich/news-app/repository"
+)
+
+// runMigrate handles "newsctl migrate up|down|status"
+func runMigrate(ctx context.Context, env *environment, args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	steps := fs.Int("steps", 1, "number of migrations to roll back with down")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: newsctl migrate [-steps n] up|down|status")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	migrator, err := migrations.NewMigrator(env.database, migrations.All())
+	if err != nil {
+		return err
+	}
+	migrator.WithLockTTL(time.Duration(env.config.Mongo.LockTTL) * time.Second)
+
+	switch fs.Arg(0) {
+	case "up":
+		count, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applied %d migration(s)\n", count)
+	case "down":
+		count, err := migrator.Down(ctx, *steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Rolled back %d migration(s)\n", count)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tAPPLIED\tDESCRIPTION")
+		for _, s := range statuses {
+			applied := "pending"
+			if s.Applied {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, applied, s.Description)
+		}
+		return w.Flush()
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	return nil
+}
+
+// runIndexes handles "newsctl indexes"
+func runIndexes(ctx context.Context, env *environment, args []string) error {
+	postRepo := repository.NewPostRepository(env.database)
+	if err := postRepo.EnsureIndexes(ctx); err != nil {
+		return err
+	}
+
+	fmt.Println("Indexes are up to date")
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/gekich/news-app/repository"
+	"github.com/gekich/news-app/seeder"
+)
+
+// runSeed handles "newsctl seed"
+func runSeed(ctx context.Context, env *environment, args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	mode := fs.String("mode", env.config.App.SeedMode, "seed mode: append, replace or reset")
+	count := fs.Int("count", env.config.App.SeedCount, "number of posts to generate")
+	seed := fs.Int64("seed", 0, "random seed repeating the content of an earlier run, with new IDs and times relative to now (0 picks one at random)")
+	generator := fs.String("generator", env.config.App.SeedGenerator, "post generator: "+strings.Join(seeder.GeneratorNames(), ", "))
+	batchSize := fs.Int("batch", seeder.DefaultBatchSize, "number of posts written per batch")
+	fixtures := fs.String("fixtures", env.config.App.SeedFixtures, "YAML or JSON fixture file loaded in reset mode")
+	fs.Parse(args)
+
+	parsedMode, err := seeder.ParseMode(*mode)
+	if err != nil {
+		return err
+	}
+
+	// Replacing the posts deletes their files too
+	store, err := openMedia(env)
+	if err != nil {
+		return err
+	}
+
+	postRepo := repository.NewPostRepository(env.database)
+	written, err := seeder.Run(ctx, postRepo, seeder.Options{
+		Mode:        parsedMode,
+		Count:       *count,
+		Seed:        *seed,
+		Generator:   *generator,
+		BatchSize:   *batchSize,
+		FixturePath: *fixtures,
+		Media:       store,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Seeded %d post(s) in %s mode\n", written, parsedMode)
+	return nil
+}
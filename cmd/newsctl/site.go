@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/gekich/news-app/feed"
+	"github.com/gekich/news-app/repository"
+	"github.com/gekich/news-app/sitegen"
+	"github.com/gekich/news-app/static"
+	"github.com/gekich/news-app/templates"
+)
+
+// runSite handles "newsctl site"
+func runSite(ctx context.Context, env *environment, args []string) error {
+	fs := flag.NewFlagSet("site", flag.ExitOnError)
+	output := fs.String("o", "public", "output directory")
+	templateDir := fs.String("templates", env.config.App.TemplatesDirectory, "directory of templates overriding the built-in ones")
+	staticDir := fs.String("static", env.config.App.StaticDirectory, "directory of static assets overriding the built-in ones")
+	baseURL := fs.String("base-url", env.config.App.BaseURL, "public URL of the site, used in feeds and the sitemap")
+	pageSize := fs.Int("page-size", env.config.App.PostsPerPage, "number of posts per index page")
+	force := fs.Bool("force", false, "rebuild every page, e.g. after changing the templates")
+	fs.Parse(args)
+
+	assets, err := static.NewAssets(static.Files(*staticDir))
+	if err != nil {
+		return fmt.Errorf("failed to load static files: %w", err)
+	}
+
+	registry, err := templates.Load(*templateDir, assets)
+	if err != nil {
+		return fmt.Errorf("failed to load templates: %w", err)
+	}
+
+	store, err := openMedia(env)
+	if err != nil {
+		return err
+	}
+
+	result, err := sitegen.Build(ctx, repository.NewPostRepository(env.database), registry, sitegen.Options{
+		OutputDir: *output,
+		Assets:    assets,
+		Channel: feed.Channel{
+			Title:       env.config.App.SiteTitle,
+			Description: "Latest posts from " + env.config.App.SiteTitle,
+			BaseURL:     *baseURL,
+		},
+		PageSize:         *pageSize,
+		Force:            *force,
+		Locale:           env.config.App.DefaultLocale,
+		FallbackLanguage: env.config.App.FallbackLanguage,
+		Media:            store,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Built %s: %d post(s), %d written, %d removed; %d index page(s), %d written; %d static file(s) and %d attachment(s) copied\n",
+		*output, result.Posts, result.PostsWritten, result.PostsRemoved, result.Pages, result.PagesWritten, result.StaticFiles, result.MediaFiles)
+	return nil
+}
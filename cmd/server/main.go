@@ -2,17 +2,25 @@ package main
 
 import (
 	"context"
+	"expvar"
 	"fmt"
-	"github.com/gekich/news-app/templates"
 	"log"
 	"net/http"
 	"time"
 
+	"github.com/gekich/news-app/cache"
 	"github.com/gekich/news-app/config"
 	"github.com/gekich/news-app/db"
+	"github.com/gekich/news-app/flash"
 	"github.com/gekich/news-app/handlers"
+	"github.com/gekich/news-app/i18n"
+	"github.com/gekich/news-app/imaging"
+	"github.com/gekich/news-app/media"
+	"github.com/gekich/news-app/migrations"
 	"github.com/gekich/news-app/repository"
 	"github.com/gekich/news-app/router"
+	"github.com/gekich/news-app/static"
+	"github.com/gekich/news-app/templates"
 )
 
 func main() {
@@ -31,10 +39,94 @@ func main() {
 	}
 	defer mongoDB.Disconnect(ctx)
 
-	postRepo := repository.NewPostRepository(mongoDB.Database(cfg.Mongo.DB))
-	postTemplates := templates.PostTemplates()
-	postHandler := handlers.NewPostHandler(postRepo, postTemplates, cfg)
-	r := router.SetupRouter(postHandler, cfg.App.StaticDirectory)
+	database := mongoDB.Database(cfg.Mongo.DB)
+	postRepo := repository.NewPostRepository(database)
+
+	if cfg.Mongo.AutoMigrate {
+		// Migrations and index builds can take longer than connecting on
+		// large collections, so the connect timeout doesn't apply to them
+		migrateCtx := context.Background()
+		migrator, err := migrations.NewMigrator(database, migrations.All())
+		if err != nil {
+			log.Fatalf("Failed to load migrations: %v", err)
+		}
+		migrator.WithLockTTL(time.Duration(cfg.Mongo.LockTTL) * time.Second)
+		if _, err := migrator.Up(migrateCtx); err != nil {
+			log.Fatalf("Failed to apply migrations: %v", err)
+		}
+		if err := postRepo.EnsureIndexes(migrateCtx); err != nil {
+			log.Fatalf("Failed to create indexes: %v", err)
+		}
+	}
+
+	backend, err := cache.NewBackend(cfg.Cache.Backend, cfg.Cache.Size, time.Duration(cfg.Cache.TTL)*time.Second)
+	if err != nil {
+		log.Fatalf("Failed to create cache: %v", err)
+	}
+	// Posts and rendered list pages share the cache, so a write clears both
+	postCache := cache.NewStore(backend)
+	expvar.Publish("cache", expvar.Func(func() any { return postCache.Stats() }))
+
+	var postHandler *handlers.PostHandler
+	var assetHandler http.Handler
+	store := repository.NewCachedPostStore(postRepo, postCache)
+	if cfg.App.Dev {
+		// Templates and assets are read from the source tree on every
+		// request, and pages aren't cached, so edits show up on reload
+		templateDir, staticDir := cfg.App.TemplatesDirectory, cfg.App.StaticDirectory
+		if templateDir == "" {
+			templateDir = "templates"
+		}
+		if staticDir == "" {
+			staticDir = "static"
+		}
+		log.Printf("Development mode: reloading templates from %s and assets from %s\n", templateDir, staticDir)
+
+		reloader := templates.NewReloader(templates.Files(templateDir), nil)
+		if _, err := reloader.Templates(); err != nil {
+			log.Printf("Template error: %v\n", err)
+		}
+		postHandler = handlers.NewPostHandler(store, nil, cfg).WithTemplateSource(reloader)
+		assetHandler = static.DevHandler(static.Files(staticDir))
+	} else {
+		assets, err := static.NewAssets(static.Files(cfg.App.StaticDirectory))
+		if err != nil {
+			log.Fatalf("Failed to load static files: %v", err)
+		}
+		postTemplates, err := templates.Load(cfg.App.TemplatesDirectory, assets)
+		if err != nil {
+			log.Fatalf("Failed to load templates: %v", err)
+		}
+		postHandler = handlers.NewPostHandler(store, postTemplates, cfg).WithCache(postCache)
+		assetHandler = assets.Handler()
+	}
+	flashes, err := flash.NewStore([]byte(cfg.App.SecretKey))
+	if err != nil {
+		log.Fatalf("Failed to create flash store: %v", err)
+	}
+	postHandler.WithFlashes(flashes)
+
+	mediaStore, err := media.NewStore(cfg.Media.Backend, database, cfg.Media.Directory)
+	if err != nil {
+		log.Fatalf("Failed to create media store: %v", err)
+	}
+	// Uploaded images are resized in the background, through the cached
+	// store so that pages show the copies once they are ready
+	images := imaging.NewProcessor(mediaStore, store, cfg.Media.ImageWidths, cfg.Media.MaxPixels)
+	images.Start(cfg.Media.ImageWorkers)
+	postHandler.WithMedia(mediaStore).WithImages(images)
+
+	bundle, err := i18n.New(cfg.App.DefaultLocale)
+	if err != nil {
+		log.Fatalf("Failed to load translations: %v", err)
+	}
+
+	// The metrics are left off unless enabled, as nothing guards /admin
+	var metrics http.Handler
+	if cfg.App.Metrics {
+		metrics = expvar.Handler()
+	}
+	r := router.SetupRouter(postHandler, assetHandler, bundle, metrics)
 
 	serverAddr := fmt.Sprintf("%s:%s", cfg.Server.Host, cfg.Server.Port)
 	log.Printf("Serving at %s\n", serverAddr)
@@ -17,15 +17,53 @@ type Config struct {
 	} `mapstructure:"server"`
 
 	Mongo struct {
-		URI     string `mapstructure:"uri"`
-		DB      string `mapstructure:"db"`
-		Timeout int    `mapstructure:"timeout"`
+		URI         string `mapstructure:"uri"`
+		DB          string `mapstructure:"db"`
+		Timeout     int    `mapstructure:"timeout"`
+		AutoMigrate bool   `mapstructure:"auto_migrate"`
+		LockTTL     int    `mapstructure:"lock_ttl"`
 	} `mapstructure:"mongo"`
 
 	App struct {
-		PostsPerPage    int    `mapstructure:"posts_per_page"`
-		StaticDirectory string `mapstructure:"static_directory"`
+		Dev                bool     `mapstructure:"dev"`
+		PostsPerPage       int      `mapstructure:"posts_per_page"`
+		Pagination         string   `mapstructure:"pagination"`
+		CountPosts         bool     `mapstructure:"count_posts"`
+		Metrics            bool     `mapstructure:"metrics"`
+		StaticDirectory    string   `mapstructure:"static_directory"`
+		TemplatesDirectory string   `mapstructure:"templates_directory"`
+		SeedMode           string   `mapstructure:"seed_mode"`
+		SeedCount          int      `mapstructure:"seed_count"`
+		SeedMaxCount       int      `mapstructure:"seed_max_count"`
+		SeedGenerator      string   `mapstructure:"seed_generator"`
+		SeedFixtures       string   `mapstructure:"seed_fixtures"`
+		ImportMaxBytes     int64    `mapstructure:"import_max_bytes"`
+		ImportMaxFileBytes int64    `mapstructure:"import_max_file_bytes"`
+		BaseURL            string   `mapstructure:"base_url"`
+		SiteTitle          string   `mapstructure:"site_title"`
+		SecretKey          string   `mapstructure:"secret_key"`
+		DefaultLocale      string   `mapstructure:"default_locale"`
+		FallbackLanguage   string   `mapstructure:"fallback_language"`
+		RobotsFile         string   `mapstructure:"robots_file"`
+		RobotsDisallow     []string `mapstructure:"robots_disallow"`
 	} `mapstructure:"app"`
+
+	Cache struct {
+		Backend string `mapstructure:"backend"`
+		Size    int    `mapstructure:"size"`
+		TTL     int    `mapstructure:"ttl"`
+	} `mapstructure:"cache"`
+
+	Media struct {
+		Backend      string   `mapstructure:"backend"`
+		Directory    string   `mapstructure:"directory"`
+		MaxBytes     int64    `mapstructure:"max_bytes"`
+		MaxFiles     int      `mapstructure:"max_files"`
+		AllowedTypes []string `mapstructure:"allowed_types"`
+		MaxPixels    int64    `mapstructure:"max_pixels"`
+		ImageWidths  []int    `mapstructure:"image_widths"`
+		ImageWorkers int      `mapstructure:"image_workers"`
+	} `mapstructure:"media"`
 }
 
 func Load() (Config, error) {
@@ -56,8 +94,40 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("mongo.uri", "mongodb://localhost:27017")
 	v.SetDefault("mongo.db", "news_app")
 	v.SetDefault("mongo.timeout", 10)
+	v.SetDefault("mongo.auto_migrate", true)
+	v.SetDefault("mongo.lock_ttl", 300)
+	v.SetDefault("app.dev", false)
 	v.SetDefault("app.posts_per_page", 12)
-	v.SetDefault("app.static_directory", "static")
+	v.SetDefault("app.pagination", "links")
+	v.SetDefault("app.count_posts", true)
+	v.SetDefault("app.metrics", false)
+	v.SetDefault("app.static_directory", "")
+	v.SetDefault("app.templates_directory", "")
+	v.SetDefault("app.seed_mode", "replace")
+	v.SetDefault("app.seed_count", 10)
+	v.SetDefault("app.seed_max_count", 1000)
+	v.SetDefault("app.seed_generator", "fake")
+	v.SetDefault("app.seed_fixtures", "")
+	v.SetDefault("app.import_max_bytes", 32<<20)
+	v.SetDefault("app.import_max_file_bytes", 4<<20)
+	v.SetDefault("app.base_url", "http://localhost:8080")
+	v.SetDefault("app.site_title", "News App")
+	v.SetDefault("app.secret_key", "")
+	v.SetDefault("app.default_locale", "en")
+	v.SetDefault("app.fallback_language", "")
+	v.SetDefault("app.robots_file", "")
+	v.SetDefault("app.robots_disallow", []string{"/admin/"})
+	v.SetDefault("cache.backend", "memory")
+	v.SetDefault("cache.size", 1000)
+	v.SetDefault("cache.ttl", 60)
+	v.SetDefault("media.backend", "gridfs")
+	v.SetDefault("media.directory", "media")
+	v.SetDefault("media.max_bytes", 10<<20)
+	v.SetDefault("media.max_files", 10)
+	v.SetDefault("media.allowed_types", []string{"image/jpeg", "image/png", "image/gif", "image/webp", "application/pdf"})
+	v.SetDefault("media.max_pixels", 40_000_000)
+	v.SetDefault("media.image_widths", []int{320, 640, 1280})
+	v.SetDefault("media.image_workers", 2)
 }
 
 // isRunningInContainer detects if the app is running inside a container
@@ -40,8 +40,14 @@ func TestLoad(t *testing.T) {
 		assert.Equal(t, "mongodb://localhost:27017", config.Mongo.URI)
 		assert.Equal(t, "news_app", config.Mongo.DB)
 		assert.Equal(t, 10, config.Mongo.Timeout)
+		assert.True(t, config.Mongo.AutoMigrate)
 		assert.Equal(t, 12, config.App.PostsPerPage)
-		assert.Equal(t, "static", config.App.StaticDirectory)
+		assert.Equal(t, "", config.App.StaticDirectory)
+		assert.Equal(t, "", config.App.TemplatesDirectory)
+		assert.Equal(t, "replace", config.App.SeedMode)
+		assert.Equal(t, 10, config.App.SeedCount)
+		assert.Equal(t, 1000, config.App.SeedMaxCount)
+		assert.Equal(t, "fake", config.App.SeedGenerator)
 	})
 
 	t.Run("environment variables override defaults", func(t *testing.T) {
@@ -0,0 +1,61 @@
+package exporter
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gekich/news-app/importer"
+	"github.com/gekich/news-app/models"
+)
+
+// csvHeader uses the column names the importer maps by default
+var csvHeader = []string{"id", "title", "content", "author", "tags", "status", "language", "translation_group", "created_at", "updated_at"}
+
+// csvEncoder writes posts as CSV records
+type csvEncoder struct {
+	writer        *csv.Writer
+	headerWritten bool
+}
+
+func newCSVEncoder(w io.Writer) *csvEncoder {
+	return &csvEncoder{writer: csv.NewWriter(w)}
+}
+
+// Encode writes a post as a CSV record, preceded by the header for the first post
+func (e *csvEncoder) Encode(post models.Post) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	return e.writer.Write([]string{
+		post.ID.Hex(),
+		post.Title,
+		post.Content,
+		post.Author,
+		strings.Join(post.Tags, importer.TagSeparator),
+		post.Status,
+		post.Language,
+		post.TranslationGroup,
+		post.CreatedAt.UTC().Format(time.RFC3339Nano),
+		post.UpdatedAt.UTC().Format(time.RFC3339Nano),
+	})
+}
+
+// Close writes the header if no posts were exported and flushes buffered records
+func (e *csvEncoder) Close() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+func (e *csvEncoder) writeHeader() error {
+	if e.headerWritten {
+		return nil
+	}
+	e.headerWritten = true
+	return e.writer.Write(csvHeader)
+}
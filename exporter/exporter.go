@@ -0,0 +1,139 @@
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gekich/news-app/i18n"
+	"github.com/gekich/news-app/models"
+	"github.com/gekich/news-app/repository"
+)
+
+// Format identifies the layout of an export
+type Format string
+
+const (
+	// FormatJSONL writes one JSON encoded post per line
+	FormatJSONL Format = "jsonl"
+	// FormatCSV writes a CSV file with a header row
+	FormatCSV Format = "csv"
+	// FormatMarkdown writes a zip archive with one Markdown file with YAML frontmatter per post
+	FormatMarkdown Format = "markdown"
+)
+
+// ParseFormat converts a format name into a Format
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "jsonl", "ndjson":
+		return FormatJSONL, nil
+	case "csv":
+		return FormatCSV, nil
+	case "markdown", "md", "zip":
+		return FormatMarkdown, nil
+	default:
+		return "", fmt.Errorf("unsupported export format %q", s)
+	}
+}
+
+// ContentType returns the MIME type of the format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatMarkdown:
+		return "application/zip"
+	default:
+		return "application/x-ndjson"
+	}
+}
+
+// Extension returns the file extension used for the format
+func (f Format) Extension() string {
+	switch f {
+	case FormatCSV:
+		return "csv"
+	case FormatMarkdown:
+		return "zip"
+	default:
+		return "jsonl"
+	}
+}
+
+// Source streams posts matching a filter
+type Source interface {
+	Stream(ctx context.Context, filter repository.PostFilter, fn func(models.Post) error) error
+}
+
+// encoder writes posts one at a time in a particular format
+type encoder interface {
+	Encode(post models.Post) error
+	Close() error
+}
+
+// Export streams every post matching filter from src to w and returns the number of posts written
+func Export(ctx context.Context, src Source, w io.Writer, format Format, filter repository.PostFilter) (int, error) {
+	var enc encoder
+	switch format {
+	case FormatJSONL:
+		enc = newJSONLEncoder(w)
+	case FormatCSV:
+		enc = newCSVEncoder(w)
+	case FormatMarkdown:
+		enc = newMarkdownEncoder(w)
+	default:
+		return 0, fmt.Errorf("unsupported export format %q", format)
+	}
+
+	count := 0
+	err := src.Stream(ctx, filter, func(post models.Post) error {
+		if err := enc.Encode(post); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+
+	return count, enc.Close()
+}
+
+// dateLayout is the format of the from and to filter values
+const dateLayout = "2006-01-02"
+
+// ParseFilter builds a filter from user supplied values. Dates use the
+// YYYY-MM-DD format and both ends of the range are inclusive. Errors are
+// translated by tr.
+func ParseFilter(search, from, to, status string, tr *i18n.Translator) (repository.PostFilter, error) {
+	filter := repository.PostFilter{Search: search}
+
+	if from != "" {
+		t, err := time.Parse(dateLayout, from)
+		if err != nil {
+			return filter, errors.New(tr.T("filter.invalid_from", from))
+		}
+		filter.From = t
+	}
+
+	if to != "" {
+		t, err := time.Parse(dateLayout, to)
+		if err != nil {
+			return filter, errors.New(tr.T("filter.invalid_to", to))
+		}
+		filter.To = t.AddDate(0, 0, 1)
+	}
+
+	switch status {
+	case "", models.StatusPublished, models.StatusDraft:
+		filter.Status = status
+	default:
+		return filter, errors.New(tr.T("filter.invalid_status", status))
+	}
+
+	return filter, nil
+}
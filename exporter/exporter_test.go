@@ -0,0 +1,165 @@
+//go:build unit
+
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gekich/news-app/importer"
+	"github.com/gekich/news-app/models"
+	"github.com/gekich/news-app/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memorySource struct {
+	posts []models.Post
+}
+
+func (s *memorySource) Stream(ctx context.Context, filter repository.PostFilter, fn func(models.Post) error) error {
+	for _, post := range s.posts {
+		if filter.Status != "" && post.Status != filter.Status {
+			continue
+		}
+		if err := fn(post); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type memoryStore struct {
+	posts []models.Post
+}
+
+func (s *memoryStore) CreateMany(ctx context.Context, posts []models.Post) ([]string, error) {
+	s.posts = append(s.posts, posts...)
+	return make([]string, len(posts)), nil
+}
+
+func (s *memoryStore) ExistingSourceGUIDs(ctx context.Context, guids []string) (map[string]bool, error) {
+	return map[string]bool{}, nil
+}
+
+func samplePosts() []models.Post {
+	created := time.Date(2024, 2, 29, 8, 15, 30, 123000000, time.UTC)
+	group := primitive.NewObjectID()
+	return []models.Post{
+		{
+			ID:               group,
+			Language:         "en",
+			TranslationGroup: group.Hex(),
+			Title:            "Leap Day Special: \"Quotes\", commas, and more",
+			Content:          "First paragraph.\n\n## Heading\n\n- item one\n- item two\n---\nNot frontmatter.",
+			Author:           "Chloé Durand",
+			Tags:             []string{"culture", "local"},
+			Status:           models.StatusPublished,
+			CreatedAt:        created,
+			UpdatedAt:        created.Add(time.Hour),
+		},
+		{
+			ID:               primitive.NewObjectID(),
+			Language:         "uk",
+			TranslationGroup: group.Hex(),
+			Title:            "Draft without tags",
+			Content:          "Body of the draft post.",
+			Status:           models.StatusDraft,
+			CreatedAt:        created.Add(-24 * time.Hour),
+			UpdatedAt:        created.Add(-24 * time.Hour),
+		},
+	}
+}
+
+func TestExport_RoundTrip(t *testing.T) {
+	importFormats := map[Format]importer.Format{
+		FormatJSONL:    importer.FormatJSONL,
+		FormatCSV:      importer.FormatCSV,
+		FormatMarkdown: importer.FormatMarkdown,
+	}
+
+	for format, importFormat := range importFormats {
+		t.Run(string(format), func(t *testing.T) {
+			source := &memorySource{posts: samplePosts()}
+
+			var buf bytes.Buffer
+			count, err := Export(context.Background(), source, &buf, format, repository.PostFilter{})
+			require.NoError(t, err)
+			assert.Equal(t, 2, count)
+
+			store := &memoryStore{}
+			report, err := importer.Import(context.Background(), store, bytes.NewReader(buf.Bytes()), importer.Options{Format: importFormat})
+			require.NoError(t, err)
+			assert.Empty(t, report.Errors)
+			require.Len(t, store.posts, 2)
+
+			for i, want := range source.posts {
+				got := store.posts[i]
+				assert.Equal(t, want.Title, got.Title)
+				assert.Equal(t, want.Content, got.Content)
+				assert.Equal(t, want.Author, got.Author)
+				assert.Equal(t, want.Tags, got.Tags)
+				assert.Equal(t, want.Status, got.Status)
+				assert.True(t, want.CreatedAt.Equal(got.CreatedAt), "created_at %v != %v", want.CreatedAt, got.CreatedAt)
+				assert.True(t, want.UpdatedAt.Equal(got.UpdatedAt), "updated_at %v != %v", want.UpdatedAt, got.UpdatedAt)
+				assert.Equal(t, want.Language, got.Language)
+			}
+
+			// The translations stay together in a group of their own
+			assert.NotEmpty(t, store.posts[0].TranslationGroup)
+			assert.NotEqual(t, source.posts[0].TranslationGroup, store.posts[0].TranslationGroup)
+			assert.Equal(t, store.posts[0].TranslationGroup, store.posts[1].TranslationGroup)
+		})
+	}
+}
+
+func TestExport_Filter(t *testing.T) {
+	source := &memorySource{posts: samplePosts()}
+
+	var buf bytes.Buffer
+	count, err := Export(context.Background(), source, &buf, FormatJSONL, repository.PostFilter{Status: models.StatusDraft})
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Contains(t, buf.String(), "Draft without tags")
+}
+
+func TestExport_MarkdownArchive(t *testing.T) {
+	source := &memorySource{posts: samplePosts()}
+
+	var buf bytes.Buffer
+	_, err := Export(context.Background(), source, &buf, FormatMarkdown, repository.PostFilter{})
+	require.NoError(t, err)
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Len(t, archive.File, 2)
+	assert.True(t, strings.HasPrefix(archive.File[0].Name, "2024-02-29-leap-day-special-quotes-commas-and-more-"))
+	assert.True(t, strings.HasSuffix(archive.File[0].Name, ".md"))
+}
+
+func TestExport_EmptyCSVHasHeader(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := Export(context.Background(), &memorySource{}, &buf, FormatCSV, repository.PostFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, "id,title,content,author,tags,status,language,translation_group,created_at,updated_at\n", buf.String())
+}
+
+func TestParseFilter(t *testing.T) {
+	filter, err := ParseFilter("news", "2024-01-01", "2024-01-31", "draft", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "news", filter.Search)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), filter.From)
+	assert.Equal(t, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), filter.To)
+	assert.Equal(t, "draft", filter.Status)
+
+	_, err = ParseFilter("", "01/01/2024", "", "", nil)
+	assert.Error(t, err)
+
+	_, err = ParseFilter("", "", "", "archived", nil)
+	assert.Error(t, err)
+}
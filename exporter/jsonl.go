@@ -0,0 +1,27 @@
+package exporter
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/gekich/news-app/models"
+)
+
+// jsonlEncoder writes one JSON object per line
+type jsonlEncoder struct {
+	encoder *json.Encoder
+}
+
+func newJSONLEncoder(w io.Writer) *jsonlEncoder {
+	return &jsonlEncoder{encoder: json.NewEncoder(w)}
+}
+
+// Encode writes a post followed by a newline
+func (e *jsonlEncoder) Encode(post models.Post) error {
+	return e.encoder.Encode(post)
+}
+
+// Close is a no-op as every line is written immediately
+func (e *jsonlEncoder) Close() error {
+	return nil
+}
@@ -0,0 +1,81 @@
+package exporter
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gekich/news-app/importer"
+	"github.com/gekich/news-app/models"
+	"gopkg.in/yaml.v3"
+)
+
+var nonFilenameChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// markdownEncoder writes each post into its own file of a zip archive
+type markdownEncoder struct {
+	zip *zip.Writer
+}
+
+func newMarkdownEncoder(w io.Writer) *markdownEncoder {
+	return &markdownEncoder{zip: zip.NewWriter(w)}
+}
+
+// Encode adds a Markdown file for the post to the archive
+func (e *markdownEncoder) Encode(post models.Post) error {
+	header := &zip.FileHeader{
+		Name:     markdownFilename(post),
+		Method:   zip.Deflate,
+		Modified: post.UpdatedAt,
+	}
+
+	file, err := e.zip.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+
+	return WriteMarkdown(file, post)
+}
+
+// Close finishes the archive
+func (e *markdownEncoder) Close() error {
+	return e.zip.Close()
+}
+
+// WriteMarkdown writes a post as YAML frontmatter followed by its content, in the layout importer.ParseMarkdown reads
+func WriteMarkdown(w io.Writer, post models.Post) error {
+	frontmatter := importer.Frontmatter{
+		Title:            post.Title,
+		Slug:             post.Slug,
+		Author:           post.Author,
+		Tags:             post.Tags,
+		Status:           post.Status,
+		Language:         post.Language,
+		TranslationGroup: post.TranslationGroup,
+		CreatedAt:        post.CreatedAt.UTC().Format(time.RFC3339Nano),
+		UpdatedAt:        post.UpdatedAt.UTC().Format(time.RFC3339Nano),
+	}
+	if !post.ID.IsZero() {
+		frontmatter.ID = post.ID.Hex()
+	}
+
+	encoded, err := yaml.Marshal(frontmatter)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "---\n%s---\n%s\n", encoded, post.Content)
+	return err
+}
+
+// markdownFilename builds a unique, readable file name such as 2025-01-02-local-festival-<id>.md
+func markdownFilename(post models.Post) string {
+	name := strings.Trim(nonFilenameChars.ReplaceAllString(strings.ToLower(post.Title), "-"), "-")
+	if len(name) > 60 {
+		name = strings.TrimRight(name[:60], "-")
+	}
+	return fmt.Sprintf("%s-%s-%s.md", post.CreatedAt.UTC().Format("2006-01-02"), name, post.ID.Hex())
+}
@@ -0,0 +1,180 @@
+package feed
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gekich/news-app/models"
+)
+
+// DefaultSize is the number of posts included in a feed
+const DefaultSize = 20
+
+// Channel describes the site a feed belongs to
+type Channel struct {
+	Title       string
+	Description string
+	// BaseURL is the absolute URL of the site, without a trailing slash
+	BaseURL string
+	// PostPath returns the path of a post relative to BaseURL, post.Path() when nil
+	PostPath func(post models.Post) string
+}
+
+// PostURL returns the absolute URL of a post
+func (c Channel) PostURL(post models.Post) string {
+	path := post.Path()
+	if c.PostPath != nil {
+		path = c.PostPath(post)
+	}
+	return strings.TrimRight(c.BaseURL, "/") + path
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        string   `xml:"guid"`
+	Author      string   `xml:"author,omitempty"`
+	Categories  []string `xml:"category"`
+	PubDate     string   `xml:"pubDate"`
+	Description string   `xml:"description"`
+}
+
+// WriteRSS writes an RSS 2.0 feed of the given posts, newest first
+func WriteRSS(w io.Writer, channel Channel, posts []models.Post) error {
+	feed := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:       channel.Title,
+			Link:        channel.BaseURL,
+			Description: channel.Description,
+		},
+	}
+	if updated := latest(posts); !updated.IsZero() {
+		feed.Channel.LastBuildDate = updated.Format(time.RFC1123Z)
+	}
+
+	for _, post := range posts {
+		link := channel.PostURL(post)
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:       post.Title,
+			Link:        link,
+			GUID:        link,
+			Author:      post.Author,
+			Categories:  post.Tags,
+			PubDate:     post.CreatedAt.UTC().Format(time.RFC1123Z),
+			Description: post.Content,
+		})
+	}
+
+	return encode(w, feed)
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Links   []atomLink  `xml:"link"`
+	Updated string      `xml:"updated"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Link       atomLink       `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Author     *atomAuthor    `xml:"author,omitempty"`
+	Categories []atomCategory `xml:"category"`
+	Content    atomContent    `xml:"content"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// WriteAtom writes an Atom feed of the given posts, newest first. selfURL is
+// the absolute URL the feed is published at.
+func WriteAtom(w io.Writer, channel Channel, selfURL string, posts []models.Post) error {
+	feed := atomFeed{
+		Title:   channel.Title,
+		ID:      channel.BaseURL + "/",
+		Links:   []atomLink{{Href: channel.BaseURL + "/"}, {Href: selfURL, Rel: "self"}},
+		Updated: latest(posts).UTC().Format(time.RFC3339),
+	}
+
+	for _, post := range posts {
+		link := channel.PostURL(post)
+		entry := atomEntry{
+			Title:     post.Title,
+			ID:        link,
+			Link:      atomLink{Href: link},
+			Published: post.CreatedAt.UTC().Format(time.RFC3339),
+			Updated:   post.UpdatedAt.UTC().Format(time.RFC3339),
+			Content:   atomContent{Type: "text", Body: post.Content},
+		}
+		if post.Author != "" {
+			entry.Author = &atomAuthor{Name: post.Author}
+		}
+		for _, tag := range post.Tags {
+			entry.Categories = append(entry.Categories, atomCategory{Term: tag})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	return encode(w, feed)
+}
+
+// latest returns the most recent update time of the posts
+func latest(posts []models.Post) time.Time {
+	var updated time.Time
+	for _, post := range posts {
+		if post.UpdatedAt.After(updated) {
+			updated = post.UpdatedAt
+		}
+	}
+	return updated
+}
+
+func encode(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,160 @@
+// Package flash carries one-off messages, like the confirmation of a save,
+// from the request that redirects to the page that shows them. Messages are
+// kept in a signed cookie, so they need no server side session.
+package flash
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Levels of flash messages
+const (
+	LevelSuccess = "success"
+	LevelInfo    = "info"
+	LevelWarning = "warning"
+	LevelError   = "error"
+)
+
+// CookieName is the cookie holding the pending messages
+const CookieName = "flash"
+
+// maxMessages caps the messages kept in the cookie, since browsers drop
+// cookies over 4 KB
+const maxMessages = 5
+
+// ErrInvalidCookie is returned for cookies that weren't signed with the
+// store's key or don't decode
+var ErrInvalidCookie = errors.New("invalid flash cookie")
+
+// Message is a flash message
+type Message struct {
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+// Success returns a success message
+func Success(message string) Message { return Message{Level: LevelSuccess, Message: message} }
+
+// Info returns an informational message
+func Info(message string) Message { return Message{Level: LevelInfo, Message: message} }
+
+// Warning returns a warning message
+func Warning(message string) Message { return Message{Level: LevelWarning, Message: message} }
+
+// Error returns an error message
+func Error(message string) Message { return Message{Level: LevelError, Message: message} }
+
+// Store keeps flash messages in a cookie signed with HMAC-SHA256. A nil
+// Store keeps nothing.
+type Store struct {
+	key []byte
+}
+
+// NewStore signs cookies with key. Without a key it signs them with a random
+// one, so messages pending during a restart are dropped, and instances behind
+// a load balancer don't accept each other's cookies.
+func NewStore(key []byte) (*Store, error) {
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+	}
+	return &Store{key: key}, nil
+}
+
+// Add adds messages to the ones pending for the next page. Only the last
+// few messages are kept.
+func (s *Store) Add(w http.ResponseWriter, r *http.Request, messages ...Message) {
+	if s == nil || len(messages) == 0 {
+		return
+	}
+
+	pending, _ := s.read(r)
+	pending = append(pending, messages...)
+	if len(pending) > maxMessages {
+		pending = pending[len(pending)-maxMessages:]
+	}
+
+	payload, err := json.Marshal(pending)
+	if err != nil {
+		return
+	}
+	value := base64.RawURLEncoding.EncodeToString(payload)
+	http.SetCookie(w, s.cookie(r, value+"."+s.sign(value), 0))
+}
+
+// Pending reports whether the request carries messages
+func (s *Store) Pending(r *http.Request) bool {
+	if s == nil {
+		return false
+	}
+	messages, _ := s.read(r)
+	return len(messages) > 0
+}
+
+// Pop returns the messages the request carries and deletes the cookie, so
+// they are shown once. Cookies with a bad signature are deleted too.
+func (s *Store) Pop(w http.ResponseWriter, r *http.Request) []Message {
+	if s == nil {
+		return nil
+	}
+	if _, err := r.Cookie(CookieName); err != nil {
+		return nil
+	}
+
+	messages, _ := s.read(r)
+	http.SetCookie(w, s.cookie(r, "", -1))
+	return messages
+}
+
+// read decodes and verifies the messages of the request's cookie
+func (s *Store) read(r *http.Request) ([]Message, error) {
+	cookie, err := r.Cookie(CookieName)
+	if err != nil {
+		return nil, nil
+	}
+
+	value, signature, ok := strings.Cut(cookie.Value, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(s.sign(value))) {
+		return nil, ErrInvalidCookie
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCookie
+	}
+
+	var messages []Message
+	if err := json.Unmarshal(payload, &messages); err != nil {
+		return nil, ErrInvalidCookie
+	}
+	return messages, nil
+}
+
+// sign returns the signature of a cookie value
+func (s *Store) sign(value string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(CookieName + "=" + value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// cookie returns the flash cookie with value, deleting it when maxAge is
+// negative
+func (s *Store) cookie(r *http.Request, value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     CookieName,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
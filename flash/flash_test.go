@@ -0,0 +1,161 @@
+//go:build unit
+
+package flash
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// carry sends the cookies set by a response along with a new request
+func carry(rr *httptest.ResponseRecorder) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/posts", nil)
+	for _, cookie := range rr.Result().Cookies() {
+		req.AddCookie(cookie)
+	}
+	return req
+}
+
+func newTestStore(t *testing.T, key string) *Store {
+	t.Helper()
+	store, err := NewStore([]byte(key))
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	return store
+}
+
+func TestStore_AddPop(t *testing.T) {
+	store := newTestStore(t, "secret")
+
+	rr := httptest.NewRecorder()
+	store.Add(rr, httptest.NewRequest(http.MethodPost, "/posts", nil), Success("Post created"), Warning("Post is a draft"))
+
+	cookies := rr.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != CookieName {
+		t.Fatalf("expected a %s cookie, but got %v", CookieName, cookies)
+	}
+	if !cookies[0].HttpOnly || cookies[0].SameSite != http.SameSiteLaxMode || cookies[0].Path != "/" {
+		t.Errorf("expected an HttpOnly, SameSite=Lax cookie for the whole site, but got %+v", cookies[0])
+	}
+
+	req := carry(rr)
+	if !store.Pending(req) {
+		t.Error("expected the request to carry pending messages, but it did not")
+	}
+
+	popped := httptest.NewRecorder()
+	messages := store.Pop(popped, req)
+	want := []Message{{Level: LevelSuccess, Message: "Post created"}, {Level: LevelWarning, Message: "Post is a draft"}}
+	if !reflect.DeepEqual(messages, want) {
+		t.Errorf("expected messages %v, but got %v", want, messages)
+	}
+
+	deleted := popped.Result().Cookies()
+	if len(deleted) != 1 || deleted[0].MaxAge >= 0 {
+		t.Errorf("expected Pop to delete the cookie, but got %v", deleted)
+	}
+}
+
+func TestStore_AddKeepsPending(t *testing.T) {
+	store := newTestStore(t, "secret")
+
+	rr := httptest.NewRecorder()
+	store.Add(rr, httptest.NewRequest(http.MethodPost, "/", nil), Info("first"))
+	next := httptest.NewRecorder()
+	store.Add(next, carry(rr), Info("second"))
+
+	messages := store.Pop(httptest.NewRecorder(), carry(next))
+	if len(messages) != 2 || messages[0].Message != "first" || messages[1].Message != "second" {
+		t.Errorf("expected both messages in order, but got %v", messages)
+	}
+}
+
+func TestStore_AddCapsMessages(t *testing.T) {
+	store := newTestStore(t, "secret")
+
+	var messages []Message
+	for _, text := range strings.Split("a b c d e f g", " ") {
+		messages = append(messages, Info(text))
+	}
+	rr := httptest.NewRecorder()
+	store.Add(rr, httptest.NewRequest(http.MethodPost, "/", nil), messages...)
+
+	kept := store.Pop(httptest.NewRecorder(), carry(rr))
+	if len(kept) != maxMessages || kept[0].Message != "c" || kept[len(kept)-1].Message != "g" {
+		t.Errorf("expected the last %d messages, but got %v", maxMessages, kept)
+	}
+}
+
+func TestStore_RejectsForgedCookies(t *testing.T) {
+	store := newTestStore(t, "secret")
+	rr := httptest.NewRecorder()
+	store.Add(rr, httptest.NewRequest(http.MethodPost, "/", nil), Success("Post created"))
+	value := rr.Result().Cookies()[0].Value
+
+	tests := []struct {
+		name  string
+		store *Store
+		value string
+	}{
+		{"other key", newTestStore(t, "other"), value},
+		{"tampered payload", store, "W10" + value},
+		{"missing signature", store, strings.Split(value, ".")[0]},
+		{"garbage", store, "not a cookie"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.AddCookie(&http.Cookie{Name: CookieName, Value: tt.value})
+
+			if _, err := tt.store.read(req); err != ErrInvalidCookie {
+				t.Errorf("expected ErrInvalidCookie, but got %v", err)
+			}
+			if tt.store.Pending(req) {
+				t.Error("expected no pending messages, but got some")
+			}
+
+			popped := httptest.NewRecorder()
+			if messages := tt.store.Pop(popped, req); messages != nil {
+				t.Errorf("expected no messages, but got %v", messages)
+			}
+			if cookies := popped.Result().Cookies(); len(cookies) != 1 || cookies[0].MaxAge >= 0 {
+				t.Errorf("expected the forged cookie to be deleted, but got %v", cookies)
+			}
+		})
+	}
+}
+
+func TestStore_RandomKey(t *testing.T) {
+	first := newTestStore(t, "")
+	second := newTestStore(t, "")
+
+	rr := httptest.NewRecorder()
+	first.Add(rr, httptest.NewRequest(http.MethodPost, "/", nil), Success("saved"))
+
+	if !first.Pending(carry(rr)) {
+		t.Error("expected the store to accept its own cookie, but it did not")
+	}
+	if second.Pending(carry(rr)) {
+		t.Error("expected stores with random keys to reject each other's cookies, but the cookie was accepted")
+	}
+}
+
+func TestStore_Nil(t *testing.T) {
+	var store *Store
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	store.Add(rr, req, Success("saved"))
+
+	if len(rr.Result().Cookies()) != 0 {
+		t.Error("expected a nil store to set no cookie, but it did")
+	}
+	if store.Pending(req) || store.Pop(rr, req) != nil {
+		t.Error("expected a nil store to have no messages, but it had some")
+	}
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gekich/news-app/models"
+	"github.com/gekich/news-app/repository"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Representations of a page. The HTMX partial, the full page and the JSON
+// form of the same resource differ, so they get different entity tags. HTML
+// representations differ by locale as well.
+const (
+	representationPage = "page"
+	representationHTMX = "htmx"
+	representationJSON = "json"
+)
+
+// representation names the form of the response the request asks for
+func representation(r *http.Request) string {
+	if format, _ := r.Context().Value(middleware.URLFormatCtxKey).(string); format == "json" {
+		return representationJSON
+	}
+	rep := representationPage
+	if isHTMXRequest(r) {
+		rep = representationHTMX
+		// Load more fragments are yet another rendering of the list
+		if target := r.Header.Get("HX-Target"); target == loadMoreTarget {
+			rep += ":" + target
+		}
+	}
+	return localized(r, rep)
+}
+
+// localized names an HTML representation in the locale of the request
+func localized(r *http.Request, rep string) string {
+	return rep + ":" + translator(r).Locale()
+}
+
+// entityTag returns a strong entity tag over the given parts
+func entityTag(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// postETag returns the entity tag of a representation of a post, which
+// changes whenever the post is saved, or its translations are added, moved
+// or removed
+func postETag(post models.Post, representation string, translations ...models.Post) string {
+	parts := []string{representation, post.ID.Hex(), strconv.FormatInt(post.UpdatedAt.UnixNano(), 10)}
+	for _, translation := range translations {
+		parts = append(parts, translation.ID.Hex(), translation.Language, translation.Slug, translation.Status)
+	}
+	return entityTag(parts...)
+}
+
+// listETag returns the entity tag of a representation of a page of the post
+// list: its canonical URL and pagination style, and the posts on it
+func listETag(representation, url, pagination string, page repository.PostPage) string {
+	parts := []string{representation, url, pagination, page.NextCursor, page.PrevCursor, strconv.FormatInt(page.Total, 10)}
+	for _, post := range page.Posts {
+		parts = append(parts, post.ID.Hex(), strconv.FormatInt(post.UpdatedAt.UnixNano(), 10))
+	}
+	return entityTag(parts...)
+}
+
+// notModified sets the validators of a GET response and answers 304 Not
+// Modified when the request's If-None-Match, or failing that its
+// If-Modified-Since, shows the client's copy is current. It reports whether
+// the response has been written. A zero lastModified leaves Last-Modified out.
+func notModified(w http.ResponseWriter, r *http.Request, etag string, lastModified time.Time) bool {
+	header := w.Header()
+	header.Set("ETag", etag)
+	if !lastModified.IsZero() {
+		header.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	// Always revalidate, so an edit shows up on the next load
+	header.Set("Cache-Control", "no-cache")
+	header.Add("Vary", "HX-Request, HX-Target")
+
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		if !etagListMatches(match, etag, false) {
+			return false
+		}
+	} else if since := r.Header.Get("If-Modified-Since"); since != "" && !lastModified.IsZero() {
+		t, err := http.ParseTime(since)
+		// HTTP dates have whole seconds
+		if err != nil || lastModified.Truncate(time.Second).After(t) {
+			return false
+		}
+	} else {
+		return false
+	}
+
+	// A 304 carries the validators but no body
+	header.Del("Content-Type")
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// ifMatch checks the If-Match precondition of a request that changes a
+// post. It holds when the header is absent, is "*", or lists the entity tag
+// of a page or HTMX representation of the post as it is now. Otherwise it
+// answers 412 Precondition Failed, or the error looking up the post's
+// translations, and returns false.
+func (h *PostHandler) ifMatch(w http.ResponseWriter, r *http.Request, post models.Post) bool {
+	match := r.Header.Get("If-Match")
+	if match == "" {
+		return true
+	}
+
+	translations, err := h.translations(r.Context(), post)
+	if err != nil {
+		h.handleError(w, r, err, "Failed to fetch translations")
+		return false
+	}
+	for _, representation := range []string{representationPage, representationHTMX} {
+		if etagListMatches(match, postETag(post, localized(r, representation), translations...), true) {
+			return true
+		}
+	}
+
+	preconditionFailed(w, r)
+	return false
+}
+
+// preconditionFailed answers a request whose If-Match no longer holds
+func preconditionFailed(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, translator(r).T("error.version_conflict"), http.StatusPreconditionFailed)
+}
+
+// etagListMatches reports whether a comma-separated If-Match or
+// If-None-Match value lists etag. Strong comparison, required by If-Match,
+// never matches weak tags; weak comparison ignores the W/ prefix.
+func etagListMatches(list, etag string, strong bool) bool {
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.HasPrefix(candidate, "W/") {
+			if strong {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,198 @@
+//go:build unit
+
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gekich/news-app/config"
+	"github.com/gekich/news-app/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// conditionalRouter routes the post handlers the way the application router does
+func conditionalRouter(handler *PostHandler) http.Handler {
+	r := chi.NewRouter()
+	r.Use(middleware.URLFormat)
+	r.Get("/posts", handler.Index)
+	r.Get("/posts/{id}", handler.Show)
+	r.Put("/posts/{id}", handler.Update)
+	r.Delete("/posts/{id}", handler.Delete)
+	return r
+}
+
+func serveConditional(router http.Handler, method, target string, body url.Values, headers map[string]string) *httptest.ResponseRecorder {
+	var req *http.Request
+	if body != nil {
+		req = httptest.NewRequest(method, target, bytes.NewBufferString(body.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		req = httptest.NewRequest(method, target, nil)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestPostHandler_ShowConditional(t *testing.T) {
+	mockRepo := NewMockPostRepository()
+	ids := loadFixtures(t, mockRepo)
+	cfg, _ := config.Load()
+	router := conditionalRouter(NewPostHandler(mockRepo, createMockTemplates(), cfg))
+	post := mockRepo.posts[ids["festival"]]
+	target := "/posts/" + post.Slug
+
+	rr := serveConditional(router, http.MethodGet, target, nil, nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	etag := rr.Header().Get("ETag")
+	lastModified := rr.Header().Get("Last-Modified")
+	if etag == "" || lastModified != post.UpdatedAt.UTC().Format(http.TimeFormat) {
+		t.Fatalf("Expected an ETag and Last-Modified %q, got %q and %q", post.UpdatedAt.UTC().Format(http.TimeFormat), etag, lastModified)
+	}
+
+	rr = serveConditional(router, http.MethodGet, target, nil, map[string]string{"If-None-Match": etag})
+	if rr.Code != http.StatusNotModified || rr.Body.Len() != 0 {
+		t.Errorf("Expected an empty %d for a matching If-None-Match, got %d with %q", http.StatusNotModified, rr.Code, rr.Body.String())
+	}
+
+	rr = serveConditional(router, http.MethodGet, target, nil, map[string]string{"If-None-Match": "W/" + etag})
+	if rr.Code != http.StatusNotModified {
+		t.Errorf("Expected If-None-Match to compare weakly, got %d", rr.Code)
+	}
+
+	rr = serveConditional(router, http.MethodGet, target, nil, map[string]string{"If-Modified-Since": lastModified})
+	if rr.Code != http.StatusNotModified {
+		t.Errorf("Expected status %d for If-Modified-Since, got %d", http.StatusNotModified, rr.Code)
+	}
+
+	// The HTMX partial is a different representation
+	rr = serveConditional(router, http.MethodGet, target, nil, map[string]string{"HX-Request": "true", "If-None-Match": etag})
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected the full page ETag not to match the HTMX partial, got %d", rr.Code)
+	}
+	if htmxETag := rr.Header().Get("ETag"); htmxETag == "" || htmxETag == etag {
+		t.Errorf("Expected a distinct HTMX ETag, got %q", htmxETag)
+	}
+
+	// Saving the post changes its ETag
+	post.Content = "Updated content"
+	post.UpdatedAt = post.UpdatedAt.Add(time.Minute)
+	mockRepo.posts[ids["festival"]] = post
+
+	rr = serveConditional(router, http.MethodGet, target, nil, map[string]string{"If-None-Match": etag})
+	if rr.Code != http.StatusOK || rr.Header().Get("ETag") == etag {
+		t.Errorf("Expected a fresh response with a new ETag after the update, got %d with %q", rr.Code, rr.Header().Get("ETag"))
+	}
+	rr = serveConditional(router, http.MethodGet, target, nil, map[string]string{"If-Modified-Since": lastModified})
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected a fresh response for an outdated If-Modified-Since, got %d", rr.Code)
+	}
+}
+
+func TestPostHandler_IndexConditional(t *testing.T) {
+	mockRepo := NewMockPostRepository()
+	loadFixtures(t, mockRepo)
+	cfg, _ := config.Load()
+	router := conditionalRouter(NewPostHandler(mockRepo, createMockTemplates(), cfg))
+
+	etags := make(map[string]string)
+	for _, request := range []struct {
+		name    string
+		target  string
+		headers map[string]string
+	}{
+		{"page", "/posts", nil},
+		{"htmx", "/posts", map[string]string{"HX-Request": "true"}},
+		{"json", "/posts.json", nil},
+		{"sorted", "/posts?sort=oldest", nil},
+	} {
+		rr := serveConditional(router, http.MethodGet, request.target, nil, request.headers)
+		etag := rr.Header().Get("ETag")
+		if rr.Code != http.StatusOK || etag == "" {
+			t.Fatalf("Expected status %d with an ETag for %s, got %d", http.StatusOK, request.name, rr.Code)
+		}
+		if rr.Header().Get("Last-Modified") != "" {
+			t.Errorf("Expected no Last-Modified on the %s list", request.name)
+		}
+		for name, other := range etags {
+			if other == etag {
+				t.Errorf("Expected %s and %s to have distinct ETags", name, request.name)
+			}
+		}
+		etags[request.name] = etag
+	}
+
+	rr := serveConditional(router, http.MethodGet, "/posts", nil, map[string]string{"If-None-Match": etags["page"]})
+	if rr.Code != http.StatusNotModified {
+		t.Errorf("Expected status %d for an unchanged list, got %d", http.StatusNotModified, rr.Code)
+	}
+
+	if _, err := mockRepo.Create(context.Background(), models.Post{Title: "Breaking News", Content: "Content"}); err != nil {
+		t.Fatalf("Failed to create post: %v", err)
+	}
+	rr = serveConditional(router, http.MethodGet, "/posts", nil, map[string]string{"If-None-Match": etags["page"]})
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected a fresh list after a new post, got %d", rr.Code)
+	}
+}
+
+func TestPostHandler_IfMatch(t *testing.T) {
+	mockRepo := NewMockPostRepository()
+	ids := loadFixtures(t, mockRepo)
+	cfg, _ := config.Load()
+	router := conditionalRouter(NewPostHandler(mockRepo, createMockTemplates(), cfg))
+	id := ids["festival"]
+
+	etag := serveConditional(router, http.MethodGet, "/posts/"+mockRepo.posts[id].Slug, nil, nil).Header().Get("ETag")
+	form := url.Values{"title": {"Local Community Celebrates Annual Festival"}, "content": {"First edit"}}
+
+	rr := serveConditional(router, http.MethodPut, "/posts/"+id, form, map[string]string{"If-Match": etag})
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("Expected status %d for a matching If-Match, got %d", http.StatusSeeOther, rr.Code)
+	}
+
+	// The tag is stale now
+	form.Set("content", "Second edit")
+	rr = serveConditional(router, http.MethodPut, "/posts/"+id, form, map[string]string{"If-Match": etag})
+	if rr.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected status %d for a stale If-Match, got %d", http.StatusPreconditionFailed, rr.Code)
+	}
+	if content := mockRepo.posts[id].Content; content != "First edit" {
+		t.Errorf("Expected the stale update to be rejected, got %q", content)
+	}
+
+	rr = serveConditional(router, http.MethodPut, "/posts/"+id, form, map[string]string{"If-Match": "W/" + etag})
+	if rr.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected weak tags never to satisfy If-Match, got %d", rr.Code)
+	}
+
+	rr = serveConditional(router, http.MethodDelete, "/posts/"+id, nil, map[string]string{"If-Match": etag})
+	if rr.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected status %d for deleting with a stale If-Match, got %d", http.StatusPreconditionFailed, rr.Code)
+	}
+	if _, exists := mockRepo.posts[id]; !exists {
+		t.Fatalf("Expected the post to survive a stale delete")
+	}
+
+	etag = serveConditional(router, http.MethodGet, "/posts/"+mockRepo.posts[id].Slug, nil, map[string]string{"HX-Request": "true"}).Header().Get("ETag")
+	rr = serveConditional(router, http.MethodDelete, "/posts/"+id, nil, map[string]string{"If-Match": etag})
+	if rr.Code != http.StatusSeeOther {
+		t.Errorf("Expected status %d for deleting with the current HTMX ETag, got %d", http.StatusSeeOther, rr.Code)
+	}
+	if _, exists := mockRepo.posts[id]; exists {
+		t.Errorf("Expected the post to be deleted")
+	}
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gekich/news-app/apperr"
+	"github.com/gekich/news-app/i18n"
+	"github.com/gekich/news-app/imaging"
+	"github.com/gekich/news-app/media"
+	"github.com/gekich/news-app/repository"
+	"github.com/gekich/news-app/templates"
+	"github.com/gekich/news-app/views"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// errorPageHeader marks error pages, which the layout swaps into the page
+// although HTMX discards error responses by default
+const errorPageHeader = "X-Error-Page"
+
+// handleError answers a request that failed with err. Errors of the
+// repository get their status, and errors that aren't an *apperr.Error are
+// internal errors shown with message.
+func (h *PostHandler) handleError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	h.renderError(w, r, classify(translator(r), err, message))
+}
+
+// classify maps err to an application error, with the messages of known
+// errors translated by tr
+func classify(tr *i18n.Translator, err error, message string) *apperr.Error {
+	if appErr, ok := apperr.As(err); ok {
+		return appErr
+	}
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return apperr.NotFound(tr.T("error.post_not_found"))
+	case errors.Is(err, media.ErrNotFound):
+		return apperr.NotFound(tr.T("error.file_not_found"))
+	case errors.Is(err, imaging.ErrMalformed):
+		return apperr.BadRequest(tr.T("error.image_damaged"), err)
+	case errors.Is(err, repository.ErrInvalidCursor):
+		return apperr.BadRequest(tr.T("error.invalid_cursor"), err)
+	case errors.Is(err, repository.ErrVersionConflict):
+		return apperr.Conflict(tr.T("error.version_conflict"), err)
+	}
+	return apperr.Internal(message, err)
+}
+
+// renderError answers with the error page, in the layout for full page
+// requests. HTMX requests get the page's content block, retargeted to
+// replace the content whatever the request targeted. Requests for JSON get
+// the message as JSON, and other formats like sitemaps as plain text.
+func (h *PostHandler) renderError(w http.ResponseWriter, r *http.Request, appErr *apperr.Error) {
+	if appErr.Status >= http.StatusInternalServerError {
+		log.Printf("%s %s: %v", r.Method, r.URL.Path, appErr)
+	}
+
+	format, _ := r.Context().Value(middleware.URLFormatCtxKey).(string)
+	switch format {
+	case "":
+	case "json":
+		writeJSONError(w, appErr)
+		return
+	default:
+		http.Error(w, appErr.Message, appErr.Status)
+		return
+	}
+
+	page := &views.ErrorPage{
+		Status:  appErr.Status,
+		Title:   errorTitle(r, appErr),
+		Message: appErr.Message,
+	}
+	*page.LayoutData() = h.layout(w, r)
+
+	var buf bytes.Buffer
+	if err := h.executeTemplate(&buf, r, templates.ErrorPage, page); err != nil {
+		// Without a working error page, like when the layout is broken
+		http.Error(w, appErr.Message, appErr.Status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if isHTMXRequest(r) {
+		w.Header().Set(errorPageHeader, "true")
+		w.Header().Set("HX-Retarget", "#content")
+		w.Header().Set("HX-Reswap", "innerHTML")
+	}
+	w.WriteHeader(appErr.Status)
+	buf.WriteTo(w)
+}
+
+// errorTitle returns the heading of the error page in the locale of the
+// request, falling back to the English one of statuses without a message
+func errorTitle(r *http.Request, appErr *apperr.Error) string {
+	if title, ok := translator(r).Lookup(fmt.Sprintf("error.%d", appErr.Status)); ok {
+		return title
+	}
+	return appErr.Title()
+}
+
+// writeJSONError answers API requests with the message of the error
+func writeJSONError(w http.ResponseWriter, appErr *apperr.Error) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(appErr.Status)
+	json.NewEncoder(w).Encode(map[string]string{"error": appErr.Message})
+}
+
+// NotFound answers requests for paths no route matches
+func (h *PostHandler) NotFound(w http.ResponseWriter, r *http.Request) {
+	h.renderError(w, r, apperr.NotFound(translator(r).T("error.page_not_found")))
+}
+
+// MethodNotAllowed answers requests whose path has no route for the method
+func (h *PostHandler) MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	h.renderError(w, r, apperr.New(http.StatusMethodNotAllowed, translator(r).T("error.method_not_allowed", r.Method), nil))
+}
@@ -0,0 +1,213 @@
+//go:build unit
+
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gekich/news-app/apperr"
+	"github.com/gekich/news-app/config"
+	"github.com/gekich/news-app/repository"
+	"github.com/gekich/news-app/templates"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// errorTemplates are the mock templates with an error page, whose layout
+// wraps the content block in <layout>
+func errorTemplates() *templates.Registry {
+	pages := mockTemplatePages()
+	pages[templates.ErrorPage] = template.Must(template.New("error").Parse(`
+		{{define "content"}}[{{.Status}}] {{.Title}}: {{.Message}}{{end}}
+		<layout>{{template "content" .}}</layout>`))
+	return templates.NewRegistryFrom(pages)
+}
+
+// errorRouter routes the post handlers with the error pages of the router
+func errorRouter(registry *templates.Registry) (http.Handler, *MockPostRepository) {
+	mockRepo := NewMockPostRepository()
+	cfg, _ := config.Load()
+	handler := NewPostHandler(mockRepo, registry, cfg)
+
+	r := chi.NewRouter()
+	r.Use(middleware.URLFormat)
+	r.NotFound(handler.NotFound)
+	r.MethodNotAllowed(handler.MethodNotAllowed)
+	r.Get("/posts", handler.Index)
+	r.Get("/posts/{id}", handler.Show)
+	r.Put("/posts/{id}", handler.Update)
+	return r, mockRepo
+}
+
+func TestPostHandler_ErrorPage(t *testing.T) {
+	router, mockRepo := errorRouter(errorTemplates())
+	loadFixtures(t, mockRepo)
+
+	t.Run("full page in the layout", func(t *testing.T) {
+		rr := serveConditional(router, http.MethodGet, "/posts/no-such-post", nil, nil)
+
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, rr.Code)
+		}
+		if body := rr.Body.String(); !strings.Contains(body, "<layout>[404] Page not found: Post not found</layout>") {
+			t.Errorf("Expected the error page in the layout, got %q", body)
+		}
+		if rr.Header().Get(errorPageHeader) != "" || rr.Header().Get("HX-Retarget") != "" {
+			t.Errorf("Expected no HTMX headers for a full page, got %v", rr.Header())
+		}
+	})
+
+	t.Run("HTMX partial retargeted to the content", func(t *testing.T) {
+		rr := serveConditional(router, http.MethodGet, "/posts/no-such-post", nil, map[string]string{"HX-Request": "true"})
+
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, rr.Code)
+		}
+		if body := rr.Body.String(); strings.Contains(body, "<layout>") || !strings.Contains(body, "[404] Page not found") {
+			t.Errorf("Expected the content block of the error page, got %q", body)
+		}
+		for header, want := range map[string]string{errorPageHeader: "true", "HX-Retarget": "#content", "HX-Reswap": "innerHTML"} {
+			if got := rr.Header().Get(header); got != want {
+				t.Errorf("Expected %s %q, got %q", header, want, got)
+			}
+		}
+	})
+
+	t.Run("invalid ObjectID", func(t *testing.T) {
+		rr := serveConditional(router, http.MethodPut, "/posts/not-an-id", nil, nil)
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, rr.Code)
+		}
+	})
+
+	t.Run("internal errors hide the cause", func(t *testing.T) {
+		mockRepo.SetShouldFail(true)
+		defer mockRepo.SetShouldFail(false)
+
+		rr := serveConditional(router, http.MethodGet, "/posts/no-such-post", nil, nil)
+		if rr.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, rr.Code)
+		}
+		body := rr.Body.String()
+		if !strings.Contains(body, "Something went wrong: Failed to fetch post") || strings.Contains(body, "mock error") {
+			t.Errorf("Expected the message without the cause, got %q", body)
+		}
+	})
+}
+
+func TestPostHandler_ErrorFormats(t *testing.T) {
+	router, mockRepo := errorRouter(errorTemplates())
+	post, _ := mockRepo.FindByID(context.Background(), loadFixtures(t, mockRepo)["festival"])
+
+	t.Run("JSON", func(t *testing.T) {
+		rr := serveConditional(router, http.MethodGet, "/posts.json?sort=sideways", nil, nil)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+		if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+			t.Errorf("Expected a JSON content type, got %q", ct)
+		}
+		var body map[string]string
+		if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil || body["error"] == "" {
+			t.Errorf("Expected an error message as JSON, got %q (%v)", rr.Body.String(), err)
+		}
+	})
+
+	t.Run("other formats", func(t *testing.T) {
+		rr := serveConditional(router, http.MethodGet, "/posts.xml", nil, nil)
+
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, rr.Code)
+		}
+		if strings.Contains(rr.Body.String(), "<layout>") {
+			t.Errorf("Expected a plain text error, got %q", rr.Body.String())
+		}
+	})
+
+	t.Run("post in another format", func(t *testing.T) {
+		if rr := serveConditional(router, http.MethodGet, post.Path(), nil, nil); rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d for the page, got %d", http.StatusOK, rr.Code)
+		}
+		for _, format := range []string{"json", "xml"} {
+			rr := serveConditional(router, http.MethodGet, post.Path()+"."+format, nil, nil)
+
+			if rr.Code != http.StatusNotFound {
+				t.Errorf("Expected status %d for .%s, got %d", http.StatusNotFound, format, rr.Code)
+			}
+			if strings.Contains(rr.Body.String(), "Post:") {
+				t.Errorf("Expected no post page for .%s, got %q", format, rr.Body.String())
+			}
+		}
+	})
+
+	t.Run("without an error page", func(t *testing.T) {
+		router, _ := errorRouter(createMockTemplates())
+		rr := serveConditional(router, http.MethodGet, "/posts/no-such-post", nil, nil)
+
+		if rr.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, rr.Code)
+		}
+		if body := strings.TrimSpace(rr.Body.String()); body != "Post not found" {
+			t.Errorf("Expected the plain message, got %q", body)
+		}
+	})
+}
+
+func TestPostHandler_RouterErrors(t *testing.T) {
+	router, _ := errorRouter(errorTemplates())
+
+	tests := []struct {
+		method string
+		target string
+		status int
+	}{
+		{http.MethodGet, "/no-such-page", http.StatusNotFound},
+		{http.MethodDelete, "/posts", http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			rr := serveConditional(router, tt.method, tt.target, nil, nil)
+
+			if rr.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rr.Code)
+			}
+			if want := fmt.Sprintf("<layout>[%d]", tt.status); !strings.Contains(rr.Body.String(), want) {
+				t.Errorf("Expected the error page, got %q", rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"application error", apperr.Forbidden("No"), http.StatusForbidden},
+		{"wrapped application error", fmt.Errorf("saving: %w", apperr.Invalid("Bad")), http.StatusUnprocessableEntity},
+		{"missing document", fmt.Errorf("finding: %w", mongo.ErrNoDocuments), http.StatusNotFound},
+		{"invalid cursor", repository.ErrInvalidCursor, http.StatusBadRequest},
+		{"version conflict", repository.ErrVersionConflict, http.StatusConflict},
+		{"other", errors.New("connection refused"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			appErr := classify(nil, tt.err, "Failed")
+			if appErr.Status != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, appErr.Status)
+			}
+		})
+	}
+}
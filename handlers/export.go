@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gekich/news-app/apperr"
+	"github.com/gekich/news-app/exporter"
+)
+
+func (h *PostHandler) Export(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	formatName := query.Get("format")
+	if formatName == "" {
+		formatName = string(exporter.FormatJSONL)
+	}
+	format, err := exporter.ParseFormat(formatName)
+	if err != nil {
+		h.renderError(w, r, apperr.BadRequest(err.Error(), err))
+		return
+	}
+
+	filter, err := exporter.ParseFilter(query.Get("search"), query.Get("from"), query.Get("to"), query.Get("status"), translator(r))
+	if err != nil {
+		h.renderError(w, r, apperr.BadRequest(err.Error(), err))
+		return
+	}
+
+	filename := fmt.Sprintf("posts-%s.%s", time.Now().Format("20060102-150405"), format.Extension())
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	// The response is already streaming, so a failure part way through can only be logged
+	if _, err := exporter.Export(r.Context(), h.repo, w, format, filter); err != nil {
+		log.Printf("Export failed: %v", err)
+	}
+}
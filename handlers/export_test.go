@@ -0,0 +1,80 @@
+//go:build unit
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gekich/news-app/config"
+)
+
+func TestPostHandler_Export(t *testing.T) {
+	tests := []struct {
+		name                string
+		query               string
+		expectedStatus      int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			name:                "default format is JSON Lines",
+			query:               "",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/x-ndjson",
+			expectedBody:        "Water Vapor Found on Nearby Exoplanet",
+		},
+		{
+			name:                "csv",
+			query:               "?format=csv",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedBody:        "id,title,content",
+		},
+		{
+			name:                "markdown zip",
+			query:               "?format=markdown",
+			expectedStatus:      http.StatusOK,
+			expectedContentType: "application/zip",
+		},
+		{
+			name:           "unknown format",
+			query:          "?format=xml",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid date",
+			query:          "?from=yesterday",
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := NewMockPostRepository()
+			loadFixtures(t, mockRepo)
+			cfg, _ := config.Load()
+			handler := NewPostHandler(mockRepo, createMockTemplates(), cfg)
+
+			req := httptest.NewRequest("GET", "/admin/export"+tt.query, nil)
+			rr := httptest.NewRecorder()
+
+			handler.Export(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if tt.expectedContentType != "" && rr.Header().Get("Content-Type") != tt.expectedContentType {
+				t.Errorf("Expected content type %q, got %q", tt.expectedContentType, rr.Header().Get("Content-Type"))
+			}
+			if tt.expectedStatus == http.StatusOK && !strings.HasPrefix(rr.Header().Get("Content-Disposition"), "attachment;") {
+				t.Errorf("Expected an attachment, got %q", rr.Header().Get("Content-Disposition"))
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("Expected body to contain %q", tt.expectedBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gekich/news-app/flash"
+	custom "github.com/gekich/news-app/middleware"
+	"github.com/gekich/news-app/views"
+)
+
+// flashEvent is the event HTMX responses trigger through HX-Trigger to show
+// flash messages as toasts
+const flashEvent = "flash"
+
+// WithFlashes keeps flash messages for the page a request redirects to in
+// store. Without it messages are only shown on HTMX swaps.
+func (h *PostHandler) WithFlashes(store *flash.Store) *PostHandler {
+	h.flashes = store
+	return h
+}
+
+// flashRedirect redirects to url, showing message on the page there. HTMX
+// requests are redirected with a full page load too, so the layout shows it.
+func (h *PostHandler) flashRedirect(w http.ResponseWriter, r *http.Request, url string, message flash.Message) {
+	h.flashes.Add(w, r, message)
+	h.redirectResponse(w, r, url)
+}
+
+// triggerFlash shows messages on the page an HTMX response is swapped into
+func triggerFlash(w http.ResponseWriter, messages ...flash.Message) {
+	if len(messages) == 0 {
+		return
+	}
+	trigger, err := json.Marshal(map[string]interface{}{
+		flashEvent: map[string]interface{}{"messages": messages},
+	})
+	if err != nil {
+		return
+	}
+	w.Header().Set("HX-Trigger", string(trigger))
+}
+
+// layout returns the layout data shared by every page of the request. It
+// takes the pending flash messages, which HTMX responses trigger as events
+// since swapped content has no layout to show them in.
+func (h *PostHandler) layout(w http.ResponseWriter, r *http.Request) views.Layout {
+	tr := translator(r)
+	layout := views.Layout{
+		SiteTitle: h.config.App.SiteTitle,
+		Locale:    tr.Locale(),
+		Locales:   localeLinks(r, tr.Locales()),
+	}
+
+	messages := h.flashes.Pop(w, r)
+	if isHTMXRequest(r) {
+		triggerFlash(w, messages...)
+		return layout
+	}
+	for _, message := range messages {
+		layout.Flashes = append(layout.Flashes, views.Flash{Level: message.Level, Message: message.Message})
+	}
+	return layout
+}
+
+// localeLinks returns the links switching the requested page to each of
+// locales, keeping its query apart from the locale parameter
+func localeLinks(r *http.Request, locales []string) []views.LocaleLink {
+	links := make([]views.LocaleLink, 0, len(locales))
+	for _, locale := range locales {
+		query := r.URL.Query()
+		query.Set(custom.LocaleParam, locale)
+		links = append(links, views.LocaleLink{Locale: locale, URL: r.URL.Path + "?" + query.Encode()})
+	}
+	return links
+}
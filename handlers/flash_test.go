@@ -0,0 +1,272 @@
+//go:build unit
+
+package handlers
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gekich/news-app/config"
+	"github.com/gekich/news-app/flash"
+	"github.com/gekich/news-app/templates"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// flashTemplates render the flash messages of the layout
+func flashTemplates() *templates.Registry {
+	layout := `{{define "flashes"}}{{range .Flashes}}[{{.Level}}] {{.Message}};{{end}}{{end}}`
+	return templates.NewRegistryFrom(map[templates.Page]*template.Template{
+		templates.PostList: template.Must(template.New("post_list").Parse(layout + `
+			{{define "content"}}Posts: {{len .Posts}}{{end}}
+			{{template "flashes" .}}Posts: {{len .Posts}}`)),
+		templates.PostShow: template.Must(template.New("show").Parse(layout + `
+			{{define "content"}}Post: {{.Post.Title}}{{end}}
+			{{template "flashes" .}}Post: {{.Post.Title}}`)),
+		templates.PostForm: template.Must(template.New("form").Parse(layout + `
+			{{define "content"}}{{template "flashes" .}}Form: {{.Post.Title}}{{end}}
+			{{template "flashes" .}}Form: {{.Post.Title}}`)),
+	})
+}
+
+// flashRouter routes the post handlers with flash messages enabled
+func flashRouter(t *testing.T) (http.Handler, *MockPostRepository) {
+	t.Helper()
+
+	store, err := flash.NewStore([]byte("secret"))
+	if err != nil {
+		t.Fatalf("Failed to create flash store: %v", err)
+	}
+	mockRepo := NewMockPostRepository()
+	cfg, _ := config.Load()
+	handler := NewPostHandler(mockRepo, flashTemplates(), cfg).WithFlashes(store)
+
+	r := chi.NewRouter()
+	r.Use(middleware.URLFormat)
+	r.Get("/posts", handler.Index)
+	r.Post("/posts", handler.Create)
+	r.Post("/posts/seed", handler.Seed)
+	r.Get("/posts/{id}", handler.Show)
+	r.Put("/posts/{id}", handler.Update)
+	r.Delete("/posts/{id}", handler.Delete)
+	return r, mockRepo
+}
+
+// withCookies sends the cookies set by a response along with a request
+func withCookies(rr *httptest.ResponseRecorder) map[string]string {
+	var cookies []string
+	for _, cookie := range rr.Result().Cookies() {
+		cookies = append(cookies, cookie.Name+"="+cookie.Value)
+	}
+	return map[string]string{"Cookie": strings.Join(cookies, "; ")}
+}
+
+// flashCookie returns the flash cookie set by a response, if any
+func flashCookie(rr *httptest.ResponseRecorder) *http.Cookie {
+	for _, cookie := range rr.Result().Cookies() {
+		if cookie.Name == flash.CookieName {
+			return cookie
+		}
+	}
+	return nil
+}
+
+// triggeredFlashes decodes the flash messages of an HX-Trigger header
+func triggeredFlashes(t *testing.T, rr *httptest.ResponseRecorder) []flash.Message {
+	t.Helper()
+
+	header := rr.Header().Get("HX-Trigger")
+	if header == "" {
+		return nil
+	}
+	var trigger map[string]struct {
+		Messages []flash.Message `json:"messages"`
+	}
+	if err := json.Unmarshal([]byte(header), &trigger); err != nil {
+		t.Fatalf("Failed to decode HX-Trigger %q: %v", header, err)
+	}
+	return trigger[flashEvent].Messages
+}
+
+func TestPostHandler_FlashAfterRedirect(t *testing.T) {
+	router, _ := flashRouter(t)
+
+	form := url.Values{"title": {"Flash News"}, "content": {"Something happened today."}}
+	created := serveConditional(router, http.MethodPost, "/posts", form, nil)
+	if created.Code != http.StatusSeeOther {
+		t.Fatalf("Expected status %d, got %d", http.StatusSeeOther, created.Code)
+	}
+	if flashCookie(created) == nil {
+		t.Fatal("Expected the redirect to set a flash cookie, but it did not")
+	}
+
+	shown := serveConditional(router, http.MethodGet, "/posts", nil, withCookies(created))
+	if !strings.Contains(shown.Body.String(), "[success] Post created;") {
+		t.Errorf("Expected the page to show the flash message, got %q", shown.Body.String())
+	}
+	if cookie := flashCookie(shown); cookie == nil || cookie.MaxAge >= 0 {
+		t.Errorf("Expected the page to delete the flash cookie, got %v", cookie)
+	}
+
+	again := serveConditional(router, http.MethodGet, "/posts", nil, nil)
+	if strings.Contains(again.Body.String(), "Post created") {
+		t.Errorf("Expected the message to be shown once, got %q", again.Body.String())
+	}
+}
+
+func TestPostHandler_FlashHTMXRedirect(t *testing.T) {
+	router, mockRepo := flashRouter(t)
+	id := loadFixtures(t, mockRepo)["festival"]
+
+	deleted := serveConditional(router, http.MethodDelete, "/posts/"+id, nil, map[string]string{"HX-Request": "true"})
+	if deleted.Header().Get("HX-Redirect") != "/posts" {
+		t.Fatalf("Expected an HX-Redirect to /posts, got %q", deleted.Header().Get("HX-Redirect"))
+	}
+
+	// HX-Redirect loads the page in full, which shows the message
+	shown := serveConditional(router, http.MethodGet, "/posts", nil, withCookies(deleted))
+	if !strings.Contains(shown.Body.String(), "[success] Post deleted;") {
+		t.Errorf("Expected the page to show the flash message, got %q", shown.Body.String())
+	}
+}
+
+func TestPostHandler_FlashHTMXTrigger(t *testing.T) {
+	router, mockRepo := flashRouter(t)
+
+	t.Run("seed swaps the list in place", func(t *testing.T) {
+		form := url.Values{"mode": {"append"}, "generator": {"sample"}, "count": {"3"}}
+		rr := serveConditional(router, http.MethodPost, "/posts/seed", form, map[string]string{"HX-Request": "true"})
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+		messages := triggeredFlashes(t, rr)
+		if len(messages) != 1 || messages[0] != flash.Success("Seeded 3 posts") {
+			t.Errorf("Expected a success message triggered, got %v", messages)
+		}
+		if flashCookie(rr) != nil {
+			t.Error("Expected no flash cookie for a swapped response, but one was set")
+		}
+	})
+
+	t.Run("pending messages of an HTMX request", func(t *testing.T) {
+		id := loadFixtures(t, mockRepo)["festival"]
+
+		form := url.Values{"title": {"New News"}, "content": {"Something happened today."}}
+		updated := serveConditional(router, http.MethodPut, "/posts/"+id, form, nil)
+
+		headers := withCookies(updated)
+		headers["HX-Request"] = "true"
+		rr := serveConditional(router, http.MethodGet, "/posts", nil, headers)
+
+		messages := triggeredFlashes(t, rr)
+		if len(messages) != 1 || messages[0] != flash.Success("Post updated") {
+			t.Errorf("Expected the pending message triggered, got %v", messages)
+		}
+		if strings.Contains(rr.Body.String(), "Post updated") {
+			t.Errorf("Expected the swapped content not to show the message, got %q", rr.Body.String())
+		}
+	})
+}
+
+func TestPostHandler_FlashInvalidForm(t *testing.T) {
+	router, _ := flashRouter(t)
+	form := url.Values{"title": {"Flash News"}, "content": {"Something happened today."}}
+	created := serveConditional(router, http.MethodPost, "/posts", form, nil)
+
+	invalid := url.Values{"title": {""}, "content": {"No title"}}
+	rr := serveConditional(router, http.MethodPost, "/posts", invalid, withCookies(created))
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status %d, got %d", http.StatusUnprocessableEntity, rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), "[success] Post created;") {
+		t.Errorf("Expected the form to show the pending message, got %q", rr.Body.String())
+	}
+	if cookie := flashCookie(rr); cookie == nil || cookie.MaxAge >= 0 {
+		t.Errorf("Expected the form to delete the flash cookie, got %v", cookie)
+	}
+
+	headers := withCookies(created)
+	headers["HX-Request"] = "true"
+	rr = serveConditional(router, http.MethodPost, "/posts", invalid, headers)
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Expected status %d, got %d", http.StatusUnprocessableEntity, rr.Code)
+	}
+	if messages := triggeredFlashes(t, rr); len(messages) != 1 || messages[0] != flash.Success("Post created") {
+		t.Errorf("Expected the pending message triggered, got %v", messages)
+	}
+}
+
+func TestPostHandler_FlashConflict(t *testing.T) {
+	router, mockRepo := flashRouter(t)
+	id := loadFixtures(t, mockRepo)["festival"]
+
+	saved := serveConditional(router, http.MethodPut, "/posts/"+id, url.Values{"title": {"First Editor"}, "content": {"First content"}, "version": {"1"}}, nil)
+	stale := url.Values{"title": {"Second Editor"}, "content": {"Second content"}, "version": {"1"}}
+
+	rr := serveConditional(router, http.MethodPut, "/posts/"+id, stale, withCookies(saved))
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("Expected status %d, got %d", http.StatusConflict, rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), "[success] Post updated;") {
+		t.Errorf("Expected the conflict screen to show the pending message, got %q", rr.Body.String())
+	}
+	if cookie := flashCookie(rr); cookie == nil || cookie.MaxAge >= 0 {
+		t.Errorf("Expected the conflict screen to delete the flash cookie, got %v", cookie)
+	}
+
+	headers := withCookies(saved)
+	headers["HX-Request"] = "true"
+	rr = serveConditional(router, http.MethodPut, "/posts/"+id, stale, headers)
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("Expected status %d, got %d", http.StatusConflict, rr.Code)
+	}
+	if messages := triggeredFlashes(t, rr); len(messages) != 1 || messages[0] != flash.Success("Post updated") {
+		t.Errorf("Expected the pending message triggered, got %v", messages)
+	}
+}
+
+func TestPostHandler_FlashSkipsNotModified(t *testing.T) {
+	router, mockRepo := flashRouter(t)
+	id := loadFixtures(t, mockRepo)["festival"]
+
+	form := url.Values{"title": {"Festival News"}, "content": {"Nothing happened today."}}
+	updated := serveConditional(router, http.MethodPut, "/posts/"+id, form, nil)
+
+	for _, target := range []string{"/posts", mockRepo.posts[id].Path()} {
+		t.Run(target, func(t *testing.T) {
+			// The browser holds the current version of the page
+			cached := serveConditional(router, http.MethodGet, target, nil, nil)
+
+			headers := withCookies(updated)
+			headers["If-None-Match"] = cached.Header().Get("ETag")
+			rr := serveConditional(router, http.MethodGet, target, nil, headers)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("Expected status %d for a page with a flash message, got %d", http.StatusOK, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), "Post updated") {
+				t.Errorf("Expected the page to show the flash message, got %q", rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestPostHandler_FlashForgedCookie(t *testing.T) {
+	router, _ := flashRouter(t)
+
+	rr := serveConditional(router, http.MethodGet, "/posts", nil, map[string]string{
+		"Cookie": flash.CookieName + "=W3sibGV2ZWwiOiJlcnJvciJ9XQ.forged",
+	})
+	if strings.Contains(rr.Body.String(), "[") {
+		t.Errorf("Expected a forged cookie not to show messages, got %q", rr.Body.String())
+	}
+	if cookie := flashCookie(rr); cookie == nil || cookie.MaxAge >= 0 {
+		t.Errorf("Expected the forged cookie to be deleted, got %v", cookie)
+	}
+}
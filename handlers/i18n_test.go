@@ -0,0 +1,133 @@
+//go:build unit
+
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gekich/news-app/config"
+	"github.com/gekich/news-app/flash"
+	"github.com/gekich/news-app/i18n"
+	custom "github.com/gekich/news-app/middleware"
+	"github.com/gekich/news-app/views"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// localeRouter routes the post handlers behind the locale middleware
+func localeRouter(t *testing.T) (http.Handler, *MockPostRepository) {
+	t.Helper()
+
+	store, err := flash.NewStore([]byte("secret"))
+	if err != nil {
+		t.Fatalf("Failed to create flash store: %v", err)
+	}
+	mockRepo := NewMockPostRepository()
+	cfg, _ := config.Load()
+	handler := NewPostHandler(mockRepo, errorTemplates(), cfg).WithFlashes(store)
+
+	r := chi.NewRouter()
+	r.Use(middleware.URLFormat)
+	r.Use(custom.Locale(i18n.Default()))
+	r.NotFound(handler.NotFound)
+	r.Get("/posts", handler.Index)
+	r.Post("/posts", handler.Create)
+	r.Get("/posts/{id}", handler.Show)
+	r.Delete("/posts/{id}", handler.Delete)
+	return r, mockRepo
+}
+
+func TestPostHandler_Localized(t *testing.T) {
+	router, mockRepo := localeRouter(t)
+	ukrainian := map[string]string{"Accept-Language": "uk"}
+
+	t.Run("form title", func(t *testing.T) {
+		form := url.Values{"title": {""}, "content": {""}}
+		rr := serveConditional(router, http.MethodPost, "/posts", form, ukrainian)
+
+		if rr.Code != http.StatusUnprocessableEntity {
+			t.Errorf("Expected status %d, got %d", http.StatusUnprocessableEntity, rr.Code)
+		}
+		if !strings.Contains(rr.Body.String(), "Form: Новий допис") {
+			t.Errorf("Expected the translated form title, got %q", rr.Body.String())
+		}
+	})
+
+	t.Run("error page", func(t *testing.T) {
+		rr := serveConditional(router, http.MethodGet, "/posts/no-such-post", nil, ukrainian)
+		if !strings.Contains(rr.Body.String(), "[404] Сторінку не знайдено: Допис не знайдено") {
+			t.Errorf("Expected the translated error title and message, got %q", rr.Body.String())
+		}
+	})
+
+	t.Run("list options error", func(t *testing.T) {
+		rr := serveConditional(router, http.MethodGet, "/posts?sort=relevance", nil, ukrainian)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+		if !strings.Contains(rr.Body.String(), "Сортування за релевантністю потребує пошукового запиту") {
+			t.Errorf("Expected the translated error message, got %q", rr.Body.String())
+		}
+	})
+
+	t.Run("flash message", func(t *testing.T) {
+		id := loadFixtures(t, mockRepo)["festival"]
+
+		deleted := serveConditional(router, http.MethodDelete, "/posts/"+id, nil, ukrainian)
+		headers := withCookies(deleted)
+		headers["Accept-Language"] = "uk"
+		headers["HX-Request"] = "true"
+		shown := serveConditional(router, http.MethodGet, "/posts", nil, headers)
+
+		messages := triggeredFlashes(t, shown)
+		if len(messages) != 1 || messages[0] != flash.Success("Допис видалено") {
+			t.Errorf("Expected the translated flash message, got %v", messages)
+		}
+	})
+}
+
+func TestPostHandler_LocalizedETags(t *testing.T) {
+	router, mockRepo := localeRouter(t)
+	target := mockRepo.posts[loadFixtures(t, mockRepo)["festival"]].Path()
+
+	for _, path := range []string{"/posts", target} {
+		t.Run(path, func(t *testing.T) {
+			english := serveConditional(router, http.MethodGet, path, nil, nil)
+			ukrainian := serveConditional(router, http.MethodGet, path, nil, map[string]string{"Accept-Language": "uk"})
+
+			etag := english.Header().Get("ETag")
+			if etag == "" || etag == ukrainian.Header().Get("ETag") {
+				t.Fatalf("Expected the locales to get different ETags, got %q and %q", etag, ukrainian.Header().Get("ETag"))
+			}
+
+			// The English page doesn't match a request for the Ukrainian one
+			rr := serveConditional(router, http.MethodGet, path, nil, map[string]string{
+				"Accept-Language": "uk",
+				"If-None-Match":   etag,
+			})
+			if rr.Code != http.StatusOK {
+				t.Errorf("Expected status %d, got %d", http.StatusOK, rr.Code)
+			}
+		})
+	}
+}
+
+func TestLocaleLinks(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/posts?tag=go&lang=en&after=abc", nil)
+
+	links := localeLinks(r, []string{"en", "uk"})
+
+	want := []views.LocaleLink{
+		{Locale: "en", URL: "/posts?after=abc&lang=en&tag=go"},
+		{Locale: "uk", URL: "/posts?after=abc&lang=uk&tag=go"},
+	}
+	if !reflect.DeepEqual(links, want) {
+		t.Errorf("Expected %v, got %v", want, links)
+	}
+}
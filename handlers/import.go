@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gekich/news-app/apperr"
+	"github.com/gekich/news-app/importer"
+	"github.com/gekich/news-app/templates"
+	"github.com/gekich/news-app/views"
+)
+
+// importPage builds the view model of the import page
+func importPage(r *http.Request) *views.ImportPage {
+	return &views.ImportPage{Title: translator(r).T("import.title")}
+}
+
+func (h *PostHandler) ImportForm(w http.ResponseWriter, r *http.Request) {
+	h.renderTemplate(w, r, templates.AdminImport, importPage(r), "/admin/import")
+}
+
+func (h *PostHandler) Import(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, h.config.App.ImportMaxBytes)
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		h.renderError(w, r, apperr.BadRequest(translator(r).T("import.upload_failed", err.Error()), err))
+		return
+	}
+
+	data := importPage(r)
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		data.Error = translator(r).T("import.no_file")
+		h.renderTemplate(w, r, templates.AdminImport, data, "")
+		return
+	}
+	defer file.Close()
+
+	opts := importer.Options{
+		DryRun:       r.FormValue("dry_run") != "",
+		MaxFileBytes: h.config.App.ImportMaxFileBytes,
+		Translator:   translator(r),
+	}
+
+	if format := r.FormValue("format"); format != "" {
+		opts.Format, err = importer.ParseFormat(format)
+	} else {
+		opts.Format, err = importer.FormatFromFilename(header.Filename)
+	}
+	if err == nil {
+		opts.Columns, err = importer.ParseColumns(r.FormValue("columns"))
+	}
+	if err != nil {
+		data.Error = err.Error()
+		h.renderTemplate(w, r, templates.AdminImport, data, "")
+		return
+	}
+
+	report, err := importer.Import(r.Context(), h.repo, file, opts)
+	if err != nil {
+		data.Error = err.Error()
+	}
+	data.Report = &report
+	data.Filename = header.Filename
+
+	h.renderTemplate(w, r, templates.AdminImport, data, "")
+}
@@ -0,0 +1,135 @@
+//go:build unit
+
+package handlers
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gekich/news-app/config"
+)
+
+func createImportRequest(t *testing.T, filename, content string, fields map[string]string) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	if filename != "" {
+		part, err := writer.CreateFormFile("file", filename)
+		if err != nil {
+			t.Fatalf("Failed to create form file: %v", err)
+		}
+		part.Write([]byte(content))
+	}
+	for name, value := range fields {
+		writer.WriteField(name, value)
+	}
+	writer.Close()
+
+	req, _ := createRequestWithChiContext("POST", "/admin/import", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestPostHandler_Import(t *testing.T) {
+	validJSONL := `{"title":"Imported title","content":"Imported content body"}` + "\n" +
+		`{"title":"","content":"Missing a title here"}` + "\n"
+
+	tests := []struct {
+		name          string
+		filename      string
+		content       string
+		fields        map[string]string
+		expectedBody  string
+		expectedPosts int
+	}{
+		{
+			name:          "imports valid rows",
+			filename:      "posts.jsonl",
+			content:       validJSONL,
+			expectedBody:  "Import: 1/2",
+			expectedPosts: 1,
+		},
+		{
+			name:          "dry run writes nothing",
+			filename:      "posts.jsonl",
+			content:       validJSONL,
+			fields:        map[string]string{"dry_run": "1"},
+			expectedBody:  "Import: 1/2",
+			expectedPosts: 0,
+		},
+		{
+			name:          "explicit CSV format with column mapping",
+			filename:      "export.txt",
+			content:       "headline,body\nCSV headline,CSV body content\n",
+			fields:        map[string]string{"format": "csv", "columns": "headline=title,body=content"},
+			expectedBody:  "Import: 1/1",
+			expectedPosts: 1,
+		},
+		{
+			name:     "WordPress export",
+			filename: "wordpress.xml",
+			content: `<rss xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:wp="http://wordpress.org/export/1.2/"><channel>` +
+				`<item><title>WordPress post</title><guid>https://example.com/?p=1</guid><content:encoded>&lt;p&gt;Imported from WordPress&lt;/p&gt;</content:encoded><wp:status>publish</wp:status><wp:post_type>post</wp:post_type></item>` +
+				`<item><title>WordPress page</title><guid>https://example.com/?p=2</guid><wp:post_type>page</wp:post_type></item>` +
+				`</channel></rss>`,
+			expectedBody:  "Import: 1/2",
+			expectedPosts: 1,
+		},
+		{
+			name:          "unknown format",
+			filename:      "posts.pdf",
+			content:       "%PDF-1.4",
+			expectedBody:  "unsupported import format",
+			expectedPosts: 0,
+		},
+		{
+			name:          "missing file",
+			expectedBody:  "Please choose a file",
+			expectedPosts: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := NewMockPostRepository()
+			cfg, _ := config.Load()
+			handler := NewPostHandler(mockRepo, createMockTemplates(), cfg)
+
+			req := createImportRequest(t, tt.filename, tt.content, tt.fields)
+			rr := httptest.NewRecorder()
+
+			handler.Import(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Errorf("Expected status %d, got %d", http.StatusOK, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("Expected body to contain %q, got %q", tt.expectedBody, rr.Body.String())
+			}
+			if len(mockRepo.posts) != tt.expectedPosts {
+				t.Errorf("Expected %d posts, got %d", tt.expectedPosts, len(mockRepo.posts))
+			}
+		})
+	}
+}
+
+func TestPostHandler_ImportTooLarge(t *testing.T) {
+	mockRepo := NewMockPostRepository()
+	cfg, _ := config.Load()
+	cfg.App.ImportMaxBytes = 64
+	handler := NewPostHandler(mockRepo, createMockTemplates(), cfg)
+
+	req := createImportRequest(t, "posts.jsonl", strings.Repeat("x", 1024), nil)
+	rr := httptest.NewRecorder()
+
+	handler.Import(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
@@ -0,0 +1,273 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gekich/news-app/apperr"
+	"github.com/gekich/news-app/imaging"
+	"github.com/gekich/news-app/media"
+	"github.com/gekich/news-app/models"
+	"github.com/gekich/news-app/validation"
+	"github.com/go-chi/chi/v5"
+)
+
+// formMaxMemory is how much of a multipart form is kept in memory, and the
+// room left for the fields of a post next to its attachments
+const formMaxMemory = 1 << 20
+
+// WithMedia stores the files uploaded with posts in store. Without a store
+// posts can't have attachments.
+func (h *PostHandler) WithMedia(store media.Store) *PostHandler {
+	h.media = store
+	return h
+}
+
+// WithImages queues the images uploaded with posts on processor, which
+// makes the resized copies lists and pages show
+func (h *PostHandler) WithImages(processor *imaging.Processor) *PostHandler {
+	h.images = processor
+	return h
+}
+
+// uploadLimits returns the limits of the files uploaded with a post, nil
+// without a media store
+func (h *PostHandler) uploadLimits() *validation.UploadLimits {
+	if h.media == nil {
+		return nil
+	}
+	return &validation.UploadLimits{
+		MaxBytes:     h.config.Media.MaxBytes,
+		MaxFiles:     h.config.Media.MaxFiles,
+		AllowedTypes: h.config.Media.AllowedTypes,
+		MaxPixels:    h.config.Media.MaxPixels,
+	}
+}
+
+// parsePostForm parses the submitted post form, which is multipart when it
+// carries attachments. Bodies larger than the attachments of a post may be
+// are rejected before they are read to the end.
+func (h *PostHandler) parsePostForm(w http.ResponseWriter, r *http.Request) *apperr.Error {
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseForm(); err != nil {
+			return apperr.BadRequest(translator(r).T("error.form_unreadable"), err)
+		}
+		return nil
+	}
+
+	limit := int64(formMaxMemory)
+	if limits := h.uploadLimits(); limits != nil {
+		limit += limits.MaxBytes * int64(limits.MaxFiles)
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+	if err := r.ParseMultipartForm(formMaxMemory); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return apperr.New(http.StatusRequestEntityTooLarge, translator(r).T("error.upload_too_large"), err)
+		}
+		return apperr.BadRequest(translator(r).T("error.form_unreadable"), err)
+	}
+	return nil
+}
+
+// upload is a file uploaded with a post, checked and ready to be stored
+type upload struct {
+	header      *multipart.FileHeader
+	contentType string
+	// image is the size of an image that can be decoded
+	image imaging.Config
+}
+
+// readUploads checks the files uploaded with the post form. attached is the
+// number of attachments the post already has, which count towards the limit.
+// It returns the validation message of the first invalid file.
+func (h *PostHandler) readUploads(r *http.Request, attached int) ([]upload, string, error) {
+	limits := h.uploadLimits()
+	if limits == nil || r.MultipartForm == nil {
+		return nil, "", nil
+	}
+
+	var uploads []upload
+	for _, header := range r.MultipartForm.File["attachments"] {
+		// Browsers send an empty part for a file input left empty
+		if header.Filename == "" && header.Size == 0 {
+			continue
+		}
+		if attached+len(uploads) >= limits.MaxFiles {
+			return nil, translator(r).T("validation.file_count", limits.MaxFiles), nil
+		}
+
+		contentType, err := sniff(header)
+		if err != nil {
+			return nil, "", err
+		}
+		if message := validation.ValidateUpload(header.Filename, header.Size, contentType, *limits, translator(r)); message != "" {
+			return nil, message, nil
+		}
+
+		upload := upload{header: header, contentType: contentType}
+		if imaging.Decodable(contentType) {
+			// Only the header is read, so images too large to decode are
+			// turned away before any work is spent on them
+			if upload.image, err = inspect(header); err != nil {
+				return nil, translator(r).T("validation.image_invalid", header.Filename), nil
+			}
+			if message := validation.ValidateImage(header.Filename, upload.image.Pixels(), *limits, translator(r)); message != "" {
+				return nil, message, nil
+			}
+		}
+		uploads = append(uploads, upload)
+	}
+	return uploads, "", nil
+}
+
+// sniff returns the content type of an uploaded file from its contents
+func sniff(header *multipart.FileHeader) (string, error) {
+	f, err := header.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	contentType, _, err := media.DetectContentType(f)
+	return contentType, err
+}
+
+// inspect reads the size of an uploaded image from its header
+func inspect(header *multipart.FileHeader) (imaging.Config, error) {
+	f, err := header.Open()
+	if err != nil {
+		return imaging.Config{}, err
+	}
+	defer f.Close()
+
+	return imaging.Inspect(f)
+}
+
+// saveUploads stores uploads in the media store and returns them as
+// attachments. When one fails, those stored before it are deleted again.
+func (h *PostHandler) saveUploads(ctx context.Context, uploads []upload) ([]models.Attachment, error) {
+	var attachments []models.Attachment
+	for _, upload := range uploads {
+		attachment, err := h.saveUpload(ctx, upload)
+		if err != nil {
+			h.deleteAttachments(ctx, attachments)
+			return nil, err
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments, nil
+}
+
+// saveUpload stores one upload in the media store, without the metadata of
+// images
+func (h *PostHandler) saveUpload(ctx context.Context, upload upload) (models.Attachment, error) {
+	f, err := upload.header.Open()
+	if err != nil {
+		return models.Attachment{}, err
+	}
+	defer f.Close()
+
+	stripped, w := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.CloseWithError(imaging.Strip(w, f, upload.contentType))
+	}()
+	info, err := h.media.Save(ctx, upload.header.Filename, upload.contentType, stripped)
+	// Stops the copy when saving failed before reading everything
+	stripped.CloseWithError(err)
+	<-done
+	if err != nil {
+		return models.Attachment{}, err
+	}
+	return models.Attachment{
+		ID:          info.ID,
+		Filename:    info.Filename,
+		ContentType: info.ContentType,
+		Size:        info.Size,
+		Width:       upload.image.Width,
+		Height:      upload.image.Height,
+	}, nil
+}
+
+// processImages queues the images among attachments to be resized
+func (h *PostHandler) processImages(attachments []models.Attachment) {
+	if h.images == nil {
+		return
+	}
+	for _, attachment := range attachments {
+		if !h.images.Enqueue(attachment) {
+			log.Printf("Image queue is full, %s is shown at full size", attachment.ID)
+		}
+	}
+}
+
+// deleteAttachments removes the files of attachments, and the resized copies
+// of images, from the media store. A file that can't be deleted is only
+// orphaned, for "newsctl media prune" to clean up, so failures are logged.
+func (h *PostHandler) deleteAttachments(ctx context.Context, attachments []models.Attachment) {
+	if h.media == nil {
+		return
+	}
+	for _, attachment := range attachments {
+		for _, id := range attachment.MediaIDs() {
+			if err := h.media.Delete(ctx, id); err != nil {
+				log.Printf("Failed to delete attachment %s: %v", id, err)
+			}
+		}
+	}
+}
+
+// removeAttachments splits the attachments of a post into those it keeps
+// and those the form asked to remove
+func removeAttachments(r *http.Request, attachments []models.Attachment) (kept, removed []models.Attachment) {
+	remove := r.Form["remove_attachment"]
+	for _, attachment := range attachments {
+		if slices.Contains(remove, attachment.ID) {
+			removed = append(removed, attachment)
+		} else {
+			kept = append(kept, attachment)
+		}
+	}
+	return kept, removed
+}
+
+// Media serves a file of the media store, with support for range requests.
+// Stored files never change, so their ID is their entity tag and caches may
+// keep them for good.
+func (h *PostHandler) Media(w http.ResponseWriter, r *http.Request) {
+	if h.media == nil {
+		h.NotFound(w, r)
+		return
+	}
+
+	file, err := h.media.Open(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		h.handleError(w, r, err, "Failed to open file")
+		return
+	}
+	defer file.Close()
+
+	info := file.Info()
+	// Only images and PDFs are shown in the browser, other files are
+	// downloaded rather than rendered in the site's origin
+	disposition := "attachment"
+	if strings.HasPrefix(info.ContentType, "image/") || info.ContentType == "application/pdf" {
+		disposition = "inline"
+	}
+
+	w.Header().Set("Content-Type", info.ContentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": info.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("ETag", `"`+info.ID+`"`)
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	http.ServeContent(w, r, info.Filename, info.UploadedAt, file)
+}
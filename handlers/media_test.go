@@ -0,0 +1,395 @@
+//go:build unit
+
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"html/template"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gekich/news-app/config"
+	"github.com/gekich/news-app/i18n"
+	"github.com/gekich/news-app/imaging"
+	"github.com/gekich/news-app/media"
+	custom "github.com/gekich/news-app/middleware"
+	"github.com/gekich/news-app/templates"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+const pdfFile = "%PDF-1.7\n document"
+
+// pngFile is a small image
+var pngFile = testPNG(4, 3)
+
+// testPNG encodes a plain image of the given size as PNG
+func testPNG(width, height int) string {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{R: 200, G: 80, B: 40, A: 255}), image.Point{}, draw.Src)
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.String()
+}
+
+// pngHeader returns the start of a PNG file declaring an image of the given
+// size, which is all it takes for a decompression bomb to be turned away
+func pngHeader(width, height int) string {
+	ihdr := make([]byte, 0, 17)
+	ihdr = append(ihdr, "IHDR"...)
+	ihdr = binary.BigEndian.AppendUint32(ihdr, uint32(width))
+	ihdr = binary.BigEndian.AppendUint32(ihdr, uint32(height))
+	ihdr = append(ihdr, 8, 2, 0, 0, 0)
+
+	header := []byte("\x89PNG\r\n\x1a\n")
+	header = binary.BigEndian.AppendUint32(header, 13)
+	header = append(header, ihdr...)
+	header = binary.BigEndian.AppendUint32(header, crc32.ChecksumIEEE(ihdr))
+	return string(header)
+}
+
+// mediaRouter routes the post handlers with a media store in a temporary
+// directory, allowing two PNG or PDF files of up to 1 KB per post. Images
+// get a copy 100 pixels wide from the returned processor.
+func mediaRouter(t *testing.T) (http.Handler, *MockPostRepository, *media.LocalStore, *imaging.Processor) {
+	t.Helper()
+
+	store, err := media.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create media store: %v", err)
+	}
+
+	pages := mockTemplatePages()
+	pages[templates.PostForm] = template.Must(template.New("form").Parse(
+		`{{define "content"}}Form: {{.Title}} {{.Errors.Attachments}}{{range .Post.Attachments}} [{{.Filename}}]{{end}}{{end}}{{template "content" .}}`,
+	))
+
+	mockRepo := NewMockPostRepository()
+	cfg, _ := config.Load()
+	cfg.Media.MaxBytes = 1 << 10
+	cfg.Media.MaxFiles = 2
+	cfg.Media.AllowedTypes = []string{"image/png", "application/pdf"}
+	images := imaging.NewProcessor(store, mockRepo, []int{100}, cfg.Media.MaxPixels)
+	images.Start(1)
+	t.Cleanup(images.Close)
+	handler := NewPostHandler(mockRepo, templates.NewRegistryFrom(pages), cfg).WithMedia(store).WithImages(images)
+
+	r := chi.NewRouter()
+	r.Use(middleware.URLFormat)
+	r.Use(custom.MethodOverride)
+	r.Use(custom.Locale(i18n.Default()))
+	r.Post("/posts", handler.Create)
+	r.Put("/posts/{id}", handler.Update)
+	r.Delete("/posts/{id}", handler.Delete)
+	r.Get("/media/{id}", handler.Media)
+	return r, mockRepo, store, images
+}
+
+// testUpload is a file of a multipart post form
+type testUpload struct {
+	filename, content string
+}
+
+// serveMultipart submits a multipart post form with files as attachments
+func serveMultipart(router http.Handler, target string, fields map[string][]string, files ...testUpload) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, values := range fields {
+		for _, value := range values {
+			writer.WriteField(name, value)
+		}
+	}
+	for _, file := range files {
+		part, _ := writer.CreateFormFile("attachments", file.filename)
+		part.Write([]byte(file.content))
+	}
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, target, &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+// storedFiles returns the IDs of the files in store
+func storedFiles(t *testing.T, store media.Store) []string {
+	t.Helper()
+
+	var ids []string
+	if err := store.Walk(context.Background(), func(info media.Info) error {
+		ids = append(ids, info.ID)
+		return nil
+	}); err != nil {
+		t.Fatalf("Failed to list media: %v", err)
+	}
+	return ids
+}
+
+func TestPostHandler_Attachments(t *testing.T) {
+	router, mockRepo, store, _ := mediaRouter(t)
+	fields := map[string][]string{"title": {"Festival photos"}, "content": {"Pictures from the square."}}
+
+	rr := serveMultipart(router, "/posts", fields, testUpload{"square.png", pngFile}, testUpload{"programme.pdf", pdfFile})
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusSeeOther, rr.Code, rr.Body.String())
+	}
+
+	var id string
+	for key := range mockRepo.posts {
+		id = key
+	}
+	post := mockRepo.posts[id]
+	if len(post.Attachments) != 2 {
+		t.Fatalf("Expected 2 attachments, got %+v", post.Attachments)
+	}
+	// The content type is sniffed, not taken from the client
+	if post.Attachments[0].ContentType != "image/png" || post.Attachments[1].ContentType != "application/pdf" {
+		t.Errorf("Expected the sniffed content types, got %+v", post.Attachments)
+	}
+	if len(storedFiles(t, store)) != 2 {
+		t.Errorf("Expected 2 stored files, got %v", storedFiles(t, store))
+	}
+
+	t.Run("invalid uploads", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			files []testUpload
+			want  string
+		}{
+			{"too large", []testUpload{{"large.png", pngFile + strings.Repeat(".", 1<<10)}}, "large.png is larger than 1 KB"},
+			{"wrong type", []testUpload{{"notes.png", "just some text"}}, "notes.png is not a type of file that can be attached"},
+			{"too many", []testUpload{{"a.png", pngFile}, {"b.png", pngFile}, {"c.png", pngFile}}, "Attach at most 2 files"},
+			{"not an image", []testUpload{{"broken.png", "\x89PNG\r\n\x1a\n broken"}}, "broken.png is not a valid image"},
+			{"decompression bomb", []testUpload{{"bomb.png", pngHeader(50000, 50000)}}, "bomb.png is larger than 40 megapixels"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				rr := serveMultipart(router, "/posts", fields, tt.files...)
+				if rr.Code != http.StatusUnprocessableEntity {
+					t.Errorf("Expected status %d, got %d", http.StatusUnprocessableEntity, rr.Code)
+				}
+				if !strings.Contains(rr.Body.String(), tt.want) {
+					t.Errorf("Expected %q, got %q", tt.want, rr.Body.String())
+				}
+				if len(storedFiles(t, store)) != 2 {
+					t.Errorf("Expected nothing to be stored, got %v", storedFiles(t, store))
+				}
+			})
+		}
+	})
+
+	t.Run("too large a body", func(t *testing.T) {
+		rr := serveMultipart(router, "/posts", fields, testUpload{"huge.png", pngFile + strings.Repeat(".", 2<<20)})
+		if rr.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("Expected status %d, got %d", http.StatusRequestEntityTooLarge, rr.Code)
+		}
+	})
+
+	t.Run("update", func(t *testing.T) {
+		removed := post.Attachments[0]
+		update := map[string][]string{
+			"title":             fields["title"],
+			"content":           fields["content"],
+			"remove_attachment": {removed.ID},
+		}
+
+		// Forms without JavaScript put the method in the query
+		rr := serveMultipart(router, "/posts/"+id+"?_method=PUT", update, testUpload{"poster.pdf", pdfFile})
+		if rr.Code != http.StatusSeeOther {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusSeeOther, rr.Code, rr.Body.String())
+		}
+
+		attachments := mockRepo.posts[id].Attachments
+		if len(attachments) != 2 || attachments[0].Filename != "programme.pdf" || attachments[1].Filename != "poster.pdf" {
+			t.Errorf("Expected the programme and the poster, got %+v", attachments)
+		}
+		if _, err := store.Open(context.Background(), removed.ID); err == nil {
+			t.Error("Expected the removed attachment to be deleted")
+		}
+	})
+
+	t.Run("update conflict", func(t *testing.T) {
+		before := storedFiles(t, store)
+		stale := map[string][]string{
+			"title":             fields["title"],
+			"content":           fields["content"],
+			"version":           {strconv.FormatInt(mockRepo.posts[id].Version-1, 10)},
+			"remove_attachment": {mockRepo.posts[id].Attachments[1].ID},
+		}
+
+		rr := serveMultipart(router, "/posts/"+id+"?_method=PUT", stale, testUpload{"late.pdf", pdfFile})
+		if rr.Code != http.StatusConflict {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusConflict, rr.Code, rr.Body.String())
+		}
+		if strings.Contains(rr.Body.String(), "late.pdf") {
+			t.Errorf("Expected the conflict screen not to offer the discarded upload, got %q", rr.Body.String())
+		}
+		if files := storedFiles(t, store); len(files) != len(before) {
+			t.Errorf("Expected the upload to be deleted and the attachments to be kept, got %v", files)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		rr := serveConditional(router, http.MethodDelete, "/posts/"+id, nil, nil)
+		if rr.Code != http.StatusSeeOther {
+			t.Fatalf("Expected status %d, got %d", http.StatusSeeOther, rr.Code)
+		}
+		if files := storedFiles(t, store); len(files) != 0 {
+			t.Errorf("Expected the attachments to be deleted with the post, got %v", files)
+		}
+	})
+}
+
+func TestPostHandler_Media(t *testing.T) {
+	router, _, store, _ := mediaRouter(t)
+	ctx := context.Background()
+
+	text, err := store.Save(ctx, "digits.txt", "text/plain", strings.NewReader("0123456789"))
+	if err != nil {
+		t.Fatalf("Failed to store file: %v", err)
+	}
+	image, err := store.Save(ctx, "square.png", "image/png", strings.NewReader(pngFile))
+	if err != nil {
+		t.Fatalf("Failed to store file: %v", err)
+	}
+	target := "/media/" + text.ID
+
+	t.Run("whole file", func(t *testing.T) {
+		rr := serveConditional(router, http.MethodGet, target+".txt", nil, nil)
+		if rr.Code != http.StatusOK || rr.Body.String() != "0123456789" {
+			t.Fatalf("Expected the file, got %d %q", rr.Code, rr.Body.String())
+		}
+
+		headers := map[string]string{
+			"Content-Type":        "text/plain",
+			"Content-Disposition": `attachment; filename=digits.txt`,
+			"ETag":                `"` + text.ID + `"`,
+			"Cache-Control":       "public, max-age=31536000, immutable",
+			"Accept-Ranges":       "bytes",
+		}
+		for name, want := range headers {
+			if got := rr.Header().Get(name); got != want {
+				t.Errorf("Expected %s %q, got %q", name, want, got)
+			}
+		}
+	})
+
+	t.Run("range", func(t *testing.T) {
+		rr := serveConditional(router, http.MethodGet, target, nil, map[string]string{"Range": "bytes=2-5"})
+		if rr.Code != http.StatusPartialContent {
+			t.Fatalf("Expected status %d, got %d", http.StatusPartialContent, rr.Code)
+		}
+		if rr.Body.String() != "2345" || rr.Header().Get("Content-Range") != "bytes 2-5/10" {
+			t.Errorf("Expected bytes 2-5, got %q (%s)", rr.Body.String(), rr.Header().Get("Content-Range"))
+		}
+	})
+
+	t.Run("not modified", func(t *testing.T) {
+		rr := serveConditional(router, http.MethodGet, target, nil, map[string]string{"If-None-Match": `"` + text.ID + `"`})
+		if rr.Code != http.StatusNotModified {
+			t.Errorf("Expected status %d, got %d", http.StatusNotModified, rr.Code)
+		}
+	})
+
+	t.Run("image", func(t *testing.T) {
+		rr := serveConditional(router, http.MethodGet, "/media/"+image.ID+".png", nil, nil)
+		if got := rr.Header().Get("Content-Disposition"); got != "inline; filename=square.png" {
+			t.Errorf("Expected images to be shown inline, got %q", got)
+		}
+	})
+
+	for _, ref := range []string{"000000000000000000000001", "not-an-id"} {
+		t.Run("unknown "+ref, func(t *testing.T) {
+			rr := serveConditional(router, http.MethodGet, "/media/"+ref, nil, nil)
+			if rr.Code != http.StatusNotFound {
+				t.Errorf("Expected status %d, got %d", http.StatusNotFound, rr.Code)
+			}
+		})
+	}
+}
+
+func TestPostHandler_Images(t *testing.T) {
+	router, mockRepo, store, images := mediaRouter(t)
+	ctx := context.Background()
+	fields := map[string][]string{"title": {"Harbour at dawn"}, "content": {"Boats leaving the harbour."}}
+
+	rr := serveMultipart(router, "/posts", fields, testUpload{"harbour.png", testPNG(200, 150)}, testUpload{"icon.png", pngFile})
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusSeeOther, rr.Code, rr.Body.String())
+	}
+	// Waits for the queued images to be resized
+	images.Close()
+
+	var id string
+	for key := range mockRepo.posts {
+		id = key
+	}
+	post := mockRepo.posts[id]
+	harbour, icon := post.Attachments[0], post.Attachments[1]
+	if harbour.Width != 200 || harbour.Height != 150 {
+		t.Errorf("Expected the size of the image to be recorded, got %dx%d", harbour.Width, harbour.Height)
+	}
+	if len(harbour.Variants) != 1 || harbour.Variants[0].Width != 100 || harbour.Variants[0].Height != 75 {
+		t.Fatalf("Expected a copy 100 pixels wide, got %+v", harbour.Variants)
+	}
+	if len(icon.Variants) != 0 {
+		t.Errorf("Expected no copies of an image narrower than them, got %+v", icon.Variants)
+	}
+	if featured := post.Featured(); featured == nil || featured.ID != harbour.ID {
+		t.Errorf("Expected the first image to be featured, got %+v", featured)
+	}
+
+	f, err := store.Open(ctx, harbour.Variants[0].ID)
+	if err != nil {
+		t.Fatalf("Failed to open the copy: %v", err)
+	}
+	config, err := imaging.Inspect(f)
+	f.Close()
+	if err != nil || config.Width != 100 || config.Height != 75 {
+		t.Errorf("Expected a stored 100x75 image, got %+v (%v)", config, err)
+	}
+
+	t.Run("featured image", func(t *testing.T) {
+		update := map[string][]string{"title": fields["title"], "content": fields["content"], "featured_image": {icon.ID}}
+		rr := serveMultipart(router, "/posts/"+id+"?_method=PUT", update)
+		if rr.Code != http.StatusSeeOther {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusSeeOther, rr.Code, rr.Body.String())
+		}
+		if got := mockRepo.posts[id].FeaturedImage; got != icon.ID {
+			t.Errorf("Expected the icon to be featured, got %q", got)
+		}
+
+		// Removing the featured image features the first one left
+		update["remove_attachment"] = []string{icon.ID}
+		rr = serveMultipart(router, "/posts/"+id+"?_method=PUT", update)
+		if rr.Code != http.StatusSeeOther {
+			t.Fatalf("Expected status %d, got %d: %s", http.StatusSeeOther, rr.Code, rr.Body.String())
+		}
+		if got := mockRepo.posts[id]; got.FeaturedImage != "" || got.Featured() == nil || got.Featured().ID != harbour.ID {
+			t.Errorf("Expected the harbour to be featured, got %q", got.FeaturedImage)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		rr := serveConditional(router, http.MethodDelete, "/posts/"+id, nil, nil)
+		if rr.Code != http.StatusSeeOther {
+			t.Fatalf("Expected status %d, got %d", http.StatusSeeOther, rr.Code)
+		}
+		if files := storedFiles(t, store); len(files) != 0 {
+			t.Errorf("Expected the images and their copies to be deleted, got %v", files)
+		}
+	})
+}
@@ -1,27 +1,63 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"html/template"
+	"io"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/gekich/news-app/apperr"
+	"github.com/gekich/news-app/cache"
 	"github.com/gekich/news-app/config"
+	"github.com/gekich/news-app/exporter"
+	"github.com/gekich/news-app/flash"
+	"github.com/gekich/news-app/i18n"
+	"github.com/gekich/news-app/imaging"
+	"github.com/gekich/news-app/media"
 	"github.com/gekich/news-app/models"
 	"github.com/gekich/news-app/repository"
 	"github.com/gekich/news-app/seeder"
+	"github.com/gekich/news-app/templates"
 	"github.com/gekich/news-app/validation"
+	"github.com/gekich/news-app/views"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type PostHandler struct {
-	repo   *repository.PostRepository
-	tmpl   map[string]*template.Template
+	repo   repository.PostStore
+	tmpl   *templates.Registry
 	config config.Config
+	// cache holds rendered list pages; nil renders every request
+	cache *cache.Store
+	// templates replaces tmpl when set, for templates that change at runtime
+	templates TemplateSource
+	// flashes keeps messages for the page a request redirects to
+	flashes *flash.Store
+	// media stores the files attached to posts; nil disables attachments
+	media media.Store
+	// images makes resized copies of attached images; nil shows them as
+	// uploaded
+	images *imaging.Processor
 }
 
-func NewPostHandler(repo *repository.PostRepository, tmpl map[string]*template.Template, cfg config.Config) *PostHandler {
+// TemplateSource provides templates that can change while the server runs,
+// like a templates.Reloader in development
+type TemplateSource interface {
+	Templates() (*templates.Registry, error)
+}
+
+func NewPostHandler(repo repository.PostStore, tmpl *templates.Registry, cfg config.Config) *PostHandler {
 	return &PostHandler{
 		repo:   repo,
 		tmpl:   tmpl,
@@ -29,37 +65,110 @@ func NewPostHandler(repo *repository.PostRepository, tmpl map[string]*template.T
 	}
 }
 
+// WithCache caches rendered list pages in store. Pass the store the
+// repository is cached with, so that writes invalidate the pages too.
+func (h *PostHandler) WithCache(store *cache.Store) *PostHandler {
+	h.cache = store
+	return h
+}
+
+// WithTemplateSource takes the templates from source on every render
+// instead of the templates the handler was created with
+func (h *PostHandler) WithTemplateSource(source TemplateSource) *PostHandler {
+	h.templates = source
+	return h
+}
+
 // isHTMXRequest checks if the request is from HTMX
 func isHTMXRequest(r *http.Request) bool {
 	return r.Header.Get("HX-Request") == "true"
 }
 
-// renderTemplate renders the specified template with the given data
-func (h *PostHandler) renderTemplate(w http.ResponseWriter, r *http.Request, templateName string, data map[string]interface{}, pushURL string) {
-	isHTMX := isHTMXRequest(r)
+// translator returns the translator into the locale of the request
+func translator(r *http.Request) *i18n.Translator {
+	return i18n.FromContext(r.Context())
+}
 
-	if isHTMX {
-		if pushURL != "" {
-			w.Header().Set("HX-Push-Url", pushURL)
-		}
-		if err := h.tmpl[templateName].ExecuteTemplate(w, "content", data); err != nil {
-			http.Error(w, fmt.Sprintf("Failed to render template: %v", err), http.StatusInternalServerError)
-		}
+// renderTemplate renders the specified page with its view model, filling in
+// the layout data
+func (h *PostHandler) renderTemplate(w http.ResponseWriter, r *http.Request, page templates.Page, data views.View, pushURL string) {
+	h.renderTemplateStatus(w, r, http.StatusOK, page, data, pushURL)
+}
+
+// renderTemplateStatus renders the page like renderTemplate with the given
+// status. The status is written last, after the layout has set its flash
+// cookie and HTMX headers.
+func (h *PostHandler) renderTemplateStatus(w http.ResponseWriter, r *http.Request, status int, page templates.Page, data views.View, pushURL string) {
+	*data.LayoutData() = h.layout(w, r)
+
+	// Render ahead, so a failing template doesn't leave half a page behind
+	var buf bytes.Buffer
+	if err := h.executeTemplate(&buf, r, page, data); err != nil {
+		h.renderTemplateError(w, r, err)
 		return
 	}
 
-	if err := h.tmpl[templateName].Execute(w, data); err != nil {
-		http.Error(w, "Failed to render template", http.StatusInternalServerError)
+	if isHTMXRequest(r) && pushURL != "" {
+		w.Header().Set("HX-Push-Url", pushURL)
 	}
+	w.WriteHeader(status)
+	buf.WriteTo(w)
 }
 
-// handleError sends an appropriate error response
-func (h *PostHandler) handleError(w http.ResponseWriter, err error, message string, status int) {
-	if err == mongo.ErrNoDocuments {
-		http.Error(w, "Post not found", http.StatusNotFound)
-		return
+// executeTemplate renders the "content" block of the page for HTMX requests
+// and the whole page otherwise
+func (h *PostHandler) executeTemplate(w io.Writer, r *http.Request, page templates.Page, data views.View) error {
+	if isHTMXRequest(r) {
+		return h.executeBlock(w, page, "content", data)
+	}
+	return h.executeBlock(w, page, "", data)
+}
+
+// executeBlock renders a block of the page in the locale of its layout, or
+// all of it in its layout when block is empty. Failures are returned as a
+// *templateError.
+func (h *PostHandler) executeBlock(w io.Writer, page templates.Page, block string, data views.View) error {
+	registry := h.tmpl
+	if h.templates != nil {
+		var err error
+		if registry, err = h.templates.Templates(); err != nil {
+			return &templateError{err: err}
+		}
+	}
+	registry = registry.In(data.LayoutData().Locale)
+
+	var err error
+	if block == "" {
+		err = registry.Render(w, page, data)
+	} else {
+		err = registry.RenderBlock(w, page, block, data)
+	}
+	if err != nil {
+		return &templateError{err: err}
+	}
+	return nil
+}
+
+// formStatus reads the post status from the submitted form, defaulting to published
+func formStatus(r *http.Request) string {
+	if status := r.FormValue("status"); status != "" {
+		return status
+	}
+	return models.StatusPublished
+}
+
+// formVersion reads the version of the post the form was loaded with. ok is
+// false when the form doesn't carry one.
+func formVersion(r *http.Request) (version int64, ok bool, err error) {
+	value := r.FormValue("version")
+	if value == "" {
+		return 0, false, nil
+	}
+	version, err = strconv.ParseInt(value, 10, 64)
+	if err != nil || version < 0 {
+		return 0, false, fmt.Errorf("invalid version %q", value)
 	}
-	http.Error(w, message, status)
+	return version, true, nil
 }
 
 // redirectResponse redirects the user to the specified URL
@@ -73,209 +182,749 @@ func (h *PostHandler) redirectResponse(w http.ResponseWriter, r *http.Request, u
 	http.Redirect(w, r, url, http.StatusSeeOther)
 }
 
-func (h *PostHandler) Index(w http.ResponseWriter, r *http.Request) {
-	page := 1
-	limit := int64(h.config.App.PostsPerPage)
-
-	pageStr := r.URL.Query().Get("page")
-	if pageStr != "" {
-		pageInt, err := strconv.Atoi(pageStr)
-		if err == nil && pageInt > 0 {
-			page = pageInt
+// Pagination styles of the post list
+const (
+	paginationLinks    = "links"
+	paginationLoadMore = "load_more"
+	paginationInfinite = "infinite"
+)
+
+// loadMoreTarget is the element that "load more" and infinite scroll requests
+// replace with the next posts
+const loadMoreTarget = "load-more"
+
+// postListResponse is the JSON form of a page of the post list
+type postListResponse struct {
+	Posts      []models.Post `json:"posts"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+	PrevCursor string        `json:"prev_cursor,omitempty"`
+	Total      *int64        `json:"total,omitempty"`
+}
+
+// pagination returns the configured pagination style of the post list
+func (h *PostHandler) pagination() string {
+	switch h.config.App.Pagination {
+	case paginationLoadMore, paginationInfinite:
+		return h.config.App.Pagination
+	default:
+		return paginationLinks
+	}
+}
+
+// maxListParam caps the length of the free text parameters of the post list
+const maxListParam = 200
+
+// sortChoices are the sorts offered on the post list, in menu order.
+// Relevance is only offered when searching.
+var sortChoices = []views.SortChoice{
+	{Value: repository.SortNewest, Label: "sort.newest"},
+	{Value: repository.SortOldest, Label: "sort.oldest"},
+	{Value: repository.SortUpdated, Label: "sort.updated"},
+	{Value: repository.SortTitle, Label: "sort.title"},
+	{Value: repository.SortRelevance, Label: "sort.relevance"},
+}
+
+// listOptions are the sort and filters of the post list, with the methods
+// building its URLs
+type listOptions views.ListOptions
+
+// parseListOptions reads the sort and filters of the post list from the
+// query, rejecting unknown sorts, malformed dates and statuses, and
+// oversized values
+func parseListOptions(tr *i18n.Translator, query url.Values) (listOptions, repository.PostFilter, error) {
+	opts := listOptions{
+		Search:   query.Get("search"),
+		Sort:     query.Get("sort"),
+		From:     query.Get("from"),
+		To:       query.Get("to"),
+		Tag:      query.Get("tag"),
+		Author:   query.Get("author"),
+		Status:   query.Get("status"),
+		Language: query.Get("language"),
+	}
+
+	for _, param := range []struct{ name, value string }{
+		{"search", opts.Search}, {"tag", opts.Tag}, {"author", opts.Author}, {"language", opts.Language},
+	} {
+		if len(param.value) > maxListParam {
+			return opts, repository.PostFilter{}, errors.New(tr.T("list.too_long", param.name, maxListParam))
 		}
 	}
 
-	// Get search parameter from query
-	search := r.URL.Query().Get("search")
+	if opts.Sort == "" {
+		opts.Sort = repository.SortNewest
+	}
+	if !repository.ValidSort(opts.Sort) {
+		return opts, repository.PostFilter{}, errors.New(tr.T("list.invalid_sort", opts.Sort))
+	}
+	if opts.Sort == repository.SortRelevance && opts.Search == "" {
+		return opts, repository.PostFilter{}, errors.New(tr.T("list.relevance_without_search"))
+	}
 
-	posts, totalPages, err := h.repo.FindAll(r.Context(), int64(page), limit, search)
+	filter, err := exporter.ParseFilter(opts.Search, opts.From, opts.To, opts.Status, tr)
 	if err != nil {
-		h.handleError(w, err, "Failed to fetch posts", http.StatusInternalServerError)
+		return opts, filter, err
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return opts, filter, errors.New(tr.T("list.dates_reversed"))
+	}
+	filter.Tag = opts.Tag
+	filter.Author = opts.Author
+	filter.Language = opts.Language
+
+	return opts, filter, nil
+}
+
+// query returns the options as URL query values, leaving out defaults
+func (o listOptions) query() url.Values {
+	query := url.Values{}
+	for _, param := range []struct{ name, value string }{
+		{"search", o.Search}, {"from", o.From}, {"to", o.To},
+		{"tag", o.Tag}, {"author", o.Author}, {"status", o.Status},
+		{"language", o.Language},
+	} {
+		if param.value != "" {
+			query.Set(param.name, param.value)
+		}
+	}
+	if o.Sort != "" && o.Sort != repository.SortNewest {
+		query.Set("sort", o.Sort)
+	}
+	return query
+}
+
+// url returns the URL of the list at path with these options, positioned at
+// the cursor given in param ("after" or "before")
+func (o listOptions) url(path, param, cursor string) string {
+	query := o.query()
+	if cursor != "" {
+		query.Set(param, cursor)
+	}
+	if len(query) == 0 {
+		return path
+	}
+	return path + "?" + query.Encode()
+}
+
+// listPage is the view model of a page of the post list
+func (h *PostHandler) listPage(page repository.PostPage, opts listOptions) *views.ListPage {
+	options := views.ListOptions(opts)
+	data := &views.ListPage{
+		Posts:      page.Posts,
+		Page:       &page,
+		Search:     opts.Search,
+		Options:    &options,
+		Sorts:      sortChoices,
+		Pagination: h.pagination(),
+	}
+	if page.NextCursor != "" {
+		data.NextURL = opts.url("/posts", "after", page.NextCursor)
+	}
+	if page.PrevCursor != "" {
+		data.PrevURL = opts.url("/posts", "before", page.PrevCursor)
+	}
+	return data
+}
+
+// Index lists posts a page at a time in the sort and with the filters given
+// in the URL. Pages are addressed by the after and before cursors, and the
+// list is also served as JSON at /posts.json. The list is in the language
+// given in the URL, which defaults to the locale of the page for HTML, and
+// falls back to the fallback language for stories that have no translation
+// into it. JSON lists have every language unless the URL names one.
+func (h *PostHandler) Index(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	format, _ := r.Context().Value(middleware.URLFormatCtxKey).(string)
+	if format != "" && format != "json" {
+		h.NotFound(w, r)
 		return
 	}
 
-	data := map[string]interface{}{
-		"Posts":       posts,
-		"CurrentPage": page,
-		"TotalPages":  totalPages,
-		"Search":      search,
+	opts, filter, err := parseListOptions(translator(r), query)
+	if err != nil {
+		h.renderError(w, r, apperr.BadRequest(err.Error(), err))
+		return
 	}
 
-	// Include search parameter in pagination URL if it exists
-	paginationURL := fmt.Sprintf("/posts?page=%d", page)
-	if search != "" {
-		paginationURL = fmt.Sprintf("/posts?page=%d&search=%s", page, search)
+	// Cached pages are keyed by one cursor, so the store never sees both
+	if query.Get("after") != "" && query.Get("before") != "" {
+		h.renderError(w, r, apperr.BadRequest(translator(r).T("error.invalid_cursor"), repository.ErrInvalidCursor))
+		return
 	}
 
-	h.renderTemplate(w, r, "post_list", data, paginationURL)
+	pushURL := opts.url("/posts", "after", query.Get("after"))
+	if before := query.Get("before"); before != "" {
+		pushURL = opts.url("/posts", "before", before)
+	}
+
+	rep := representation(r)
+	filter = h.listLanguage(r, rep, filter)
+
+	// Pages showing flash messages are rendered for this request alone
+	var layout views.Layout
+	var flashed bool
+	if rep != representationJSON {
+		flashed = h.flashes.Pending(r)
+		layout = h.layout(w, r)
+	}
+
+	// Another request may share the load, so it mustn't fail when this one
+	// is cancelled
+	ctx := context.WithoutCancel(r.Context())
+	load := func() ([]byte, error) {
+		page, err := h.repo.FindPage(ctx, repository.PageRequest{
+			Filter:    filter,
+			Sort:      opts.Sort,
+			After:     query.Get("after"),
+			Before:    query.Get("before"),
+			Limit:     int64(h.config.App.PostsPerPage),
+			SkipCount: !h.config.App.CountPosts,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		response, err := h.renderPostList(r, rep, page, opts, pushURL, layout)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(response)
+	}
+
+	var cached []byte
+	if flashed {
+		cached, err = load()
+	} else {
+		cached, err = h.cache.Fetch("list:"+rep+":"+h.pagination()+":"+pushURL, load)
+	}
+	var templateErr *templateError
+	if errors.As(err, &templateErr) {
+		h.renderTemplateError(w, r, templateErr)
+		return
+	}
+	if err != nil {
+		h.handleError(w, r, err, "Failed to fetch posts")
+		return
+	}
+
+	var response renderedResponse
+	if err := json.Unmarshal(cached, &response); err != nil {
+		h.handleError(w, r, err, "Failed to fetch posts")
+		return
+	}
+
+	// Lists have no Last-Modified: a deleted post doesn't move any timestamp
+	if !flashed && notModified(w, r, response.ETag, time.Time{}) {
+		return
+	}
+	response.write(w)
+}
+
+// listLanguage narrows a filter of the post list down to its language. HTML
+// lists default to the locale of the request, which their representation
+// already tells apart.
+func (h *PostHandler) listLanguage(r *http.Request, rep string, filter repository.PostFilter) repository.PostFilter {
+	if filter.Language == "" && rep != representationJSON {
+		filter.Language = translator(r).Locale()
+	}
+	filter.FallbackLanguage = h.fallbackLanguage()
+	return filter
+}
+
+// renderedResponse is a response rendered ahead of writing it, so that it
+// can be cached
+type renderedResponse struct {
+	ETag   string            `json:"etag"`
+	Header map[string]string `json:"header,omitempty"`
+	Body   []byte            `json:"body"`
+}
+
+func (res renderedResponse) write(w http.ResponseWriter) {
+	for key, value := range res.Header {
+		w.Header().Set(key, value)
+	}
+	w.Write(res.Body)
+}
+
+// renderPostList renders a page of the post list in the given representation
+func (h *PostHandler) renderPostList(r *http.Request, rep string, page repository.PostPage, opts listOptions, pushURL string, layout views.Layout) (renderedResponse, error) {
+	response := renderedResponse{
+		ETag:   listETag(rep, pushURL, h.pagination(), page),
+		Header: map[string]string{},
+	}
+
+	if rep == representationJSON {
+		body, link, err := postListJSON(page, opts)
+		if err != nil {
+			return response, err
+		}
+		response.Body = body
+		response.Header["Content-Type"] = "application/json; charset=utf-8"
+		if link != "" {
+			response.Header["Link"] = link
+		}
+		return response, nil
+	}
+
+	var buf bytes.Buffer
+	data := h.listPage(page, opts)
+	data.Layout = layout
+
+	// Load more and infinite scroll only need the next posts
+	if isHTMXRequest(r) && r.Header.Get("HX-Target") == loadMoreTarget {
+		if err := h.executeBlock(&buf, templates.PostList, "post_items", data); err != nil {
+			return response, err
+		}
+		response.Body = buf.Bytes()
+		return response, nil
+	}
+
+	if isHTMXRequest(r) {
+		response.Header["HX-Push-Url"] = pushURL
+	}
+	if err := h.executeTemplate(&buf, r, templates.PostList, data); err != nil {
+		return response, err
+	}
+	response.Body = buf.Bytes()
+	return response, nil
+}
+
+// postListJSON encodes a page of the post list as JSON and links the
+// neighbouring pages for a Link header
+func postListJSON(page repository.PostPage, opts listOptions) ([]byte, string, error) {
+	response := postListResponse{
+		Posts:      page.Posts,
+		NextCursor: page.NextCursor,
+		PrevCursor: page.PrevCursor,
+	}
+	if response.Posts == nil {
+		response.Posts = []models.Post{}
+	}
+	if page.Total >= 0 {
+		response.Total = &page.Total
+	}
+
+	var links []string
+	if page.NextCursor != "" {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, opts.url("/posts.json", "after", page.NextCursor)))
+	}
+	if page.PrevCursor != "" {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, opts.url("/posts.json", "before", page.PrevCursor)))
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(response); err != nil {
+		return nil, "", fmt.Errorf("failed to encode posts: %w", err)
+	}
+	return buf.Bytes(), strings.Join(links, ", "), nil
+}
+
+// findPost looks a post up by its slug, one of its previous slugs or its ID
+func (h *PostHandler) findPost(ctx context.Context, ref string) (models.Post, error) {
+	if primitive.IsValidObjectID(ref) {
+		post, err := h.repo.FindByID(ctx, ref)
+		if err != mongo.ErrNoDocuments {
+			return post, err
+		}
+	}
+	return h.repo.FindBySlug(ctx, ref)
 }
 
 func (h *PostHandler) Show(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
-	post, err := h.repo.FindByID(r.Context(), id)
+	// Posts are only served as pages
+	if !hasURLFormat(r, "") {
+		h.NotFound(w, r)
+		return
+	}
+
+	ref := chi.URLParam(r, "id")
+	post, err := h.findPost(r.Context(), ref)
+	if err != nil {
+		h.handleError(w, r, err, "Failed to fetch post")
+		return
+	}
+
+	// Posts addressed by ID or an old slug move permanently to the current slug
+	if post.Slug != "" && ref != post.Slug {
+		http.Redirect(w, r, post.Path(), http.StatusMovedPermanently)
+		return
+	}
+
+	translations, err := h.translations(r.Context(), post)
 	if err != nil {
-		h.handleError(w, err, "Failed to fetch post", http.StatusInternalServerError)
+		h.handleError(w, r, err, "Failed to fetch translations")
 		return
 	}
 
-	data := map[string]interface{}{
-		"Post": post,
+	// A page showing flash messages mustn't come from the browser's cache
+	if !h.flashes.Pending(r) && notModified(w, r, postETag(post, representation(r), translations...), post.UpdatedAt) {
+		return
 	}
 
-	h.renderTemplate(w, r, "show", data, fmt.Sprintf("/posts/%s", id))
+	h.renderTemplate(w, r, templates.PostShow, h.postPage(r, post, translations), post.Path())
 }
 
 func (h *PostHandler) New(w http.ResponseWriter, r *http.Request) {
-	data := map[string]interface{}{
-		"Post":   models.Post{},
-		"Title":  "Create New Post",
-		"Action": "/posts",
-		"Method": "post",
+	data := &views.FormPage{
+		Title:     translator(r).T("form.create_title"),
+		Post:      models.Post{Language: translator(r).Locale()},
+		Action:    "/posts",
+		Method:    "post",
+		Languages: translator(r).Locales(),
+		Upload:    h.uploadLimits(),
 	}
 
-	h.renderTemplate(w, r, "form", data, "/posts/new")
+	h.renderTemplate(w, r, templates.PostForm, data, "/posts/new")
 }
 
 func (h *PostHandler) Create(w http.ResponseWriter, r *http.Request) {
-	if err := r.ParseForm(); err != nil {
-		h.handleError(w, err, "Failed to parse form", http.StatusBadRequest)
+	if appErr := h.parsePostForm(w, r); appErr != nil {
+		h.renderError(w, r, appErr)
 		return
 	}
 
 	post := models.Post{
-		Title:   r.FormValue("title"),
-		Content: r.FormValue("content"),
+		Title:            r.FormValue("title"),
+		Content:          r.FormValue("content"),
+		Status:           formStatus(r),
+		Language:         r.FormValue("language"),
+		TranslationGroup: r.FormValue("translation_group"),
 	}
 
-	errors, valid := validation.ValidatePost(post)
+	errors, valid := validation.ValidatePost(post, translator(r))
+	if valid {
+		message, err := h.translationError(r, post)
+		if err != nil {
+			h.handleError(w, r, err, "Failed to fetch translations")
+			return
+		}
+		errors.Language, valid = message, message == ""
+	}
+	uploads, message, err := h.readUploads(r, 0)
+	if err != nil {
+		h.renderError(w, r, apperr.BadRequest(translator(r).T("error.upload_unreadable"), err))
+		return
+	}
+	if message != "" {
+		errors.Attachments, valid = message, false
+	}
 	if !valid {
-		data := map[string]interface{}{
-			"Post":   post,
-			"Errors": errors,
-			"Title":  "Create New Post",
-			"Action": "/posts",
-			"Method": "post",
+		title := translator(r).T("form.create_title")
+		if post.TranslationGroup != "" {
+			title = translator(r).T("form.translate_title", translator(r).T("language."+h.postLanguage(post)))
+		}
+		data := &views.FormPage{
+			Title:     title,
+			Post:      post,
+			Action:    "/posts",
+			Method:    "post",
+			Languages: translator(r).Locales(),
+			Errors:    errors,
+			Upload:    h.uploadLimits(),
 		}
 
-		h.renderTemplate(w, r, "form", data, "")
+		h.renderTemplateStatus(w, r, http.StatusUnprocessableEntity, templates.PostForm, data, "")
 		return
 	}
 
+	post.Attachments, err = h.saveUploads(r.Context(), uploads)
+	if err != nil {
+		h.handleError(w, r, err, "Failed to store attachments")
+		return
+	}
 	id, err := h.repo.Create(r.Context(), post)
 	if err != nil {
-		h.handleError(w, err, "Failed to create post", http.StatusInternalServerError)
+		h.deleteAttachments(r.Context(), post.Attachments)
+		h.handleError(w, r, err, "Failed to create post")
 		return
 	}
+	h.processImages(post.Attachments)
 
 	redirectURL := "/posts"
 	if isHTMXRequest(r) {
 		redirectURL = fmt.Sprintf("/posts/%s", id)
 	}
 
-	h.redirectResponse(w, r, redirectURL)
+	h.flashRedirect(w, r, redirectURL, flash.Success(translator(r).T("flash.post_created")))
 }
 
 func (h *PostHandler) Edit(w http.ResponseWriter, r *http.Request) {
-	id := chi.URLParam(r, "id")
-	post, err := h.repo.FindByID(r.Context(), id)
+	post, err := h.findPost(r.Context(), chi.URLParam(r, "id"))
 	if err != nil {
-		h.handleError(w, err, "Failed to fetch post", http.StatusInternalServerError)
+		h.handleError(w, r, err, "Failed to fetch post")
 		return
 	}
 
-	data := map[string]interface{}{
-		"Post":   post,
-		"Title":  "Edit Post",
-		"Action": fmt.Sprintf("/posts/%s", id),
-		"Method": "put",
+	post.Language = h.postLanguage(post)
+	data := &views.FormPage{
+		Title:     translator(r).T("form.edit_title"),
+		Post:      post,
+		Action:    fmt.Sprintf("/posts/%s", post.ID.Hex()),
+		Method:    "put",
+		Languages: translator(r).Locales(),
+		Upload:    h.uploadLimits(),
 	}
 
-	h.renderTemplate(w, r, "form", data, fmt.Sprintf("/posts/%s/edit", id))
+	h.renderTemplate(w, r, templates.PostForm, data, post.Path()+"/edit")
 }
 
 func (h *PostHandler) Update(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
-	if err := r.ParseForm(); err != nil {
-		h.handleError(w, err, "Failed to parse form", http.StatusBadRequest)
+	if appErr := h.parsePostForm(w, r); appErr != nil {
+		h.renderError(w, r, appErr)
 		return
 	}
 
 	existingPost, err := h.repo.FindByID(r.Context(), id)
 	if err != nil {
-		h.handleError(w, err, "Failed to fetch post", http.StatusInternalServerError)
+		h.handleError(w, r, err, "Failed to fetch post")
+		return
+	}
+	if !h.ifMatch(w, r, existingPost) {
+		return
+	}
+
+	// Saves are checked against the version the form was loaded with. Clients
+	// that don't send one save over whatever is stored.
+	version, ok, err := formVersion(r)
+	if err != nil {
+		h.renderError(w, r, apperr.BadRequest(err.Error(), err))
 		return
 	}
+	if ok {
+		existingPost.Version = version
+	}
 
 	existingPost.Title = r.FormValue("title")
 	existingPost.Content = r.FormValue("content")
+	// Clients that don't send the status, like older forms and API callers,
+	// leave it as it is instead of publishing drafts
+	if _, ok := r.Form["status"]; ok {
+		existingPost.Status = formStatus(r)
+	}
+	if _, ok := r.Form["language"]; ok {
+		existingPost.Language = r.FormValue("language")
+	}
 
-	errors, valid := validation.ValidatePost(existingPost)
+	validationErrors, valid := validation.ValidatePost(existingPost, translator(r))
+	if valid {
+		message, err := h.translationError(r, existingPost)
+		if err != nil {
+			h.handleError(w, r, err, "Failed to fetch translations")
+			return
+		}
+		validationErrors.Language, valid = message, message == ""
+	}
+	kept, removed := removeAttachments(r, existingPost.Attachments)
+	uploads, message, err := h.readUploads(r, len(kept))
+	if err != nil {
+		h.renderError(w, r, apperr.BadRequest(translator(r).T("error.upload_unreadable"), err))
+		return
+	}
+	if message != "" {
+		validationErrors.Attachments, valid = message, false
+	}
 	if !valid {
-		data := map[string]interface{}{
-			"Post":   existingPost,
-			"Errors": errors,
-			"Title":  "Edit Post",
-			"Action": fmt.Sprintf("/posts/%s", id),
-			"Method": "put",
+		data := &views.FormPage{
+			Title:     translator(r).T("form.edit_title"),
+			Post:      existingPost,
+			Action:    fmt.Sprintf("/posts/%s", id),
+			Method:    "put",
+			Languages: translator(r).Locales(),
+			Errors:    validationErrors,
+			Upload:    h.uploadLimits(),
 		}
 
-		h.renderTemplate(w, r, "form", data, "")
+		h.renderTemplateStatus(w, r, http.StatusUnprocessableEntity, templates.PostForm, data, "")
 		return
 	}
 
+	added, err := h.saveUploads(r.Context(), uploads)
+	if err != nil {
+		h.handleError(w, r, err, "Failed to store attachments")
+		return
+	}
+	existingPost.Attachments = append(kept, added...)
+	if _, ok := r.Form["featured_image"]; ok {
+		existingPost.FeaturedImage = r.FormValue("featured_image")
+	}
+	// A removed featured image leaves the first image featured
+	if !slices.ContainsFunc(kept, func(a models.Attachment) bool { return a.ID == existingPost.FeaturedImage }) {
+		existingPost.FeaturedImage = ""
+	}
+
 	err = h.repo.Update(r.Context(), id, existingPost)
 	if err != nil {
-		h.handleError(w, err, "Failed to update post", http.StatusInternalServerError)
+		// The post keeps the attachments it had, and the conflict screen
+		// mustn't offer the uploads deleted here
+		h.deleteAttachments(r.Context(), added)
+		existingPost.Attachments = kept
+		if slices.ContainsFunc(added, func(a models.Attachment) bool { return a.ID == existingPost.FeaturedImage }) {
+			existingPost.FeaturedImage = ""
+		}
+	}
+	if errors.Is(err, repository.ErrVersionConflict) {
+		// API clients asked for the save to depend on the version they hold
+		if r.Header.Get("If-Match") != "" {
+			preconditionFailed(w, r)
+			return
+		}
+		h.renderConflict(w, r, id, existingPost)
 		return
 	}
+	if err != nil {
+		h.handleError(w, r, err, "Failed to update post")
+		return
+	}
+	h.deleteAttachments(r.Context(), removed)
+	h.processImages(added)
 
 	redirectURL := "/posts"
 	if isHTMXRequest(r) {
 		redirectURL = fmt.Sprintf("/posts/%s", id)
 	}
 
-	h.redirectResponse(w, r, redirectURL)
+	h.flashRedirect(w, r, redirectURL, flash.Success(translator(r).T("flash.post_updated")))
+}
+
+// renderConflict re-renders the edit form after a save lost the race against
+// another edit. The form shows the stored post next to the submitted changes
+// and carries the stored version, so saving it again merges or overwrites.
+func (h *PostHandler) renderConflict(w http.ResponseWriter, r *http.Request, id string, submitted models.Post) {
+	current, err := h.repo.FindByID(r.Context(), id)
+	if err != nil {
+		h.handleError(w, r, err, "Failed to fetch post")
+		return
+	}
+
+	submitted.Version = current.Version
+	data := &views.FormPage{
+		Title:     translator(r).T("form.edit_title"),
+		Post:      submitted,
+		Action:    fmt.Sprintf("/posts/%s", id),
+		Method:    "put",
+		Languages: translator(r).Locales(),
+		Conflict:  &current,
+		Upload:    h.uploadLimits(),
+	}
+
+	h.renderTemplateStatus(w, r, http.StatusConflict, templates.PostForm, data, "")
 }
 
 func (h *PostHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
-	err := h.repo.Delete(r.Context(), id)
+	// The post is looked up first for the attachments to delete with it
+	post, err := h.repo.FindByID(r.Context(), id)
+	if err == nil {
+		if r.Header.Get("If-Match") != "" {
+			// Only delete the version the client's entity tag matched
+			if !h.ifMatch(w, r, post) {
+				return
+			}
+			err = h.repo.DeleteVersion(r.Context(), id, post.Version)
+			if errors.Is(err, repository.ErrVersionConflict) {
+				preconditionFailed(w, r)
+				return
+			}
+		} else {
+			err = h.repo.Delete(r.Context(), id)
+		}
+	}
 	if err != nil {
-		h.handleError(w, err, "Failed to delete post", http.StatusInternalServerError)
+		h.handleError(w, r, err, "Failed to delete post")
 		return
 	}
+	h.deleteAttachments(r.Context(), post.Attachments)
 
-	h.redirectResponse(w, r, "/posts")
+	h.flashRedirect(w, r, "/posts", flash.Success(translator(r).T("flash.post_deleted")))
+}
+
+// seedOptions reads the seed mode, count, generator and random seed from the request,
+// falling back to the configured defaults
+func (h *PostHandler) seedOptions(r *http.Request) (seeder.Options, error) {
+	opts := seeder.Options{
+		Count:       h.config.App.SeedCount,
+		Generator:   h.config.App.SeedGenerator,
+		FixturePath: h.config.App.SeedFixtures,
+		Media:       h.media,
+	}
+
+	if generator := r.FormValue("generator"); generator != "" {
+		opts.Generator = generator
+	}
+	if !seeder.HasGenerator(opts.Generator) {
+		return opts, fmt.Errorf("unknown generator %q", opts.Generator)
+	}
+
+	mode := r.FormValue("mode")
+	if mode == "" {
+		mode = h.config.App.SeedMode
+	}
+	parsedMode, err := seeder.ParseMode(mode)
+	if err != nil {
+		return opts, err
+	}
+	opts.Mode = parsedMode
+
+	if countStr := r.FormValue("count"); countStr != "" {
+		count, err := strconv.Atoi(countStr)
+		if err != nil || count < 1 || count > h.config.App.SeedMaxCount {
+			return opts, fmt.Errorf("count must be between 1 and %d", h.config.App.SeedMaxCount)
+		}
+		opts.Count = count
+	}
+
+	if seedStr := r.FormValue("seed"); seedStr != "" {
+		seed, err := strconv.ParseInt(seedStr, 10, 64)
+		if err != nil {
+			return opts, fmt.Errorf("seed must be an integer")
+		}
+		opts.Seed = seed
+	}
+
+	return opts, nil
 }
 
 func (h *PostHandler) Seed(w http.ResponseWriter, r *http.Request) {
-	samplePosts := seeder.GenerateSamplePosts(10)
+	if err := r.ParseForm(); err != nil {
+		h.renderError(w, r, apperr.BadRequest(translator(r).T("error.form_unreadable"), err))
+		return
+	}
 
-	err := h.repo.CreateMany(r.Context(), samplePosts)
+	opts, err := h.seedOptions(r)
 	if err != nil {
-		h.handleError(w, err, "Failed to seed database: "+err.Error(), http.StatusInternalServerError)
+		h.renderError(w, r, apperr.BadRequest(err.Error(), err))
 		return
 	}
 
+	count, err := seeder.Run(r.Context(), h.repo, opts)
+	if err != nil {
+		h.handleError(w, r, err, "Failed to seed database: "+err.Error())
+		return
+	}
+	message := flash.Success(translator(r).T("flash.seeded", count))
+	if count == 0 {
+		message = flash.Info(translator(r).T("flash.seeded_none"))
+	}
+
 	if isHTMXRequest(r) {
-		posts, totalPages, err := h.repo.FindAll(r.Context(), 1, int64(h.config.App.PostsPerPage), "")
+		page, err := h.repo.FindPage(r.Context(), repository.PageRequest{
+			Filter:    h.listLanguage(r, representationHTMX, repository.PostFilter{}),
+			Limit:     int64(h.config.App.PostsPerPage),
+			SkipCount: !h.config.App.CountPosts,
+		})
 		if err != nil {
-			h.handleError(w, err, "Failed to fetch posts after seeding", http.StatusInternalServerError)
+			h.handleError(w, r, err, "Failed to fetch posts after seeding")
 			return
 		}
 
-		data := map[string]interface{}{
-			"Posts":       posts,
-			"CurrentPage": 1,
-			"TotalPages":  totalPages,
-			"Search":      "",
-		}
-
-		h.renderTemplate(w, r, "post_list", data, "/posts")
+		triggerFlash(w, message)
+		h.renderTemplate(w, r, templates.PostList, h.listPage(page, listOptions{Sort: repository.SortNewest}), "/posts")
 		return
 	}
 
-	h.redirectResponse(w, r, "/posts")
+	h.flashRedirect(w, r, "/posts", message)
 }
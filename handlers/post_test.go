@@ -5,30 +5,53 @@ package handlers
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"html/template"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"reflect"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/gekich/news-app/apperr"
+	"github.com/gekich/news-app/cache"
 	"github.com/gekich/news-app/config"
 	"github.com/gekich/news-app/models"
+	"github.com/gekich/news-app/repository"
+	"github.com/gekich/news-app/seeder"
+	"github.com/gekich/news-app/slug"
+	"github.com/gekich/news-app/templates"
+	"github.com/gekich/news-app/validation"
+	"github.com/gekich/news-app/views"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type PostRepositoryInterface interface {
 	FindAll(ctx context.Context, page, limit int64, search string) ([]models.Post, int64, error)
+	FindPage(ctx context.Context, req repository.PageRequest) (repository.PostPage, error)
 	FindByID(ctx context.Context, id string) (models.Post, error)
+	FindBySlug(ctx context.Context, slug string) (models.Post, error)
+	FindTranslations(ctx context.Context, group string) ([]models.Post, error)
+	Stream(ctx context.Context, filter repository.PostFilter, fn func(models.Post) error) error
+	Count(ctx context.Context, filter repository.PostFilter) (int64, error)
 	Create(ctx context.Context, post models.Post) (string, error)
 	Update(ctx context.Context, id string, post models.Post) error
 	Delete(ctx context.Context, id string) error
-	CreateMany(ctx context.Context, posts []models.Post) error
+	DeleteVersion(ctx context.Context, id string, version int64) error
+	CreateMany(ctx context.Context, posts []models.Post) ([]string, error)
+	ExistingSourceGUIDs(ctx context.Context, guids []string) (map[string]bool, error)
+	ReplaceAll(ctx context.Context, posts []models.Post) error
+	ReplaceAllBatched(ctx context.Context, fill func(insert func([]models.Post) error) error) error
 }
 
 type MockPostRepository struct {
@@ -65,16 +88,355 @@ func (m *MockPostRepository) FindAll(ctx context.Context, page, limit int64, sea
 	return posts, totalPages, nil
 }
 
+func (m *MockPostRepository) FindPage(ctx context.Context, req repository.PageRequest) (repository.PostPage, error) {
+	page := repository.PostPage{Total: -1}
+	if m.shouldFail {
+		return page, fmt.Errorf("mock error")
+	}
+
+	if req.Sort == "" {
+		req.Sort = repository.SortNewest
+	}
+	if !repository.ValidSort(req.Sort) || (req.Sort == repository.SortRelevance && req.Filter.Search == "") {
+		return page, repository.ErrInvalidSort
+	}
+	if req.After != "" && req.Before != "" {
+		return page, repository.ErrInvalidCursor
+	}
+
+	posts := m.filtered(req.Filter)
+	sort.Slice(posts, func(i, j int) bool {
+		return compareMockKeys(mockPostKeys(req.Sort, req.Filter.Search, posts[i]), mockPostKeys(req.Sort, req.Filter.Search, posts[j])) < 0
+	})
+
+	// position returns the index of the first post past the cursor
+	position := func(ref string) (int, error) {
+		cursor, err := repository.ParseCursor(ref)
+		if err != nil || cursor.Sort != req.Sort {
+			return 0, repository.ErrInvalidCursor
+		}
+		keys := mockKeys(req.Sort, normalizeMockKeys(cursor.Keys), cursor.ID.Hex())
+		i := 0
+		for i < len(posts) && compareMockKeys(mockPostKeys(req.Sort, req.Filter.Search, posts[i]), keys) <= 0 {
+			i++
+		}
+		return i, nil
+	}
+
+	start, end := 0, len(posts)
+	switch {
+	case req.After != "":
+		i, err := position(req.After)
+		if err != nil {
+			return page, err
+		}
+		start = i
+		if req.Limit > 0 {
+			end = min(start+int(req.Limit), len(posts))
+		}
+	case req.Before != "":
+		i, err := position(req.Before)
+		if err != nil {
+			return page, err
+		}
+		// Skip the post the cursor points at
+		end = max(i-1, 0)
+		if req.Limit > 0 {
+			start = max(end-int(req.Limit), 0)
+		}
+	default:
+		if req.Limit > 0 {
+			end = min(int(req.Limit), len(posts))
+		}
+	}
+
+	page.Posts = posts[start:end]
+	if len(page.Posts) > 0 {
+		if end < len(posts) {
+			page.NextCursor = mockCursor(req.Sort, req.Filter.Search, posts[end-1])
+		}
+		if start > 0 {
+			page.PrevCursor = mockCursor(req.Sort, req.Filter.Search, posts[start])
+		}
+	}
+	if !req.SkipCount {
+		page.Total = int64(len(posts))
+	}
+	return page, nil
+}
+
+// mockPostKeys returns the keys a post is ordered by in the given sort
+func mockPostKeys(sortName, search string, post models.Post) []interface{} {
+	var values []interface{}
+	switch sortName {
+	case repository.SortNewest, repository.SortOldest:
+		values = []interface{}{post.CreatedAt.UnixMilli()}
+	case repository.SortUpdated:
+		values = []interface{}{post.UpdatedAt.UnixMilli()}
+	case repository.SortTitle:
+		values = []interface{}{strings.ToLower(post.Title)}
+	case repository.SortRelevance:
+		values = []interface{}{int64(mockScore(search, post)), post.CreatedAt.UnixMilli()}
+	}
+	return mockKeys(sortName, values, post.ID.Hex())
+}
+
+// mockKeys turns the sort values and ID of a post into keys whose ascending
+// order is the order of the sort
+func mockKeys(sortName string, values []interface{}, id string) []interface{} {
+	descending := sortName == repository.SortNewest || sortName == repository.SortUpdated ||
+		sortName == repository.SortRelevance
+
+	keys := make([]interface{}, 0, len(values)+1)
+	for _, value := range values {
+		if n, ok := value.(int64); ok && descending {
+			value = -n
+		}
+		keys = append(keys, value)
+	}
+	if descending {
+		id = reverseHex(id)
+	}
+	return append(keys, id)
+}
+
+// mockCursor returns the cursor pointing at a post in the given sort
+func mockCursor(sortName, search string, post models.Post) string {
+	cursor := repository.Cursor{Sort: sortName, ID: post.ID}
+	switch sortName {
+	case repository.SortNewest, repository.SortOldest:
+		cursor.Keys = bson.A{post.CreatedAt}
+	case repository.SortUpdated:
+		cursor.Keys = bson.A{post.UpdatedAt}
+	case repository.SortTitle:
+		cursor.Keys = bson.A{strings.ToLower(post.Title)}
+	case repository.SortRelevance:
+		cursor.Keys = bson.A{mockScore(search, post), post.CreatedAt}
+	}
+	return cursor.String()
+}
+
+// normalizeMockKeys converts the keys of a parsed cursor into the form
+// returned by mockSortKeys for its sort
+func normalizeMockKeys(values bson.A) []interface{} {
+	var keys []interface{}
+	for _, value := range values {
+		switch v := value.(type) {
+		case primitive.DateTime:
+			keys = append(keys, v.Time().UnixMilli())
+		case int32:
+			keys = append(keys, int64(v))
+		case int64:
+			keys = append(keys, v)
+		default:
+			keys = append(keys, value)
+		}
+	}
+	return keys
+}
+
+// mockScore mirrors the relevance score of the repository: two points for a
+// match in the title and one for a match in the content
+func mockScore(search string, post models.Post) int {
+	search = strings.ToLower(search)
+	score := 0
+	if strings.Contains(strings.ToLower(post.Title), search) {
+		score += 2
+	}
+	if strings.Contains(strings.ToLower(post.Content), search) {
+		score++
+	}
+	return score
+}
+
+// reverseHex maps a hex string to one that sorts in the opposite order
+func reverseHex(hex string) string {
+	const digits = "0123456789abcdef"
+	reversed := []byte(hex)
+	for i, c := range reversed {
+		reversed[i] = digits[15-strings.IndexByte(digits, c)]
+	}
+	return string(reversed)
+}
+
+// compareMockKeys compares two key lists element by element
+func compareMockKeys(a, b []interface{}) int {
+	for i := range a {
+		switch x := a[i].(type) {
+		case int64:
+			if y := b[i].(int64); x != y {
+				if x < y {
+					return -1
+				}
+				return 1
+			}
+		case string:
+			if y := b[i].(string); x != y {
+				return strings.Compare(x, y)
+			}
+		}
+	}
+	return 0
+}
+
 func (m *MockPostRepository) FindByID(ctx context.Context, id string) (models.Post, error) {
 	if m.shouldFail {
 		return models.Post{}, fmt.Errorf("mock error")
 	}
 
 	post, exists := m.posts[id]
-	if !exists {
-		return models.Post{}, mongo.ErrNoDocuments
+	if exists {
+		return post, nil
+	}
+	for _, post := range m.posts {
+		if post.ID.Hex() == id {
+			return post, nil
+		}
+	}
+	return models.Post{}, mongo.ErrNoDocuments
+}
+
+func (m *MockPostRepository) FindBySlug(ctx context.Context, s string) (models.Post, error) {
+	if m.shouldFail {
+		return models.Post{}, fmt.Errorf("mock error")
+	}
+
+	for _, post := range m.posts {
+		if post.Slug == s {
+			return post, nil
+		}
+	}
+	for _, post := range m.posts {
+		for _, previous := range post.PreviousSlugs {
+			if previous == s {
+				return post, nil
+			}
+		}
+	}
+	return models.Post{}, mongo.ErrNoDocuments
+}
+
+func (m *MockPostRepository) FindTranslations(ctx context.Context, group string) ([]models.Post, error) {
+	if m.shouldFail {
+		return nil, fmt.Errorf("mock error")
+	}
+
+	var posts []models.Post
+	for _, post := range m.posts {
+		if post.Group() == group {
+			posts = append(posts, post)
+		}
+	}
+	sort.Slice(posts, func(i, j int) bool {
+		return posts[i].Language < posts[j].Language
+	})
+	return posts, nil
+}
+
+// uniqueSlug returns a slug for the title that no other post uses
+func (m *MockPostRepository) uniqueSlug(title, exceptID string) string {
+	taken := make(map[string]bool)
+	for key, post := range m.posts {
+		if key == exceptID {
+			continue
+		}
+		taken[post.Slug] = true
+		for _, previous := range post.PreviousSlugs {
+			taken[previous] = true
+		}
+	}
+
+	base := slug.Make(title)
+	candidate := base
+	for n := 2; taken[candidate]; n++ {
+		candidate = slug.WithSuffix(base, n)
+	}
+	return candidate
+}
+
+func (m *MockPostRepository) Stream(ctx context.Context, filter repository.PostFilter, fn func(models.Post) error) error {
+	if m.shouldFail {
+		return fmt.Errorf("mock error")
+	}
+
+	posts := m.filtered(filter)
+	sort.Slice(posts, func(i, j int) bool {
+		return posts[i].CreatedAt.After(posts[j].CreatedAt)
+	})
+
+	if filter.Skip > 0 {
+		posts = posts[min(filter.Skip, int64(len(posts))):]
+	}
+	if filter.Limit > 0 && int64(len(posts)) > filter.Limit {
+		posts = posts[:filter.Limit]
+	}
+
+	for _, post := range posts {
+		if err := fn(post); err != nil {
+			return err
+		}
 	}
-	return post, nil
+	return nil
+}
+
+func (m *MockPostRepository) Count(ctx context.Context, filter repository.PostFilter) (int64, error) {
+	if m.shouldFail {
+		return 0, fmt.Errorf("mock error")
+	}
+	return int64(len(m.filtered(filter))), nil
+}
+
+// filtered returns the posts matching the filter, ignoring Skip and Limit
+func (m *MockPostRepository) filtered(filter repository.PostFilter) []models.Post {
+	var posts []models.Post
+	search := strings.ToLower(filter.Search)
+	for _, post := range m.posts {
+		if filter.Status != "" && post.Status != filter.Status {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(post.Title), search) &&
+			!strings.Contains(strings.ToLower(post.Content), search) {
+			continue
+		}
+		if filter.Tag != "" && !slices.Contains(post.Tags, filter.Tag) {
+			continue
+		}
+		if filter.Author != "" && post.Author != filter.Author {
+			continue
+		}
+		if !filter.From.IsZero() && post.CreatedAt.Before(filter.From) {
+			continue
+		}
+		if !filter.To.IsZero() && !post.CreatedAt.Before(filter.To) {
+			continue
+		}
+		if filter.Language != "" && !m.inLanguage(post, filter) {
+			continue
+		}
+		posts = append(posts, post)
+	}
+	return posts
+}
+
+// inLanguage reports whether the post is in the language of the filter, or
+// in its fallback language and not translated into it
+func (m *MockPostRepository) inLanguage(post models.Post, filter repository.PostFilter) bool {
+	language := post.Language
+	if language == "" {
+		language = filter.FallbackLanguage
+	}
+	if language == filter.Language {
+		return true
+	}
+	if filter.FallbackLanguage == "" || language != filter.FallbackLanguage {
+		return false
+	}
+	for _, other := range m.posts {
+		if post.TranslationGroup != "" && other.TranslationGroup == post.TranslationGroup && other.Language == filter.Language {
+			return false
+		}
+	}
+	return true
 }
 
 func (m *MockPostRepository) Create(ctx context.Context, post models.Post) (string, error) {
@@ -88,9 +450,18 @@ func (m *MockPostRepository) Create(ctx context.Context, post models.Post) (stri
 	// Create ObjectID for the post
 	objectID, _ := primitive.ObjectIDFromHex(fmt.Sprintf("%024d", m.nextID-1))
 	post.ID = objectID
+	post.Slug = m.uniqueSlug(post.Title, "")
+	post.Version = 1
 	post.CreatedAt = time.Now()
 	post.UpdatedAt = time.Now()
 
+	for key, source := range m.posts {
+		if post.TranslationGroup != "" && source.ID.Hex() == post.TranslationGroup && source.TranslationGroup == "" {
+			source.TranslationGroup = post.TranslationGroup
+			m.posts[key] = source
+		}
+	}
+
 	m.posts[id] = post
 	return id, nil
 }
@@ -100,9 +471,19 @@ func (m *MockPostRepository) Update(ctx context.Context, id string, post models.
 		return fmt.Errorf("mock error")
 	}
 
-	if _, exists := m.posts[id]; !exists {
+	current, exists := m.posts[id]
+	if !exists {
 		return mongo.ErrNoDocuments
 	}
+	if post.Version != current.Version {
+		return repository.ErrVersionConflict
+	}
+	post.Version++
+
+	if slug.Make(post.Title) != slug.Make(current.Title) {
+		post.Slug = m.uniqueSlug(post.Title, id)
+		post.PreviousSlugs = append(current.PreviousSlugs, current.Slug)
+	}
 
 	objectID, _ := primitive.ObjectIDFromHex(fmt.Sprintf("%024s", id))
 	post.ID = objectID
@@ -111,6 +492,37 @@ func (m *MockPostRepository) Update(ctx context.Context, id string, post models.
 	return nil
 }
 
+func (m *MockPostRepository) SetImageVariants(ctx context.Context, attachmentID string, variants []models.ImageVariant) error {
+	for id, post := range m.posts {
+		for i, attachment := range post.Attachments {
+			if attachment.ID == attachmentID {
+				post.Attachments[i].Variants = variants
+				post.UpdatedAt = time.Now()
+				m.posts[id] = post
+				return nil
+			}
+		}
+	}
+	return mongo.ErrNoDocuments
+}
+
+func (m *MockPostRepository) DeleteVersion(ctx context.Context, id string, version int64) error {
+	if m.shouldFail {
+		return fmt.Errorf("mock error")
+	}
+
+	post, exists := m.posts[id]
+	if !exists {
+		return mongo.ErrNoDocuments
+	}
+	if post.Version != version {
+		return repository.ErrVersionConflict
+	}
+
+	delete(m.posts, id)
+	return nil
+}
+
 func (m *MockPostRepository) Delete(ctx context.Context, id string) error {
 	if m.shouldFail {
 		return fmt.Errorf("mock error")
@@ -124,23 +536,79 @@ func (m *MockPostRepository) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
-func (m *MockPostRepository) CreateMany(ctx context.Context, posts []models.Post) error {
+func (m *MockPostRepository) CreateMany(ctx context.Context, posts []models.Post) ([]string, error) {
 	if m.shouldFail {
-		return fmt.Errorf("mock error")
+		return nil, fmt.Errorf("mock error")
 	}
 
+	ids := make([]string, 0, len(posts))
 	for _, post := range posts {
 		id := fmt.Sprintf("%d", m.nextID)
 		m.nextID++
 
 		objectID, _ := primitive.ObjectIDFromHex(fmt.Sprintf("%024d", m.nextID-1))
 		post.ID = objectID
-		post.CreatedAt = time.Now()
-		post.UpdatedAt = time.Now()
+		if post.CreatedAt.IsZero() {
+			post.CreatedAt = time.Now()
+		}
+		if post.UpdatedAt.IsZero() {
+			post.UpdatedAt = post.CreatedAt
+		}
+		if post.Status == "" {
+			post.Status = models.StatusPublished
+		}
+		post.Slug = m.uniqueSlug(post.Title, "")
+		if post.Version == 0 {
+			post.Version = 1
+		}
 
 		m.posts[id] = post
+		ids = append(ids, id)
 	}
-	return nil
+	return ids, nil
+}
+
+func (m *MockPostRepository) ExistingSourceGUIDs(ctx context.Context, guids []string) (map[string]bool, error) {
+	if m.shouldFail {
+		return nil, fmt.Errorf("mock error")
+	}
+
+	existing := make(map[string]bool)
+	for _, guid := range guids {
+		for _, post := range m.posts {
+			if post.SourceGUID == guid {
+				existing[guid] = true
+			}
+		}
+	}
+	return existing, nil
+}
+
+func (m *MockPostRepository) ReplaceAll(ctx context.Context, posts []models.Post) error {
+	if m.shouldFail {
+		return fmt.Errorf("mock error")
+	}
+
+	m.posts = make(map[string]models.Post)
+	_, err := m.CreateMany(ctx, posts)
+	return err
+}
+
+func (m *MockPostRepository) ReplaceAllBatched(ctx context.Context, fill func(insert func([]models.Post) error) error) error {
+	if m.shouldFail {
+		return fmt.Errorf("mock error")
+	}
+
+	var posts []models.Post
+	err := fill(func(batch []models.Post) error {
+		posts = append(posts, batch...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return m.ReplaceAll(ctx, posts)
 }
 
 func (m *MockPostRepository) SetShouldFail(shouldFail bool) {
@@ -178,15 +646,15 @@ func (h *MockablePostHandler) Index(w http.ResponseWriter, r *http.Request) {
 
 	posts, totalPages, err := h.getRepo().FindAll(r.Context(), int64(page), limit, search)
 	if err != nil {
-		h.handleError(w, err, "Failed to fetch posts", http.StatusInternalServerError)
+		h.handleError(w, r, err, "Failed to fetch posts")
 		return
 	}
 
-	data := map[string]interface{}{
-		"Posts":       posts,
-		"CurrentPage": page,
-		"TotalPages":  totalPages,
-		"Search":      search,
+	data := &views.ListPage{
+		Posts:       posts,
+		Search:      search,
+		CurrentPage: page,
+		TotalPages:  int(totalPages),
 	}
 
 	paginationURL := fmt.Sprintf("/posts?page=%d", page)
@@ -194,27 +662,23 @@ func (h *MockablePostHandler) Index(w http.ResponseWriter, r *http.Request) {
 		paginationURL = fmt.Sprintf("/posts?page=%d&search=%s", page, search)
 	}
 
-	h.renderTemplate(w, r, "post_list", data, paginationURL)
+	h.renderTemplate(w, r, templates.PostList, data, paginationURL)
 }
 
 func (h *MockablePostHandler) Show(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	post, err := h.getRepo().FindByID(r.Context(), id)
 	if err != nil {
-		h.handleError(w, err, "Failed to fetch post", http.StatusInternalServerError)
+		h.handleError(w, r, err, "Failed to fetch post")
 		return
 	}
 
-	data := map[string]interface{}{
-		"Post": post,
-	}
-
-	h.renderTemplate(w, r, "show", data, fmt.Sprintf("/posts/%s", id))
+	h.renderTemplate(w, r, templates.PostShow, &views.PostPage{Post: post}, fmt.Sprintf("/posts/%s", id))
 }
 
 func (h *MockablePostHandler) Create(w http.ResponseWriter, r *http.Request) {
 	if err := r.ParseForm(); err != nil {
-		h.handleError(w, err, "Failed to parse form", http.StatusBadRequest)
+		h.renderError(w, r, apperr.BadRequest("Failed to parse form", err))
 		return
 	}
 
@@ -225,21 +689,22 @@ func (h *MockablePostHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 	// Simple validation for tests - just check if title and content are not empty
 	if post.Title == "" || post.Content == "" {
-		data := map[string]interface{}{
-			"Post":   post,
-			"Errors": map[string]string{"title": "Title is required", "content": "Content is required"},
-			"Title":  "Create New Post",
-			"Action": "/posts",
-			"Method": "post",
+		data := &views.FormPage{
+			Title:  "Create New Post",
+			Post:   post,
+			Action: "/posts",
+			Method: "post",
+			Errors: validation.PostError{Title: "Title is required", Content: "Content is required"},
 		}
 
-		h.renderTemplate(w, r, "form", data, "")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		h.renderTemplate(w, r, templates.PostForm, data, "")
 		return
 	}
 
 	id, err := h.getRepo().Create(r.Context(), post)
 	if err != nil {
-		h.handleError(w, err, "Failed to create post", http.StatusInternalServerError)
+		h.handleError(w, r, err, "Failed to create post")
 		return
 	}
 
@@ -255,31 +720,31 @@ func (h *MockablePostHandler) Edit(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	post, err := h.getRepo().FindByID(r.Context(), id)
 	if err != nil {
-		h.handleError(w, err, "Failed to fetch post", http.StatusInternalServerError)
+		h.handleError(w, r, err, "Failed to fetch post")
 		return
 	}
 
-	data := map[string]interface{}{
-		"Post":   post,
-		"Title":  "Edit Post",
-		"Action": fmt.Sprintf("/posts/%s", id),
-		"Method": "put",
+	data := &views.FormPage{
+		Title:  "Edit Post",
+		Post:   post,
+		Action: fmt.Sprintf("/posts/%s", id),
+		Method: "put",
 	}
 
-	h.renderTemplate(w, r, "form", data, fmt.Sprintf("/posts/%s/edit", id))
+	h.renderTemplate(w, r, templates.PostForm, data, fmt.Sprintf("/posts/%s/edit", id))
 }
 
 func (h *MockablePostHandler) Update(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
 	if err := r.ParseForm(); err != nil {
-		h.handleError(w, err, "Failed to parse form", http.StatusBadRequest)
+		h.renderError(w, r, apperr.BadRequest("Failed to parse form", err))
 		return
 	}
 
 	existingPost, err := h.getRepo().FindByID(r.Context(), id)
 	if err != nil {
-		h.handleError(w, err, "Failed to fetch post", http.StatusInternalServerError)
+		h.handleError(w, r, err, "Failed to fetch post")
 		return
 	}
 
@@ -288,21 +753,22 @@ func (h *MockablePostHandler) Update(w http.ResponseWriter, r *http.Request) {
 
 	// Simple validation for tests
 	if existingPost.Title == "" || existingPost.Content == "" {
-		data := map[string]interface{}{
-			"Post":   existingPost,
-			"Errors": map[string]string{"title": "Title is required", "content": "Content is required"},
-			"Title":  "Edit Post",
-			"Action": fmt.Sprintf("/posts/%s", id),
-			"Method": "put",
+		data := &views.FormPage{
+			Title:  "Edit Post",
+			Post:   existingPost,
+			Action: fmt.Sprintf("/posts/%s", id),
+			Method: "put",
+			Errors: validation.PostError{Title: "Title is required", Content: "Content is required"},
 		}
 
-		h.renderTemplate(w, r, "form", data, "")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		h.renderTemplate(w, r, templates.PostForm, data, "")
 		return
 	}
 
 	err = h.getRepo().Update(r.Context(), id, existingPost)
 	if err != nil {
-		h.handleError(w, err, "Failed to update post", http.StatusInternalServerError)
+		h.handleError(w, r, err, "Failed to update post")
 		return
 	}
 
@@ -319,7 +785,7 @@ func (h *MockablePostHandler) Delete(w http.ResponseWriter, r *http.Request) {
 
 	err := h.getRepo().Delete(r.Context(), id)
 	if err != nil {
-		h.handleError(w, err, "Failed to delete post", http.StatusInternalServerError)
+		h.handleError(w, r, err, "Failed to delete post")
 		return
 	}
 
@@ -333,27 +799,26 @@ func (h *MockablePostHandler) Seed(w http.ResponseWriter, r *http.Request) {
 		{Title: "Sample Post 2", Content: "Sample Content 2"},
 	}
 
-	err := h.getRepo().CreateMany(r.Context(), samplePosts)
+	_, err := h.getRepo().CreateMany(r.Context(), samplePosts)
 	if err != nil {
-		h.handleError(w, err, "Failed to seed database: "+err.Error(), http.StatusInternalServerError)
+		h.handleError(w, r, err, "Failed to seed database: "+err.Error())
 		return
 	}
 
 	if isHTMXRequest(r) {
 		posts, totalPages, err := h.getRepo().FindAll(r.Context(), 1, int64(h.config.App.PostsPerPage), "")
 		if err != nil {
-			h.handleError(w, err, "Failed to fetch posts after seeding", http.StatusInternalServerError)
+			h.handleError(w, r, err, "Failed to fetch posts after seeding")
 			return
 		}
 
-		data := map[string]interface{}{
-			"Posts":       posts,
-			"CurrentPage": 1,
-			"TotalPages":  totalPages,
-			"Search":      "",
+		data := &views.ListPage{
+			Posts:       posts,
+			CurrentPage: 1,
+			TotalPages:  int(totalPages),
 		}
 
-		h.renderTemplate(w, r, "post_list", data, "/posts")
+		h.renderTemplate(w, r, templates.PostList, data, "/posts")
 		return
 	}
 
@@ -361,12 +826,16 @@ func (h *MockablePostHandler) Seed(w http.ResponseWriter, r *http.Request) {
 }
 
 // Helper function to create mock templates
-func createMockTemplates() map[string]*template.Template {
-	templates := make(map[string]*template.Template)
+func createMockTemplates() *templates.Registry {
+	return templates.NewRegistryFrom(mockTemplatePages())
+}
 
+// mockTemplatePages returns the mock templates by page
+func mockTemplatePages() map[templates.Page]*template.Template {
 	// Create simple mock templates
 	postListTmpl := template.Must(template.New("post_list").Parse(`
 		{{define "content"}}Posts: {{len .Posts}}{{end}}
+		{{define "post_items"}}Items:{{range .Posts}} {{.Title}}{{end}}{{end}}
 		Posts: {{len .Posts}}
 	`))
 
@@ -376,23 +845,28 @@ func createMockTemplates() map[string]*template.Template {
 	`))
 
 	formTmpl := template.Must(template.New("form").Parse(`
-		{{define "content"}}Form: {{.Title}}{{end}}
-		Form: {{.Title}}
+		{{define "content"}}Form: {{.Title}}{{with .Conflict}} Conflict: {{.Title}}{{end}}{{end}}
+		Form: {{.Title}}{{with .Conflict}} Conflict: {{.Title}}{{end}}
 	`))
 
-	templates["post_list"] = postListTmpl
-	templates["show"] = showTmpl
-	templates["form"] = formTmpl
+	importTmpl := template.Must(template.New("import").Parse(`
+		{{define "content"}}Import: {{with .Report}}{{.Imported}}/{{.Total}}{{end}} {{.Error}}{{end}}
+		Import: {{with .Report}}{{.Imported}}/{{.Total}}{{end}} {{.Error}}
+	`))
 
-	return templates
+	return map[templates.Page]*template.Template{
+		templates.PostList:    postListTmpl,
+		templates.PostShow:    showTmpl,
+		templates.PostForm:    formTmpl,
+		templates.AdminImport: importTmpl,
+	}
 }
 
 func createTestHandler() (*MockablePostHandler, *MockPostRepository) {
 	mockRepo := NewMockPostRepository()
-	templates := createMockTemplates()
 	cfg, _ := config.Load()
 
-	postHandler := NewPostHandler(nil, templates, cfg)
+	postHandler := NewPostHandler(nil, createMockTemplates(), cfg)
 	mockableHandler := &MockablePostHandler{
 		PostHandler: postHandler,
 		mockRepo:    mockRepo,
@@ -413,6 +887,24 @@ func createRequestWithChiContext(method, url string, body *bytes.Buffer) (*http.
 	return req, rr
 }
 
+// loadFixtures fills the mock repository from the shared test fixtures and
+// returns the created post IDs keyed by fixture key
+func loadFixtures(t *testing.T, repo *MockPostRepository) map[string]string {
+	t.Helper()
+
+	fixture, err := seeder.LoadFixtureFile("../testdata/fixtures/posts.yaml")
+	if err != nil {
+		t.Fatalf("Failed to load fixtures: %v", err)
+	}
+
+	ids, err := seeder.LoadFixture(context.Background(), repo, fixture)
+	if err != nil {
+		t.Fatalf("Failed to insert fixtures: %v", err)
+	}
+
+	return ids
+}
+
 func createMockPost(id string, title, content string) models.Post {
 	objectID, _ := primitive.ObjectIDFromHex(fmt.Sprintf("%024s", id))
 	return models.Post{
@@ -428,32 +920,30 @@ func TestPostHandler_Index(t *testing.T) {
 	tests := []struct {
 		name           string
 		queryParams    string
-		mockPosts      map[string]models.Post
 		expectedStatus int
+		expectedBody   string
 		shouldFail     bool
 	}{
 		{
 			name:           "successful index request",
 			queryParams:    "",
-			mockPosts:      map[string]models.Post{"1": createMockPost("1", "Test Post", "Test Content")},
 			expectedStatus: http.StatusOK,
+			expectedBody:   "Posts: 3",
 		},
 		{
 			name:           "index with pagination",
 			queryParams:    "?page=2",
-			mockPosts:      map[string]models.Post{"1": createMockPost("1", "Test Post", "Test Content")},
 			expectedStatus: http.StatusOK,
 		},
 		{
 			name:           "index with search",
-			queryParams:    "?search=test",
-			mockPosts:      map[string]models.Post{"1": createMockPost("1", "Test Post", "Test Content")},
+			queryParams:    "?search=exoplanet",
 			expectedStatus: http.StatusOK,
+			expectedBody:   "Posts: 1",
 		},
 		{
 			name:           "repository error",
 			queryParams:    "",
-			mockPosts:      map[string]models.Post{},
 			expectedStatus: http.StatusInternalServerError,
 			shouldFail:     true,
 		},
@@ -462,7 +952,7 @@ func TestPostHandler_Index(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			handler, mockRepo := createTestHandler()
-			mockRepo.posts = tt.mockPosts
+			loadFixtures(t, mockRepo)
 			mockRepo.SetShouldFail(tt.shouldFail)
 
 			req, rr := createRequestWithChiContext("GET", "/posts"+tt.queryParams, nil)
@@ -472,10 +962,296 @@ func TestPostHandler_Index(t *testing.T) {
 			if rr.Code != tt.expectedStatus {
 				t.Errorf("Expected status %d, got %d", tt.expectedStatus, rr.Code)
 			}
+			if tt.expectedBody != "" && !strings.Contains(rr.Body.String(), tt.expectedBody) {
+				t.Errorf("Expected body to contain %q, got %q", tt.expectedBody, rr.Body.String())
+			}
 		})
 	}
 }
 
+func TestPostHandler_IndexCursor(t *testing.T) {
+	mockRepo := NewMockPostRepository()
+	cfg, _ := config.Load()
+	cfg.App.PostsPerPage = 2
+	handler := NewPostHandler(mockRepo, createMockTemplates(), cfg)
+
+	// Five posts, newest first: Post 5 ... Post 1
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var posts []models.Post
+	for i := 1; i <= 5; i++ {
+		posts = append(posts, models.Post{
+			Title:     fmt.Sprintf("Post %d", i),
+			Content:   "Content",
+			CreatedAt: base.Add(time.Duration(i) * time.Hour),
+		})
+	}
+	if _, err := mockRepo.CreateMany(context.Background(), posts); err != nil {
+		t.Fatalf("Failed to create posts: %v", err)
+	}
+
+	list := func(target string, headers map[string]string) *httptest.ResponseRecorder {
+		r := chi.NewRouter()
+		r.Use(middleware.URLFormat)
+		r.Get("/posts", handler.Index)
+
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	page := func(target string) postListResponse {
+		t.Helper()
+		rr := list(target, nil)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d for %s, got %d", http.StatusOK, target, rr.Code)
+		}
+		var response postListResponse
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode %s: %v", target, err)
+		}
+		return response
+	}
+
+	titles := func(response postListResponse) []string {
+		var titles []string
+		for _, post := range response.Posts {
+			titles = append(titles, post.Title)
+		}
+		return titles
+	}
+
+	first := page("/posts.json")
+	if got := titles(first); !reflect.DeepEqual(got, []string{"Post 5", "Post 4"}) {
+		t.Errorf("Expected the newest posts first, got %v", got)
+	}
+	if first.PrevCursor != "" || first.NextCursor == "" {
+		t.Errorf("Expected only a next cursor on the first page, got next %q prev %q", first.NextCursor, first.PrevCursor)
+	}
+	if first.Total == nil || *first.Total != 5 {
+		t.Errorf("Expected a total of 5, got %v", first.Total)
+	}
+
+	// A post added meanwhile doesn't shift the following pages
+	if _, err := mockRepo.Create(context.Background(), models.Post{Title: "Post 6", Content: "Content"}); err != nil {
+		t.Fatalf("Failed to create post: %v", err)
+	}
+
+	second := page("/posts.json?after=" + first.NextCursor)
+	if got := titles(second); !reflect.DeepEqual(got, []string{"Post 3", "Post 2"}) {
+		t.Errorf("Expected the second page to continue after Post 4, got %v", got)
+	}
+
+	last := page("/posts.json?after=" + second.NextCursor)
+	if got := titles(last); !reflect.DeepEqual(got, []string{"Post 1"}) || last.NextCursor != "" {
+		t.Errorf("Expected the last page to hold Post 1 only, got %v with next %q", got, last.NextCursor)
+	}
+
+	back := page("/posts.json?before=" + last.PrevCursor)
+	if got := titles(back); !reflect.DeepEqual(got, []string{"Post 3", "Post 2"}) {
+		t.Errorf("Expected the previous page to go back to Post 3 and Post 2, got %v", got)
+	}
+
+	rr := list("/posts.json?after="+first.NextCursor, nil)
+	if link := rr.Header().Get("Link"); !strings.Contains(link, `rel="next"`) || !strings.Contains(link, `rel="prev"`) {
+		t.Errorf("Expected next and prev links, got %q", link)
+	}
+
+	rr = list("/posts?after="+first.NextCursor, map[string]string{"HX-Request": "true", "HX-Target": "load-more"})
+	if body := rr.Body.String(); body != "Items: Post 3 Post 2" {
+		t.Errorf("Expected load more to render only the next posts, got %q", body)
+	}
+
+	if rr := list("/posts?after=not-a-cursor", nil); rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an invalid cursor, got %d", http.StatusBadRequest, rr.Code)
+	}
+
+	handler.config.App.CountPosts = false
+	if response := page("/posts.json"); response.Total != nil {
+		t.Errorf("Expected no total when counting is disabled, got %d", *response.Total)
+	}
+}
+
+func TestPostHandler_IndexSortAndFilter(t *testing.T) {
+	mockRepo := NewMockPostRepository()
+	cfg, _ := config.Load()
+	cfg.App.PostsPerPage = 2
+	handler := NewPostHandler(mockRepo, createMockTemplates(), cfg)
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, err := mockRepo.CreateMany(context.Background(), []models.Post{
+		{Title: "banana bread", Content: "Baking", Author: "Ann", Tags: []string{"food"}, CreatedAt: base, UpdatedAt: base.AddDate(0, 1, 0)},
+		{Title: "Apple pie", Content: "Baking with apples", Author: "Bob", Tags: []string{"food"}, CreatedAt: base.AddDate(0, 0, 1)},
+		{Title: "Cherry season", Content: "Apple and cherry harvest", Author: "Ann", Tags: []string{"farming"}, CreatedAt: base.AddDate(0, 0, 2), Status: models.StatusDraft},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create posts: %v", err)
+	}
+
+	list := func(target string) *httptest.ResponseRecorder {
+		r := chi.NewRouter()
+		r.Use(middleware.URLFormat)
+		r.Get("/posts", handler.Index)
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, target, nil))
+		return rr
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedTitles []string
+	}{
+		{"newest by default", "", []string{"Cherry season", "Apple pie", "banana bread"}},
+		{"oldest", "sort=oldest", []string{"banana bread", "Apple pie", "Cherry season"}},
+		{"recently updated", "sort=updated", []string{"banana bread", "Cherry season", "Apple pie"}},
+		{"title ignores case", "sort=title", []string{"Apple pie", "banana bread", "Cherry season"}},
+		{"relevance ranks title matches first", "search=apple&sort=relevance", []string{"Apple pie", "Cherry season"}},
+		{"tag", "tag=food", []string{"Apple pie", "banana bread"}},
+		{"author", "author=Ann", []string{"Cherry season", "banana bread"}},
+		{"status", "status=draft", []string{"Cherry season"}},
+		{"date range", "from=2025-01-02&to=2025-01-02", []string{"Apple pie"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Walk every page to check the sort and filters survive pagination
+			var titles []string
+			target := "/posts.json?" + tt.query
+			for target != "" {
+				rr := list(target)
+				if rr.Code != http.StatusOK {
+					t.Fatalf("Expected status %d for %s, got %d: %s", http.StatusOK, target, rr.Code, rr.Body.String())
+				}
+				var response postListResponse
+				if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+					t.Fatalf("Failed to decode %s: %v", target, err)
+				}
+				for _, post := range response.Posts {
+					titles = append(titles, post.Title)
+				}
+
+				target = ""
+				if link := rr.Header().Get("Link"); strings.Contains(link, `rel="next"`) {
+					target = strings.TrimPrefix(strings.Split(link, ">")[0], "<")
+				}
+			}
+
+			if !reflect.DeepEqual(titles, tt.expectedTitles) {
+				t.Errorf("Expected %v, got %v", tt.expectedTitles, titles)
+			}
+		})
+	}
+
+	newest := list("/posts.json")
+	var first postListResponse
+	if err := json.NewDecoder(newest.Body).Decode(&first); err != nil {
+		t.Fatalf("Failed to decode the first page: %v", err)
+	}
+
+	for _, query := range []string{
+		"sort=popular",
+		"sort=relevance",
+		"status=deleted",
+		"from=yesterday",
+		"from=2025-02-01&to=2025-01-01",
+		"tag=" + strings.Repeat("x", maxListParam+1),
+		// A cursor only works with the sort it was issued for
+		"sort=oldest&after=" + first.NextCursor,
+	} {
+		if rr := list("/posts?" + query); rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %q, got %d", http.StatusBadRequest, query, rr.Code)
+		}
+	}
+}
+
+// countingStore counts the list queries that reach the repository
+type countingStore struct {
+	repository.PostStore
+	pages int
+}
+
+func (s *countingStore) FindPage(ctx context.Context, req repository.PageRequest) (repository.PostPage, error) {
+	s.pages++
+	return s.PostStore.FindPage(ctx, req)
+}
+
+func TestPostHandler_IndexCache(t *testing.T) {
+	mockRepo := NewMockPostRepository()
+	loadFixtures(t, mockRepo)
+	cfg, _ := config.Load()
+
+	store := cache.NewStore(cache.NewLRU(100, 0))
+	counting := &countingStore{PostStore: mockRepo}
+	repo := repository.NewCachedPostStore(counting, store)
+	router := conditionalRouter(NewPostHandler(repo, createMockTemplates(), cfg).WithCache(store))
+
+	first := serveConditional(router, http.MethodGet, "/posts", nil, nil)
+	second := serveConditional(router, http.MethodGet, "/posts", nil, nil)
+	if first.Code != http.StatusOK || second.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d and %d", http.StatusOK, first.Code, second.Code)
+	}
+	if second.Body.String() != first.Body.String() || second.Header().Get("ETag") != first.Header().Get("ETag") {
+		t.Errorf("Expected the cached page to match the rendered one")
+	}
+	if counting.pages != 1 {
+		t.Errorf("Expected the second request to be served from the cache, got %d queries", counting.pages)
+	}
+
+	// Each representation is cached separately
+	htmx := serveConditional(router, http.MethodGet, "/posts", nil, map[string]string{"HX-Request": "true"})
+	if htmx.Header().Get("HX-Push-Url") != "/posts" {
+		t.Errorf("Expected HX-Push-Url %q, got %q", "/posts", htmx.Header().Get("HX-Push-Url"))
+	}
+	serveConditional(router, http.MethodGet, "/posts.json", nil, nil)
+	jsonList := serveConditional(router, http.MethodGet, "/posts.json", nil, nil)
+	if !strings.HasPrefix(jsonList.Header().Get("Content-Type"), "application/json") {
+		t.Errorf("Expected the cached JSON list to keep its Content-Type, got %q", jsonList.Header().Get("Content-Type"))
+	}
+	if counting.pages != 3 {
+		t.Errorf("Expected one query per representation, got %d", counting.pages)
+	}
+
+	// Writes through the repository invalidate the pages
+	if _, err := repo.Create(context.Background(), models.Post{Title: "Breaking News", Content: "Content"}); err != nil {
+		t.Fatalf("Failed to create post: %v", err)
+	}
+	rr := serveConditional(router, http.MethodGet, "/posts", nil, map[string]string{"HX-Request": "true", "HX-Target": loadMoreTarget})
+	if !strings.Contains(rr.Body.String(), "Breaking News") {
+		t.Errorf("Expected the new post after invalidation, got %q", rr.Body.String())
+	}
+	if counting.pages != 4 {
+		t.Errorf("Expected a fresh query after the write, got %d", counting.pages)
+	}
+
+	if stats := store.Stats(); stats.Hits != 2 || stats.Invalidations != 1 {
+		t.Errorf("Expected 2 hits and 1 invalidation, got %+v", stats)
+	}
+}
+
+func TestPostHandler_ShowFixture(t *testing.T) {
+	handler, mockRepo := createTestHandler()
+	ids := loadFixtures(t, mockRepo)
+
+	req, rr := createRequestWithChiContext("GET", "/posts/"+ids["festival"], nil)
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", ids["festival"])
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	handler.Show(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), "Local Community Celebrates Annual Festival") {
+		t.Errorf("Expected fixture post title in body, got %q", rr.Body.String())
+	}
+}
+
 func TestPostHandler_Show(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -527,6 +1303,72 @@ func TestPostHandler_Show(t *testing.T) {
 	}
 }
 
+func TestPostHandler_ShowBySlug(t *testing.T) {
+	mockRepo := NewMockPostRepository()
+	ids := loadFixtures(t, mockRepo)
+	cfg, _ := config.Load()
+	handler := NewPostHandler(mockRepo, createMockTemplates(), cfg)
+
+	post, err := mockRepo.FindByID(context.Background(), ids["festival"])
+	if err != nil {
+		t.Fatalf("Failed to find fixture post: %v", err)
+	}
+	oldSlug := post.Slug
+
+	post.Title = "Festival Draws Record Crowds"
+	if err := mockRepo.Update(context.Background(), ids["festival"], post); err != nil {
+		t.Fatalf("Failed to rename fixture post: %v", err)
+	}
+
+	tests := []struct {
+		name             string
+		ref              string
+		expectedStatus   int
+		expectedLocation string
+	}{
+		{
+			name:           "current slug",
+			ref:            "festival-draws-record-crowds",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:             "post ID redirects to slug",
+			ref:              post.ID.Hex(),
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "/posts/festival-draws-record-crowds",
+		},
+		{
+			name:             "old slug redirects to current slug",
+			ref:              oldSlug,
+			expectedStatus:   http.StatusMovedPermanently,
+			expectedLocation: "/posts/festival-draws-record-crowds",
+		},
+		{
+			name:           "unknown slug",
+			ref:            "no-such-post",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, rr := createRequestWithChiContext("GET", "/posts/"+tt.ref, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.ref)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			handler.Show(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if location := rr.Header().Get("Location"); location != tt.expectedLocation {
+				t.Errorf("Expected Location %q, got %q", tt.expectedLocation, location)
+			}
+		})
+	}
+}
+
 func TestPostHandler_New(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -574,7 +1416,7 @@ func TestPostHandler_Create(t *testing.T) {
 				"title":   {""},
 				"content": {"Test Content"},
 			},
-			expectedStatus: http.StatusOK, // Form is re-rendered with errors
+			expectedStatus: http.StatusUnprocessableEntity, // Form is re-rendered with errors
 		},
 		{
 			name: "invalid form data - empty content",
@@ -582,7 +1424,7 @@ func TestPostHandler_Create(t *testing.T) {
 				"title":   {"Test Title"},
 				"content": {""},
 			},
-			expectedStatus: http.StatusOK, // Form is re-rendered with errors
+			expectedStatus: http.StatusUnprocessableEntity, // Form is re-rendered with errors
 		},
 		{
 			name: "repository error",
@@ -691,7 +1533,7 @@ func TestPostHandler_Update(t *testing.T) {
 				"content": {"Updated Content"},
 			},
 			mockPosts:      map[string]models.Post{"1": createMockPost("1", "Test Post", "Test Content")},
-			expectedStatus: http.StatusOK, // Form is re-rendered with errors
+			expectedStatus: http.StatusUnprocessableEntity, // Form is re-rendered with errors
 		},
 		{
 			name:   "post not found",
@@ -740,6 +1582,82 @@ func TestPostHandler_Update(t *testing.T) {
 	}
 }
 
+func TestPostHandler_UpdateKeepsStatus(t *testing.T) {
+	mockRepo := NewMockPostRepository()
+	cfg, _ := config.Load()
+	handler := NewPostHandler(mockRepo, createMockTemplates(), cfg)
+	draft := createMockPost("1", "Test Post", "Test Content")
+	draft.Status = models.StatusDraft
+	mockRepo.posts["1"] = draft
+
+	formData := url.Values{"title": {"Updated Title"}, "content": {"Updated Content"}}
+	req, rr := createRequestWithChiContext("PUT", "/posts/1", bytes.NewBufferString(formData.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", "1")
+	req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+	handler.Update(rr, req)
+
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("Expected status %d, got %d", http.StatusSeeOther, rr.Code)
+	}
+	if got := mockRepo.posts["1"]; got.Status != models.StatusDraft || got.Title != "Updated Title" {
+		t.Errorf("Expected an updated draft, got status %q and title %q", got.Status, got.Title)
+	}
+}
+
+func TestPostHandler_UpdateConflict(t *testing.T) {
+	mockRepo := NewMockPostRepository()
+	ids := loadFixtures(t, mockRepo)
+	cfg, _ := config.Load()
+	handler := NewPostHandler(mockRepo, createMockTemplates(), cfg)
+	id := ids["festival"]
+
+	update := func(form url.Values) *httptest.ResponseRecorder {
+		body := bytes.NewBufferString(form.Encode())
+		req, rr := createRequestWithChiContext("PUT", "/posts/"+id, body)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", id)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		handler.Update(rr, req)
+		return rr
+	}
+
+	// Both editors loaded version 1; the first save wins
+	rr := update(url.Values{"title": {"First Editor"}, "content": {"First content"}, "version": {"1"}})
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("Expected status %d for the first save, got %d", http.StatusSeeOther, rr.Code)
+	}
+
+	rr = update(url.Values{"title": {"Second Editor"}, "content": {"Second content"}, "version": {"1"}})
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("Expected status %d for the stale save, got %d", http.StatusConflict, rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), "Conflict: First Editor") {
+		t.Errorf("Expected the conflict screen to show the saved version, got %q", rr.Body.String())
+	}
+	if post := mockRepo.posts[id]; post.Title != "First Editor" || post.Version != 2 {
+		t.Errorf("Expected the stale save to be rejected, got %q at version %d", post.Title, post.Version)
+	}
+
+	// Saving again from the conflict screen carries the new version
+	rr = update(url.Values{"title": {"Merged"}, "content": {"Merged content"}, "version": {"2"}})
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("Expected status %d for the merged save, got %d", http.StatusSeeOther, rr.Code)
+	}
+	if post := mockRepo.posts[id]; post.Title != "Merged" || post.Version != 3 {
+		t.Errorf("Expected the merged save to be stored, got %q at version %d", post.Title, post.Version)
+	}
+
+	rr = update(url.Values{"title": {"Merged"}, "content": {"Merged content"}, "version": {"two"}})
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an invalid version, got %d", http.StatusBadRequest, rr.Code)
+	}
+}
+
 func TestPostHandler_Delete(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -824,6 +1742,107 @@ func TestPostHandler_Seed(t *testing.T) {
 	}
 }
 
+func TestPostHandler_SeedModes(t *testing.T) {
+	tests := []struct {
+		name           string
+		formData       url.Values
+		existingPosts  int
+		expectedStatus int
+		expectedPosts  int
+	}{
+		{
+			name:           "default mode replaces existing posts",
+			formData:       url.Values{},
+			existingPosts:  3,
+			expectedStatus: http.StatusSeeOther,
+			expectedPosts:  10,
+		},
+		{
+			name:           "append keeps existing posts",
+			formData:       url.Values{"mode": {"append"}, "count": {"5"}},
+			existingPosts:  3,
+			expectedStatus: http.StatusSeeOther,
+			expectedPosts:  8,
+		},
+		{
+			name:           "replace with count and seed",
+			formData:       url.Values{"mode": {"replace"}, "count": {"4"}, "seed": {"42"}},
+			existingPosts:  3,
+			expectedStatus: http.StatusSeeOther,
+			expectedPosts:  4,
+		},
+		{
+			name:           "reset loads fixtures",
+			formData:       url.Values{"mode": {"reset"}},
+			existingPosts:  3,
+			expectedStatus: http.StatusSeeOther,
+			expectedPosts:  10,
+		},
+		{
+			name:           "unknown mode",
+			formData:       url.Values{"mode": {"drop"}},
+			existingPosts:  3,
+			expectedStatus: http.StatusBadRequest,
+			expectedPosts:  3,
+		},
+		{
+			name:           "count out of range",
+			formData:       url.Values{"count": {"100000"}},
+			existingPosts:  3,
+			expectedStatus: http.StatusBadRequest,
+			expectedPosts:  3,
+		},
+		{
+			name:           "sample generator",
+			formData:       url.Values{"generator": {"sample"}, "count": {"2"}},
+			existingPosts:  3,
+			expectedStatus: http.StatusSeeOther,
+			expectedPosts:  2,
+		},
+		{
+			name:           "unknown generator",
+			formData:       url.Values{"generator": {"lorem"}},
+			existingPosts:  3,
+			expectedStatus: http.StatusBadRequest,
+			expectedPosts:  3,
+		},
+		{
+			name:           "invalid seed",
+			formData:       url.Values{"seed": {"abc"}},
+			existingPosts:  3,
+			expectedStatus: http.StatusBadRequest,
+			expectedPosts:  3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := NewMockPostRepository()
+			for i := 1; i <= tt.existingPosts; i++ {
+				id := strconv.Itoa(i)
+				mockRepo.posts[id] = createMockPost(id, "Existing Post", "Existing Content")
+			}
+			mockRepo.nextID = tt.existingPosts + 1
+
+			cfg, _ := config.Load()
+			handler := NewPostHandler(mockRepo, createMockTemplates(), cfg)
+
+			body := bytes.NewBufferString(tt.formData.Encode())
+			req, rr := createRequestWithChiContext("POST", "/posts/seed", body)
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			handler.Seed(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, rr.Code)
+			}
+			if len(mockRepo.posts) != tt.expectedPosts {
+				t.Errorf("Expected %d posts, got %d", tt.expectedPosts, len(mockRepo.posts))
+			}
+		})
+	}
+}
+
 func TestPostHandler_HTMXRequests(t *testing.T) {
 	tests := []struct {
 		name     string
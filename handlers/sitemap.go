@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gekich/news-app/models"
+	"github.com/gekich/news-app/repository"
+	"github.com/gekich/news-app/sitemap"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// sitemapMaxURLs is the number of entries per sitemap before /sitemap.xml
+// becomes a sitemap index
+var sitemapMaxURLs int64 = sitemap.MaxURLs
+
+// hasURLFormat checks the extension stripped from the path by the URLFormat middleware
+func hasURLFormat(r *http.Request, format string) bool {
+	urlFormat, _ := r.Context().Value(middleware.URLFormatCtxKey).(string)
+	return urlFormat == format
+}
+
+// baseURL returns the public URL of the site without a trailing slash
+func (h *PostHandler) baseURL() string {
+	return strings.TrimRight(h.config.App.BaseURL, "/")
+}
+
+// Sitemap serves /sitemap.xml. It lists the post index and every published
+// post, or refers to /sitemap-{n}.xml pages once there are too many posts
+// for a single sitemap.
+func (h *PostHandler) Sitemap(w http.ResponseWriter, r *http.Request) {
+	if !hasURLFormat(r, "xml") {
+		h.NotFound(w, r)
+		return
+	}
+
+	filter := repository.PostFilter{Status: models.StatusPublished}
+	count, err := h.repo.Count(r.Context(), filter)
+	if err != nil {
+		h.handleError(w, r, err, "Failed to build sitemap")
+		return
+	}
+
+	// The post index takes up one entry
+	pages := sitemap.Pages(count+1, sitemapMaxURLs)
+	if pages <= 1 {
+		h.writeSitemap(w, r, 1)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	if err := sitemap.WriteIndex(w, h.baseURL(), pages); err != nil {
+		log.Printf("Sitemap failed: %v", err)
+	}
+}
+
+// SitemapPage serves /sitemap-{page}.xml, one part of the sitemap index
+func (h *PostHandler) SitemapPage(w http.ResponseWriter, r *http.Request) {
+	page, err := strconv.ParseInt(chi.URLParam(r, "page"), 10, 64)
+	if err != nil || page < 1 || !hasURLFormat(r, "xml") {
+		h.NotFound(w, r)
+		return
+	}
+
+	count, err := h.repo.Count(r.Context(), repository.PostFilter{Status: models.StatusPublished})
+	if err != nil {
+		h.handleError(w, r, err, "Failed to build sitemap")
+		return
+	}
+	if page > sitemap.Pages(count+1, sitemapMaxURLs) {
+		h.NotFound(w, r)
+		return
+	}
+
+	h.writeSitemap(w, r, page)
+}
+
+// writeSitemap streams one page of the sitemap straight from the database.
+// The first page starts with the post index.
+func (h *PostHandler) writeSitemap(w http.ResponseWriter, r *http.Request, page int64) {
+	filter := repository.PostFilter{Status: models.StatusPublished, Limit: sitemapMaxURLs}
+	if page == 1 {
+		filter.Limit--
+	} else {
+		filter.Skip = (page-1)*sitemapMaxURLs - 1
+	}
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	urls := sitemap.NewWriter(w)
+
+	if page == 1 {
+		if err := urls.Add(sitemap.URL{Loc: h.baseURL() + "/posts"}); err != nil {
+			log.Printf("Sitemap failed: %v", err)
+			return
+		}
+	}
+
+	// The response is already streaming, so a failure part way through can only be logged
+	err := h.repo.Stream(r.Context(), filter, func(post models.Post) error {
+		return urls.Add(sitemap.URL{
+			Loc:     h.baseURL() + post.Path(),
+			LastMod: post.UpdatedAt,
+		})
+	})
+	if err == nil {
+		err = urls.Close()
+	}
+	if err != nil {
+		log.Printf("Sitemap failed: %v", err)
+	}
+}
+
+// Robots serves /robots.txt, either from the configured file or built from
+// the disallowed paths, and points crawlers at the sitemap
+func (h *PostHandler) Robots(w http.ResponseWriter, r *http.Request) {
+	if !hasURLFormat(r, "txt") {
+		h.NotFound(w, r)
+		return
+	}
+
+	var body strings.Builder
+	if h.config.App.RobotsFile != "" {
+		content, err := os.ReadFile(h.config.App.RobotsFile)
+		if err != nil {
+			h.handleError(w, r, err, "Failed to read robots.txt")
+			return
+		}
+		body.Write(content)
+		if len(content) > 0 && !strings.HasSuffix(body.String(), "\n") {
+			body.WriteString("\n")
+		}
+	} else {
+		body.WriteString("User-agent: *\n")
+		if len(h.config.App.RobotsDisallow) == 0 {
+			body.WriteString("Disallow:\n")
+		}
+		for _, path := range h.config.App.RobotsDisallow {
+			body.WriteString("Disallow: " + path + "\n")
+		}
+	}
+
+	if !strings.Contains(strings.ToLower(body.String()), "sitemap:") {
+		body.WriteString("\nSitemap: " + h.baseURL() + "/sitemap.xml\n")
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(body.String()))
+}
@@ -0,0 +1,139 @@
+//go:build unit
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gekich/news-app/config"
+	"github.com/gekich/news-app/models"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// serveWithURLFormat routes a request the way the application router does,
+// with the extension stripped by the URLFormat middleware
+func serveWithURLFormat(pattern string, handler http.HandlerFunc, target string) *httptest.ResponseRecorder {
+	r := chi.NewRouter()
+	r.Use(middleware.URLFormat)
+	r.Get(pattern, handler)
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, target, nil))
+	return rr
+}
+
+func TestPostHandler_Sitemap(t *testing.T) {
+	mockRepo := NewMockPostRepository()
+	ids := loadFixtures(t, mockRepo)
+	cfg, _ := config.Load()
+	cfg.App.BaseURL = "https://news.example.com/"
+	handler := NewPostHandler(mockRepo, createMockTemplates(), cfg)
+
+	rr := serveWithURLFormat("/sitemap", handler.Sitemap, "/sitemap.xml")
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+	}
+	if contentType := rr.Header().Get("Content-Type"); contentType != "application/xml; charset=utf-8" {
+		t.Errorf("Expected XML content type, got %q", contentType)
+	}
+
+	body := rr.Body.String()
+	post := mockRepo.posts[ids["quantum"]]
+	for _, expected := range []string{
+		"<urlset",
+		"<loc>https://news.example.com/posts</loc>",
+		"<loc>https://news.example.com/posts/quantum-computing-breakthrough-announced</loc>",
+		"<lastmod>" + post.UpdatedAt.UTC().Format(time.RFC3339) + "</lastmod>",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected sitemap to contain %q, got %q", expected, body)
+		}
+	}
+
+	for _, post := range mockRepo.posts {
+		if post.Status == models.StatusDraft && strings.Contains(body, post.Path()+"<") {
+			t.Errorf("Expected draft %q to be left out of the sitemap", post.Title)
+		}
+	}
+
+	if rr := serveWithURLFormat("/sitemap", handler.Sitemap, "/sitemap.json"); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for the wrong extension, got %d", http.StatusNotFound, rr.Code)
+	}
+}
+
+func TestPostHandler_SitemapIndex(t *testing.T) {
+	defer func(max int64) { sitemapMaxURLs = max }(sitemapMaxURLs)
+	sitemapMaxURLs = 2
+
+	mockRepo := NewMockPostRepository()
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var posts []models.Post
+	for i := 0; i < 4; i++ {
+		posts = append(posts, models.Post{
+			Title:     fmt.Sprintf("Post %d", i),
+			Content:   "Sitemap content",
+			Status:    models.StatusPublished,
+			CreatedAt: created.Add(time.Duration(i) * time.Hour),
+		})
+	}
+	mockRepo.CreateMany(context.Background(), posts)
+
+	cfg, _ := config.Load()
+	handler := NewPostHandler(mockRepo, createMockTemplates(), cfg)
+
+	// The post index and four posts need three sitemaps of two entries
+	rr := serveWithURLFormat("/sitemap", handler.Sitemap, "/sitemap.xml")
+	body := rr.Body.String()
+	if !strings.Contains(body, "<sitemapindex") || !strings.Contains(body, "/sitemap-3.xml</loc>") || strings.Contains(body, "/sitemap-4.xml") {
+		t.Fatalf("Expected a sitemap index of three pages, got %q", body)
+	}
+
+	seen := 0
+	for page := 1; page <= 3; page++ {
+		rr := serveWithURLFormat("/sitemap-{page}", handler.SitemapPage, fmt.Sprintf("/sitemap-%d.xml", page))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d for page %d, got %d", http.StatusOK, page, rr.Code)
+		}
+		seen += strings.Count(rr.Body.String(), "<url>")
+	}
+	if seen != 5 {
+		t.Errorf("Expected 5 entries across all pages, got %d", seen)
+	}
+
+	if rr := serveWithURLFormat("/sitemap-{page}", handler.SitemapPage, "/sitemap-4.xml"); rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d past the last page, got %d", http.StatusNotFound, rr.Code)
+	}
+}
+
+func TestPostHandler_Robots(t *testing.T) {
+	cfg, _ := config.Load()
+	cfg.App.BaseURL = "https://news.example.com"
+	cfg.App.RobotsDisallow = []string{"/admin/", "/posts/new"}
+	handler := NewPostHandler(NewMockPostRepository(), createMockTemplates(), cfg)
+
+	rr := serveWithURLFormat("/robots", handler.Robots, "/robots.txt")
+	expected := "User-agent: *\nDisallow: /admin/\nDisallow: /posts/new\n\nSitemap: https://news.example.com/sitemap.xml\n"
+	if rr.Body.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, rr.Body.String())
+	}
+
+	file := filepath.Join(t.TempDir(), "robots.txt")
+	os.WriteFile(file, []byte("User-agent: *\nDisallow: /"), 0644)
+	handler.config.App.RobotsFile = file
+
+	rr = serveWithURLFormat("/robots", handler.Robots, "/robots.txt")
+	expected = "User-agent: *\nDisallow: /\n\nSitemap: https://news.example.com/sitemap.xml\n"
+	if rr.Body.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, rr.Body.String())
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+
+	"github.com/gekich/news-app/apperr"
+)
+
+// templateError is a template that failed to parse or render
+type templateError struct {
+	err error
+}
+
+func (e *templateError) Error() string { return "failed to render template: " + e.err.Error() }
+
+func (e *templateError) Unwrap() error { return e.err }
+
+// devErrorPage shows a template error in the browser in development mode
+var devErrorPage = template.Must(template.New("dev_error").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <title>Template error</title>
+</head>
+<body style="margin: 0; font-family: sans-serif; background: #fef2f2;">
+    <div style="max-width: 60rem; margin: 2rem auto; padding: 1.5rem; background: #fff; border: 1px solid #fca5a5; border-radius: 0.5rem;">
+        <h1 style="margin-top: 0; color: #b91c1c;">Template error</h1>
+        <pre style="white-space: pre-wrap; padding: 1rem; background: #1f2937; color: #f9fafb; border-radius: 0.25rem;">{{.}}</pre>
+        <p style="color: #4b5563;">Fix the template and reload the page. This page is only shown in development mode.</p>
+    </div>
+</body>
+</html>
+`))
+
+// renderTemplateError answers a request whose template failed to parse or
+// render. In development mode the error replaces the page, including for
+// HTMX requests; otherwise the error page is shown, and only HTMX requests
+// see the cause.
+func (h *PostHandler) renderTemplateError(w http.ResponseWriter, r *http.Request, err error) {
+	if !h.config.App.Dev {
+		message := "Failed to render template"
+		if isHTMXRequest(r) {
+			message = fmt.Sprintf("Failed to render template: %v", errorCause(err))
+		}
+		h.renderError(w, r, apperr.Internal(message, errorCause(err)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	// The layout swaps error responses carrying this header into the page
+	w.Header().Set("X-Template-Error", "true")
+	w.Header().Set("HX-Retarget", "body")
+	w.Header().Set("HX-Reswap", "innerHTML")
+	w.WriteHeader(http.StatusInternalServerError)
+	devErrorPage.Execute(w, errorCause(err).Error())
+}
+
+// errorCause unwraps a *templateError
+func errorCause(err error) error {
+	if templateErr, ok := err.(*templateError); ok {
+		return templateErr.err
+	}
+	return err
+}
@@ -0,0 +1,72 @@
+//go:build unit
+
+package handlers
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gekich/news-app/config"
+	"github.com/gekich/news-app/templates"
+)
+
+// failingTemplates is a TemplateSource whose templates don't parse
+type failingTemplates struct{}
+
+func (failingTemplates) Templates() (*templates.Registry, error) {
+	return nil, errors.New(`template: show.html:3: unexpected "}" in operand`)
+}
+
+func TestPostHandler_TemplateErrors(t *testing.T) {
+	mockRepo := NewMockPostRepository()
+	loadFixtures(t, mockRepo)
+
+	// A template that fails while rendering, after some output
+	broken := templates.NewRegistryFrom(map[templates.Page]*template.Template{
+		templates.PostList: template.Must(template.New("post_list").Parse(`partial {{index .Posts 99}}`)),
+	})
+
+	tests := []struct {
+		name        string
+		dev         bool
+		source      TemplateSource
+		headers     map[string]string
+		wantBody    string
+		wantMissing string
+		wantHeader  string
+	}{
+		{name: "production page", wantBody: "Failed to render template", wantMissing: "partial"},
+		{name: "production HTMX", headers: map[string]string{"HX-Request": "true"}, wantBody: "Failed to render template: ", wantMissing: "<pre"},
+		{name: "development page", dev: true, wantBody: "<pre", wantMissing: "partial", wantHeader: "true"},
+		{name: "development HTMX", dev: true, headers: map[string]string{"HX-Request": "true"}, wantBody: "Template error", wantHeader: "true"},
+		{name: "development parse error", dev: true, source: failingTemplates{}, wantBody: "show.html:3: unexpected &#34;}&#34; in operand", wantHeader: "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, _ := config.Load()
+			cfg.App.Dev = tt.dev
+			handler := NewPostHandler(mockRepo, broken, cfg)
+			if tt.source != nil {
+				handler.WithTemplateSource(tt.source)
+			}
+
+			rr := serveConditional(conditionalRouter(handler), http.MethodGet, "/posts", nil, tt.headers)
+			if rr.Code != http.StatusInternalServerError {
+				t.Fatalf("Expected status %d, got %d", http.StatusInternalServerError, rr.Code)
+			}
+			if !strings.Contains(rr.Body.String(), tt.wantBody) {
+				t.Errorf("Expected the body to contain %q, got %q", tt.wantBody, rr.Body.String())
+			}
+			if tt.wantMissing != "" && strings.Contains(rr.Body.String(), tt.wantMissing) {
+				t.Errorf("Expected the body not to contain %q, got %q", tt.wantMissing, rr.Body.String())
+			}
+			if got := rr.Header().Get("X-Template-Error"); got != tt.wantHeader {
+				t.Errorf("Expected X-Template-Error %q, got %q", tt.wantHeader, got)
+			}
+		})
+	}
+}
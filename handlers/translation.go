@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"github.com/gekich/news-app/apperr"
+	"github.com/gekich/news-app/i18n"
+	"github.com/gekich/news-app/models"
+	"github.com/gekich/news-app/templates"
+	"github.com/gekich/news-app/views"
+	"github.com/go-chi/chi/v5"
+)
+
+// fallbackLanguage is the language of posts without one, which lists fall
+// back to for stories that aren't translated into their language
+func (h *PostHandler) fallbackLanguage() string {
+	switch {
+	case h.config.App.FallbackLanguage != "":
+		return h.config.App.FallbackLanguage
+	case h.config.App.DefaultLocale != "":
+		return h.config.App.DefaultLocale
+	default:
+		return i18n.DefaultLocale
+	}
+}
+
+// postLanguage returns the language of a post, the fallback language for
+// posts without one
+func (h *PostHandler) postLanguage(post models.Post) string {
+	if post.Language != "" {
+		return post.Language
+	}
+	return h.fallbackLanguage()
+}
+
+// translations returns the posts of the post's translation group with their
+// languages filled in, or none for a post that hasn't been translated
+func (h *PostHandler) translations(ctx context.Context, post models.Post) ([]models.Post, error) {
+	if post.TranslationGroup == "" {
+		return nil, nil
+	}
+
+	posts, err := h.repo.FindTranslations(ctx, post.TranslationGroup)
+	if err != nil {
+		return nil, err
+	}
+	for i := range posts {
+		posts[i].Language = h.postLanguage(posts[i])
+	}
+	return posts, nil
+}
+
+// postPage is the view model of a post's page: the post, links to its
+// translations and the languages it can still be translated into
+func (h *PostHandler) postPage(r *http.Request, post models.Post, translations []models.Post) *views.PostPage {
+	post.Language = h.postLanguage(post)
+	data := &views.PostPage{Post: post, Translations: translations}
+
+	translated := map[string]bool{post.Language: true}
+	for _, translation := range translations {
+		translated[translation.Language] = true
+		// Search engines only get to see published posts
+		if len(translations) < 2 || translation.Status == models.StatusDraft {
+			continue
+		}
+		link := h.baseURL() + translation.Path()
+		data.Alternates = append(data.Alternates, views.Alternate{Language: translation.Language, URL: link})
+		if translation.Language == h.fallbackLanguage() {
+			data.Alternates = append(data.Alternates, views.Alternate{Language: "x-default", URL: link})
+		}
+	}
+
+	for _, locale := range translator(r).Locales() {
+		if !translated[locale] {
+			data.Untranslated = append(data.Untranslated, locale)
+		}
+	}
+	return data
+}
+
+// translationError checks the language of a post joining a translation
+// group. It returns the validation message for a language another post of
+// the group already has, and an error for a group that doesn't exist.
+func (h *PostHandler) translationError(r *http.Request, post models.Post) (string, error) {
+	if post.TranslationGroup == "" {
+		return "", nil
+	}
+
+	translations, err := h.translations(r.Context(), post)
+	if err != nil {
+		return "", err
+	}
+	if len(translations) == 0 {
+		return "", apperr.BadRequest(translator(r).T("error.unknown_translation_group"), nil)
+	}
+
+	language := h.postLanguage(post)
+	for _, translation := range translations {
+		if translation.ID != post.ID && translation.Language == language {
+			tr := translator(r)
+			return tr.T("validation.translated", tr.T("language."+language)), nil
+		}
+	}
+	return "", nil
+}
+
+// Translate shows the form adding a translation of a post, into the
+// language given in the query or else the first one the post isn't
+// translated into. The form starts out as a draft copy of the post.
+func (h *PostHandler) Translate(w http.ResponseWriter, r *http.Request) {
+	source, err := h.findPost(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		h.handleError(w, r, err, "Failed to fetch post")
+		return
+	}
+	translations, err := h.translations(r.Context(), source)
+	if err != nil {
+		h.handleError(w, r, err, "Failed to fetch translations")
+		return
+	}
+
+	untranslated := h.postPage(r, source, translations).Untranslated
+	language := r.URL.Query().Get("language")
+	if language == "" && len(untranslated) > 0 {
+		language = untranslated[0]
+	}
+	if !slices.Contains(untranslated, language) {
+		message := fmt.Sprintf("Post can't be translated into %q", language)
+		h.renderError(w, r, apperr.BadRequest(message, nil))
+		return
+	}
+
+	tr := translator(r)
+	data := &views.FormPage{
+		Title: tr.T("form.translate_title", tr.T("language."+language)),
+		Post: models.Post{
+			Title:            source.Title,
+			Content:          source.Content,
+			Status:           models.StatusDraft,
+			Language:         language,
+			TranslationGroup: source.Group(),
+		},
+		Action:    "/posts",
+		Method:    "post",
+		Languages: untranslated,
+		Upload:    h.uploadLimits(),
+	}
+
+	pushURL := source.Path() + "/translate?" + url.Values{"language": {language}}.Encode()
+	h.renderTemplate(w, r, templates.PostForm, data, pushURL)
+}
@@ -0,0 +1,198 @@
+//go:build unit
+
+package handlers
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gekich/news-app/config"
+	"github.com/gekich/news-app/i18n"
+	custom "github.com/gekich/news-app/middleware"
+	"github.com/gekich/news-app/templates"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// translationRouter routes the post handlers with templates showing the
+// languages of posts and their translations
+func translationRouter(t *testing.T) (http.Handler, *MockPostRepository) {
+	t.Helper()
+
+	pages := mockTemplatePages()
+	pages[templates.PostList] = template.Must(template.New("post_list").Parse(
+		`{{define "content"}}Posts:{{range .Posts}} {{.Title}};{{end}}{{end}}{{template "content" .}}`,
+	))
+	pages[templates.PostShow] = template.Must(template.New("show").Parse(
+		`{{define "content"}}Post: {{.Post.Title}} [{{.Post.Language}}]` +
+			`{{range .Translations}} {{.Language}}={{.Path}}{{end}}` +
+			`{{range .Alternates}} alternate {{.Language}} {{.URL}}{{end}}` +
+			`{{range .Untranslated}} translate {{.}}{{end}}{{end}}{{template "content" .}}`,
+	))
+	pages[templates.PostForm] = template.Must(template.New("form").Parse(
+		`{{define "content"}}Form: {{.Title}} [{{.Post.Language}}] group={{.Post.TranslationGroup}} ` +
+			`{{.Post.Title}} {{.Post.Status}} {{.Languages}} {{.Errors.Language}}{{end}}{{template "content" .}}`,
+	))
+
+	mockRepo := NewMockPostRepository()
+	cfg, _ := config.Load()
+	handler := NewPostHandler(mockRepo, templates.NewRegistryFrom(pages), cfg)
+
+	r := chi.NewRouter()
+	r.Use(middleware.URLFormat)
+	r.Use(custom.Locale(i18n.Default()))
+	r.Get("/posts", handler.Index)
+	r.Post("/posts", handler.Create)
+	r.Get("/posts/{id}", handler.Show)
+	r.Get("/posts/{id}/translate", handler.Translate)
+	return r, mockRepo
+}
+
+func TestPostHandler_Translate(t *testing.T) {
+	router, mockRepo := translationRouter(t)
+	source := mockRepo.posts[loadFixtures(t, mockRepo)["festival"]]
+	target := "/posts/" + source.ID.Hex() + "/translate"
+
+	t.Run("form", func(t *testing.T) {
+		rr := serveConditional(router, http.MethodGet, target+"?language=uk", nil, nil)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, rr.Code)
+		}
+		want := "Form: New Translation: Ukrainian [uk] group=" + source.ID.Hex() + " " + source.Title + " draft [uk]"
+		if !strings.Contains(rr.Body.String(), want) {
+			t.Errorf("Expected %q, got %q", want, rr.Body.String())
+		}
+	})
+
+	t.Run("first untranslated language", func(t *testing.T) {
+		rr := serveConditional(router, http.MethodGet, target, nil, nil)
+		if !strings.Contains(rr.Body.String(), "[uk]") {
+			t.Errorf("Expected a form for the Ukrainian translation, got %q", rr.Body.String())
+		}
+	})
+
+	for _, language := range []string{"en", "fr"} {
+		t.Run("into "+language, func(t *testing.T) {
+			rr := serveConditional(router, http.MethodGet, target+"?language="+language, nil, nil)
+			if rr.Code != http.StatusBadRequest {
+				t.Errorf("Expected status %d, got %d", http.StatusBadRequest, rr.Code)
+			}
+		})
+	}
+}
+
+func TestPostHandler_Translations(t *testing.T) {
+	router, mockRepo := translationRouter(t)
+	ids := loadFixtures(t, mockRepo)
+	source := mockRepo.posts[ids["festival"]]
+	ukrainian := map[string]string{"Accept-Language": "uk"}
+
+	before := serveConditional(router, http.MethodGet, source.Path(), nil, nil)
+	if !strings.Contains(before.Body.String(), "translate uk") {
+		t.Errorf("Expected an untranslated post to offer a translation, got %q", before.Body.String())
+	}
+
+	translation := url.Values{
+		"title":             {"Громада святкує щорічний фестиваль"},
+		"content":           {"Мешканці зібралися на святкування."},
+		"language":          {"uk"},
+		"translation_group": {source.ID.Hex()},
+	}
+	rr := serveConditional(router, http.MethodPost, "/posts", translation, nil)
+	if rr.Code != http.StatusSeeOther {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusSeeOther, rr.Code, rr.Body.String())
+	}
+
+	var translated string
+	for _, post := range mockRepo.posts {
+		if post.Language == "uk" {
+			translated = post.Path()
+		}
+	}
+
+	t.Run("source joins the group", func(t *testing.T) {
+		if group := mockRepo.posts[ids["festival"]].TranslationGroup; group != source.ID.Hex() {
+			t.Errorf("Expected the source post in group %s, got %q", source.ID.Hex(), group)
+		}
+	})
+
+	t.Run("language switcher", func(t *testing.T) {
+		rr := serveConditional(router, http.MethodGet, source.Path(), nil, nil)
+		body := rr.Body.String()
+
+		for _, want := range []string{
+			"[en] en=" + source.Path() + " uk=" + translated,
+			"alternate en http://localhost:8080" + source.Path(),
+			"alternate x-default http://localhost:8080" + source.Path(),
+			"alternate uk http://localhost:8080" + translated,
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("Expected %q, got %q", want, body)
+			}
+		}
+		if strings.Contains(body, "translate uk") {
+			t.Errorf("Expected no link adding another Ukrainian translation, got %q", body)
+		}
+		if rr.Header().Get("ETag") == before.Header().Get("ETag") {
+			t.Error("Expected the ETag to change with the translations")
+		}
+	})
+
+	t.Run("duplicate language", func(t *testing.T) {
+		rr := serveConditional(router, http.MethodPost, "/posts", translation, nil)
+		if rr.Code != http.StatusUnprocessableEntity {
+			t.Errorf("Expected status %d, got %d", http.StatusUnprocessableEntity, rr.Code)
+		}
+		if !strings.Contains(rr.Body.String(), "The story already has a translation into Ukrainian") {
+			t.Errorf("Expected the duplicate language to be rejected, got %q", rr.Body.String())
+		}
+	})
+
+	t.Run("unknown group", func(t *testing.T) {
+		form := url.Values{
+			"title":             {"Orphan translation"},
+			"content":           {"Translated from nothing at all."},
+			"language":          {"uk"},
+			"translation_group": {"000000000000000000000099"},
+		}
+		rr := serveConditional(router, http.MethodPost, "/posts", form, nil)
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, rr.Code)
+		}
+	})
+
+	t.Run("lists", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			target  string
+			headers map[string]string
+			present string
+			absent  string
+		}{
+			{"English", "/posts", nil, source.Title, translation.Get("title")},
+			{"Ukrainian with fallback", "/posts", ukrainian, translation.Get("title"), source.Title},
+			{"language in the URL", "/posts?language=uk", nil, translation.Get("title"), source.Title},
+			{"JSON has every language", "/posts.json", ukrainian, source.Title, ""},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				body := serveConditional(router, http.MethodGet, tt.target, nil, tt.headers).Body.String()
+				if !strings.Contains(body, tt.present) {
+					t.Errorf("Expected %q in the list, got %q", tt.present, body)
+				}
+				if tt.absent != "" && strings.Contains(body, tt.absent) {
+					t.Errorf("Expected %q not to be in the list, got %q", tt.absent, body)
+				}
+				// Untranslated stories are listed in the fallback language
+				if !strings.Contains(body, "Quantum Computing Breakthrough Announced") {
+					t.Errorf("Expected the untranslated posts in the list, got %q", body)
+				}
+			})
+		}
+	})
+}
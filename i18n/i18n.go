@@ -0,0 +1,269 @@
+// Package i18n translates the user interface. Each locale has a catalog of
+// messages in locales/LOCALE.json, keyed by message key. A message is either
+// a string or an object of plural forms, and is formatted with fmt verbs.
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// embedded holds the catalogs shipped with the binary
+//
+//go:embed locales/*.json
+var embedded embed.FS
+
+// DefaultLocale is the locale of the embedded catalogs every other catalog
+// falls back to
+const DefaultLocale = "en"
+
+// Message is a message in its plural forms, keyed by plural category: one,
+// few, many and other. A message without plural forms has only other.
+type Message map[string]string
+
+// UnmarshalJSON reads a message given as a string or as an object of forms
+func (m *Message) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*m = Message{pluralOther: text}
+		return nil
+	}
+	var forms map[string]string
+	if err := json.Unmarshal(data, &forms); err != nil {
+		return err
+	}
+	if _, ok := forms[pluralOther]; !ok {
+		return fmt.Errorf("plural message without the %q form", pluralOther)
+	}
+	*m = forms
+	return nil
+}
+
+// Catalog holds the messages of a locale by key
+type Catalog map[string]Message
+
+// Bundle holds the catalogs of every supported locale
+type Bundle struct {
+	defaultLocale string
+	catalogs      map[string]Catalog
+	translators   map[string]*Translator
+}
+
+// NewBundle reads the catalogs in files, one LOCALE.json per locale under
+// locales/. Requests no locale matches get defaultLocale, which must be one
+// of them.
+func NewBundle(files fs.FS, defaultLocale string) (*Bundle, error) {
+	names, err := fs.Glob(files, "locales/*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Bundle{
+		defaultLocale: defaultLocale,
+		catalogs:      make(map[string]Catalog, len(names)),
+		translators:   make(map[string]*Translator, len(names)),
+	}
+	for _, name := range names {
+		data, err := fs.ReadFile(files, name)
+		if err != nil {
+			return nil, err
+		}
+		var catalog Catalog
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			return nil, fmt.Errorf("catalog %s: %w", name, err)
+		}
+		b.catalogs[strings.TrimSuffix(path.Base(name), ".json")] = catalog
+	}
+
+	fallback, ok := b.catalogs[DefaultLocale]
+	if !ok {
+		return nil, fmt.Errorf("no catalog for %s", DefaultLocale)
+	}
+	if _, ok := b.catalogs[defaultLocale]; !ok {
+		return nil, fmt.Errorf("no catalog for the default locale %q", defaultLocale)
+	}
+	for locale, catalog := range b.catalogs {
+		b.translators[locale] = &Translator{
+			bundle:   b,
+			locale:   locale,
+			catalog:  catalog,
+			fallback: fallback,
+			plural:   pluralRule(locale),
+		}
+	}
+	return b, nil
+}
+
+// New returns the embedded catalogs with defaultLocale as the default
+func New(defaultLocale string) (*Bundle, error) {
+	return NewBundle(embedded, defaultLocale)
+}
+
+var defaultBundle = sync.OnceValue(func() *Bundle {
+	b, err := New(DefaultLocale)
+	if err != nil {
+		panic(err)
+	}
+	return b
+})
+
+// Default returns the embedded catalogs with DefaultLocale as the default
+func Default() *Bundle {
+	return defaultBundle()
+}
+
+// DefaultLocale returns the locale of requests no locale matches
+func (b *Bundle) DefaultLocale() string {
+	return b.defaultLocale
+}
+
+// Locales returns the supported locales in order
+func (b *Bundle) Locales() []string {
+	locales := make([]string, 0, len(b.catalogs))
+	for locale := range b.catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Supports reports whether the bundle has a catalog for locale
+func (b *Bundle) Supports(locale string) bool {
+	_, ok := b.catalogs[locale]
+	return ok
+}
+
+// Translator returns the translator of locale, or of the default locale
+// when locale isn't supported
+func (b *Bundle) Translator(locale string) *Translator {
+	if tr, ok := b.translators[locale]; ok {
+		return tr
+	}
+	return b.translators[b.defaultLocale]
+}
+
+// Translator translates messages into one locale. A nil *Translator
+// translates into the default locale of Default.
+type Translator struct {
+	bundle   *Bundle
+	locale   string
+	catalog  Catalog
+	fallback Catalog
+	plural   func(n int64) string
+}
+
+// orDefault returns tr, or the default translator when tr is nil
+func (tr *Translator) orDefault() *Translator {
+	if tr == nil {
+		return Default().Translator(DefaultLocale)
+	}
+	return tr
+}
+
+// Locale returns the locale messages are translated into
+func (tr *Translator) Locale() string {
+	return tr.orDefault().locale
+}
+
+// Locales returns the locales a user can switch to
+func (tr *Translator) Locales() []string {
+	return tr.orDefault().bundle.Locales()
+}
+
+// T translates the message key, formatting args into it. The first integer
+// argument picks the plural form. Keys missing from the catalog are taken
+// from the catalog of DefaultLocale, and keys missing from both are returned
+// as they are.
+func (tr *Translator) T(key string, args ...interface{}) string {
+	text, ok := tr.Lookup(key, args...)
+	if !ok {
+		return key
+	}
+	return text
+}
+
+// Lookup translates the message key like T, and reports whether the key
+// exists
+func (tr *Translator) Lookup(key string, args ...interface{}) (string, bool) {
+	tr = tr.orDefault()
+
+	message, ok := tr.catalog[key]
+	plural := tr.plural
+	if !ok {
+		if message, ok = tr.fallback[key]; !ok {
+			return "", false
+		}
+		plural = pluralRule(DefaultLocale)
+	}
+
+	form := message[pluralOther]
+	if n, ok := firstInt(args); ok {
+		if text, ok := message[plural(n)]; ok {
+			form = text
+		}
+	}
+	if len(args) == 0 {
+		return form, true
+	}
+	return fmt.Sprintf(form, args...), true
+}
+
+// Date formats the day of t, like "Jan 02, 2006"
+func (tr *Translator) Date(t time.Time) string {
+	return tr.T("date.short", t.Day(), tr.month(t), t.Year())
+}
+
+// DateTime formats the day and time of t, like "Jan 02, 2006 15:04"
+func (tr *Translator) DateTime(t time.Time) string {
+	return tr.T("date.long", t.Day(), tr.month(t), t.Year(), t.Format("15:04"))
+}
+
+// month returns the abbreviated name of the month of t
+func (tr *Translator) month(t time.Time) string {
+	return tr.T(fmt.Sprintf("date.month.%d", int(t.Month())))
+}
+
+// firstInt returns the first argument of an integer type
+func firstInt(args []interface{}) (int64, bool) {
+	for _, arg := range args {
+		switch n := arg.(type) {
+		case int:
+			return int64(n), true
+		case int32:
+			return int64(n), true
+		case int64:
+			return n, true
+		case uint:
+			return int64(n), true
+		case uint32:
+			return int64(n), true
+		case uint64:
+			return int64(n), true
+		}
+	}
+	return 0, false
+}
+
+type contextKey struct{}
+
+// WithTranslator returns a copy of ctx carrying tr
+func WithTranslator(ctx context.Context, tr *Translator) context.Context {
+	return context.WithValue(ctx, contextKey{}, tr)
+}
+
+// FromContext returns the translator of the request ctx belongs to, or the
+// default translator when there is none
+func FromContext(ctx context.Context) *Translator {
+	if tr, ok := ctx.Value(contextKey{}).(*Translator); ok {
+		return tr
+	}
+	return Default().Translator(DefaultLocale)
+}
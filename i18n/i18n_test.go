@@ -0,0 +1,197 @@
+//go:build unit
+
+package i18n
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestCatalogs_Complete(t *testing.T) {
+	bundle := Default()
+	reference := bundle.catalogs[DefaultLocale]
+
+	for _, locale := range bundle.Locales() {
+		catalog := bundle.catalogs[locale]
+		rule := pluralRule(locale)
+
+		for key, message := range reference {
+			translated, ok := catalog[key]
+			if !ok {
+				t.Errorf("%s: missing %q", locale, key)
+				continue
+			}
+			if len(message) == 1 {
+				continue
+			}
+			// Every count must find its form in a plural message
+			for n := int64(0); n < 200; n++ {
+				if _, ok := translated[rule(n)]; !ok {
+					t.Errorf("%s: %q has no %q form for %d", locale, key, rule(n), n)
+					break
+				}
+			}
+		}
+		for key := range catalog {
+			if _, ok := reference[key]; !ok {
+				t.Errorf("%s: %q isn't in the %s catalog", locale, key, DefaultLocale)
+			}
+		}
+	}
+}
+
+func TestTranslator_T(t *testing.T) {
+	en := Default().Translator("en")
+	uk := Default().Translator("uk")
+
+	tests := []struct {
+		name string
+		tr   *Translator
+		key  string
+		args []interface{}
+		want string
+	}{
+		{"plain", en, "posts.new", nil, "New Post"},
+		{"formatted", en, "posts.by", []interface{}{"Ada"}, "By Ada"},
+		{"plural one", en, "pagination.total", []interface{}{1}, "1 post"},
+		{"plural other", en, "pagination.total", []interface{}{int64(12)}, "12 posts"},
+		{"translated", uk, "posts.new", nil, "Новий допис"},
+		{"plural one uk", uk, "pagination.total", []interface{}{21}, "21 допис"},
+		{"plural few uk", uk, "pagination.total", []interface{}{int64(3)}, "3 дописи"},
+		{"plural many uk", uk, "pagination.total", []interface{}{11}, "11 дописів"},
+		{"count after other args", en, "import.summary", []interface{}{1, "a.csv", 1}, "Imported 1 of 1 row from a.csv"},
+		{"missing key", uk, "no.such.key", nil, "no.such.key"},
+		{"nil translator", nil, "posts.new", nil, "New Post"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.tr.T(tt.key, tt.args...); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTranslator_Fallback(t *testing.T) {
+	files := fstest.MapFS{
+		"locales/en.json": {Data: []byte(`{"greeting": "Hello", "items": {"one": "%d item", "other": "%d items"}}`)},
+		"locales/de.json": {Data: []byte(`{"items": {"one": "%d Eintrag", "other": "%d Einträge"}}`)},
+	}
+	bundle, err := NewBundle(files, "de")
+	if err != nil {
+		t.Fatalf("Failed to create bundle: %v", err)
+	}
+
+	de := bundle.Translator("de")
+	if got := de.T("greeting"); got != "Hello" {
+		t.Errorf("Expected the English message for a missing key, got %q", got)
+	}
+	if got := de.T("items", 2); got != "2 Einträge" {
+		t.Errorf("Expected %q, got %q", "2 Einträge", got)
+	}
+	if got := bundle.Translator("fr").Locale(); got != "de" {
+		t.Errorf("Expected an unsupported locale to get the default, got %q", got)
+	}
+	if _, ok := de.Lookup("farewell"); ok {
+		t.Error("Expected a missing key not to be found")
+	}
+}
+
+func TestNewBundle_Errors(t *testing.T) {
+	tests := []struct {
+		name          string
+		files         fstest.MapFS
+		defaultLocale string
+	}{
+		{"unknown default", fstest.MapFS{"locales/en.json": {Data: []byte(`{}`)}}, "fr"},
+		{"no English", fstest.MapFS{"locales/de.json": {Data: []byte(`{}`)}}, "de"},
+		{"invalid JSON", fstest.MapFS{"locales/en.json": {Data: []byte(`{`)}}, "en"},
+		{"plural without other", fstest.MapFS{"locales/en.json": {Data: []byte(`{"items": {"one": "%d item"}}`)}}, "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewBundle(tt.files, tt.defaultLocale); err == nil {
+				t.Error("Expected an error, got none")
+			}
+		})
+	}
+}
+
+func TestBundle_Negotiate(t *testing.T) {
+	bundle, err := New("en")
+	if err != nil {
+		t.Fatalf("Failed to create bundle: %v", err)
+	}
+
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", "en"},
+		{"uk", "uk"},
+		{"uk-UA,uk;q=0.9,en;q=0.8", "uk"},
+		{"en-US,en;q=0.9,uk;q=0.8", "en"},
+		{"fr-FR, uk;q=0.5", "uk"},
+		{"en;q=0.4, UK;q=0.7", "uk"},
+		{"uk;q=0, en;q=0.1", "en"},
+		{"fr, de", "en"},
+		{"*", "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := bundle.Negotiate(tt.header); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTranslator_Date(t *testing.T) {
+	at := time.Date(2025, 3, 7, 9, 5, 0, 0, time.UTC)
+
+	tests := []struct {
+		locale   string
+		date     string
+		dateTime string
+	}{
+		{"en", "Mar 07, 2025", "Mar 07, 2025 09:05"},
+		{"uk", "07 бер. 2025", "07 бер. 2025, 09:05"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			tr := Default().Translator(tt.locale)
+			if got := tr.Date(at); got != tt.date {
+				t.Errorf("Expected date %q, got %q", tt.date, got)
+			}
+			if got := tr.DateTime(at); got != tt.dateTime {
+				t.Errorf("Expected date and time %q, got %q", tt.dateTime, got)
+			}
+		})
+	}
+
+	// The English format is the one the templates used before
+	if got, want := Default().Translator("en").DateTime(at), at.Format("Jan 02, 2006 15:04"); got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if got := FromContext(context.Background()).Locale(); got != DefaultLocale {
+		t.Errorf("Expected the default locale without a translator, got %q", got)
+	}
+
+	uk := Default().Translator("uk")
+	if got := FromContext(WithTranslator(context.Background(), uk)); got != uk {
+		t.Errorf("Expected the translator of the context, got %v", got)
+	}
+	if got := strings.Join(uk.Locales(), ","); got != "en,uk" {
+		t.Errorf("Expected the locales en,uk, got %q", got)
+	}
+}
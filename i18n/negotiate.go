@@ -0,0 +1,58 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Match returns the supported locale for a language tag like "uk" or
+// "en-GB". Tags match a locale exactly or by their language.
+func (b *Bundle) Match(tag string) (string, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return "", false
+	}
+	if b.Supports(tag) {
+		return tag, true
+	}
+	language, _, _ := strings.Cut(tag, "-")
+	if b.Supports(language) {
+		return language, true
+	}
+	return "", false
+}
+
+// Negotiate returns the supported locale the Accept-Language header value
+// prefers most, or the default locale when it prefers none of them
+func (b *Bundle) Negotiate(acceptLanguage string) string {
+	type preference struct {
+		tag     string
+		quality float64
+	}
+
+	var preferences []preference
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		if quality > 0 {
+			preferences = append(preferences, preference{tag: tag, quality: quality})
+		}
+	}
+	sort.SliceStable(preferences, func(i, j int) bool {
+		return preferences[i].quality > preferences[j].quality
+	})
+
+	for _, p := range preferences {
+		if locale, ok := b.Match(p.tag); ok {
+			return locale
+		}
+	}
+	return b.defaultLocale
+}
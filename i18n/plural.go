@@ -0,0 +1,52 @@
+package i18n
+
+import "strings"
+
+// Plural categories of the CLDR plural rules, which name the forms of a
+// plural message
+const (
+	pluralOne   = "one"
+	pluralFew   = "few"
+	pluralMany  = "many"
+	pluralOther = "other"
+)
+
+// pluralRules pick the plural category of a count, by language
+var pluralRules = map[string]func(n int64) string{
+	"en": pluralOneOther,
+	"uk": pluralEastSlavic,
+}
+
+// pluralRule returns the plural rule of locale's language, falling back to
+// the English one/other rule
+func pluralRule(locale string) func(n int64) string {
+	language, _, _ := strings.Cut(locale, "-")
+	if rule, ok := pluralRules[language]; ok {
+		return rule
+	}
+	return pluralOneOther
+}
+
+// pluralOneOther is the rule of English and most Western European languages
+func pluralOneOther(n int64) string {
+	if n == 1 {
+		return pluralOne
+	}
+	return pluralOther
+}
+
+// pluralEastSlavic is the rule of Ukrainian, Russian and Belarusian:
+// 1, 21, 31 are one; 2-4, 22-24 are few; everything else is many
+func pluralEastSlavic(n int64) string {
+	if n < 0 {
+		n = -n
+	}
+	switch mod10, mod100 := n%10, n%100; {
+	case mod10 == 1 && mod100 != 11:
+		return pluralOne
+	case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+		return pluralFew
+	default:
+		return pluralMany
+	}
+}
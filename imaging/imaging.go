@@ -0,0 +1,111 @@
+// Package imaging inspects, cleans and resizes the images attached to posts.
+// Only the formats of the standard library are decoded: JPEG, PNG and GIF.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+
+	// Decoders of the formats images are inspected and resized in
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// ErrTooManyPixels is returned for images larger than they may be decoded
+// at. A small file can declare a huge image, a decompression bomb, so the
+// size is checked from the header before anything is decoded.
+var ErrTooManyPixels = errors.New("image has too many pixels")
+
+// decodable are the content types of the images that can be decoded
+var decodable = map[string]bool{"image/jpeg": true, "image/png": true, "image/gif": true}
+
+// Decodable reports whether images of contentType can be inspected
+func Decodable(contentType string) bool {
+	return decodable[contentType]
+}
+
+// Resizable reports whether images of contentType get resized copies. GIFs
+// would lose their animation, so they are shown as they are.
+func Resizable(contentType string) bool {
+	return contentType == "image/jpeg" || contentType == "image/png"
+}
+
+// Config describes an image as it is displayed, with its orientation applied
+type Config struct {
+	Width, Height int
+	// Orientation is the EXIF orientation, from 1 (upright) to 8
+	Orientation int
+}
+
+// Pixels returns the number of pixels of the decoded image
+func (c Config) Pixels() int64 {
+	return int64(c.Width) * int64(c.Height)
+}
+
+// Inspect reads the size and orientation of an image from its header,
+// without decoding it
+func Inspect(r io.Reader) (Config, error) {
+	var header bytes.Buffer
+	cfg, format, err := image.DecodeConfig(io.TeeReader(r, &header))
+	if err != nil {
+		return Config{}, err
+	}
+
+	config := Config{Width: cfg.Width, Height: cfg.Height, Orientation: 1}
+	if format == "jpeg" {
+		if orientation := jpegOrientation(header.Bytes()); orientation > 0 {
+			config.Orientation = orientation
+		}
+	}
+	// Orientations 5 to 8 turn the image on its side
+	if config.Orientation >= 5 {
+		config.Width, config.Height = config.Height, config.Width
+	}
+	return config, nil
+}
+
+// jpegOrientation finds the orientation in the EXIF segment of the start of
+// a JPEG file, returning 0 when there is none
+func jpegOrientation(data []byte) int {
+	for i := 2; i+4 <= len(data) && data[i] == 0xff; {
+		marker := data[i+1]
+		size := int(data[i+2])<<8 | int(data[i+3])
+		if marker == markerSOS || size < 2 || i+2+size > len(data) {
+			return 0
+		}
+		segment := data[i+4 : i+2+size]
+		if marker == markerAPP1 && bytes.HasPrefix(segment, exifHeader) {
+			return exifOrientation(segment[len(exifHeader):])
+		}
+		i += 2 + size
+	}
+	return 0
+}
+
+// Decode decodes an image of at most maxPixels pixels and turns it upright.
+// Larger images are rejected with ErrTooManyPixels before they are decoded.
+func Decode(r io.ReadSeeker, maxPixels int64) (*image.RGBA, error) {
+	config, err := Inspect(r)
+	if err != nil {
+		return nil, err
+	}
+	if maxPixels > 0 && config.Pixels() > maxPixels {
+		return nil, fmt.Errorf("%w: %dx%d", ErrTooManyPixels, config.Width, config.Height)
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	img, _, err := image.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	rgba := image.NewRGBA(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, img.Bounds().Min, draw.Src)
+	return orient(rgba, config.Orientation), nil
+}
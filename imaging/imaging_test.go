@@ -0,0 +1,107 @@
+//go:build unit
+
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pngHeader returns the start of a PNG file declaring an image of the given
+// size, without any image data
+func pngHeader(width, height int) []byte {
+	ihdr := binary.BigEndian.AppendUint32(nil, uint32(width))
+	ihdr = binary.BigEndian.AppendUint32(ihdr, uint32(height))
+	ihdr = append(ihdr, 8, 2, 0, 0, 0)
+	return append(append([]byte(nil), pngSignature...), pngChunk("IHDR", ihdr)...)
+}
+
+func TestInspect(t *testing.T) {
+	config, err := Inspect(bytes.NewReader(pngHeader(30000, 20000)))
+	require.NoError(t, err)
+	assert.Equal(t, Config{Width: 30000, Height: 20000, Orientation: 1}, config)
+	assert.Equal(t, int64(600_000_000), config.Pixels())
+
+	_, err = Inspect(bytes.NewReader([]byte("\x89PNG\r\n\x1a\n broken")))
+	assert.Error(t, err)
+}
+
+func TestDecode(t *testing.T) {
+	t.Run("decompression bomb", func(t *testing.T) {
+		_, err := Decode(bytes.NewReader(pngHeader(30000, 20000)), 40_000_000)
+		assert.ErrorIs(t, err, ErrTooManyPixels)
+	})
+
+	t.Run("orientation", func(t *testing.T) {
+		img, err := Decode(bytes.NewReader(testJPEG(t, 8, 4, exifSegment(6))), 40_000_000)
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 4, 8), img.Bounds(), "turned upright")
+	})
+
+	t.Run("png", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 5, 3))))
+		img, err := Decode(bytes.NewReader(buf.Bytes()), 0)
+		require.NoError(t, err)
+		assert.Equal(t, image.Rect(0, 0, 5, 3), img.Bounds())
+	})
+}
+
+func TestResize(t *testing.T) {
+	// Black and white columns average to gray
+	src := image.NewRGBA(image.Rect(0, 0, 8, 6))
+	for y := 0; y < 6; y++ {
+		for x := 0; x < 8; x += 2 {
+			src.Set(x, y, color.White)
+			src.Set(x+1, y, color.Black)
+		}
+	}
+
+	dst := Resize(src, 4)
+	assert.Equal(t, image.Rect(0, 0, 4, 3), dst.Bounds())
+	assert.Equal(t, color.RGBA{R: 128, G: 128, B: 128, A: 255}, dst.RGBAAt(2, 1))
+
+	assert.Same(t, src, Resize(src, 8), "images are never enlarged")
+	assert.Equal(t, image.Rect(0, 0, 3, 2), Resize(src, 3).Bounds())
+}
+
+func TestOrient(t *testing.T) {
+	// A 3x2 image with a numbered pixel in each position
+	src := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	for i := range 6 {
+		src.SetRGBA(i%3, i/3, color.RGBA{R: uint8(i), A: 255})
+	}
+	// pixels returns the numbers of the pixels of img row by row
+	pixels := func(img *image.RGBA) [][]uint8 {
+		var rows [][]uint8
+		for y := 0; y < img.Bounds().Dy(); y++ {
+			var row []uint8
+			for x := 0; x < img.Bounds().Dx(); x++ {
+				row = append(row, img.RGBAAt(x, y).R)
+			}
+			rows = append(rows, row)
+		}
+		return rows
+	}
+
+	tests := map[int][][]uint8{
+		1: {{0, 1, 2}, {3, 4, 5}},
+		2: {{2, 1, 0}, {5, 4, 3}},
+		3: {{5, 4, 3}, {2, 1, 0}},
+		4: {{3, 4, 5}, {0, 1, 2}},
+		5: {{0, 3}, {1, 4}, {2, 5}},
+		6: {{3, 0}, {4, 1}, {5, 2}},
+		7: {{5, 2}, {4, 1}, {3, 0}},
+		8: {{2, 5}, {1, 4}, {0, 3}},
+	}
+	for orientation, want := range tests {
+		assert.Equal(t, want, pixels(orient(src, orientation)), "orientation %d", orientation)
+	}
+}
@@ -0,0 +1,293 @@
+package imaging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrMalformed is returned for images whose structure can't be followed,
+// like truncated files
+var ErrMalformed = errors.New("malformed image")
+
+// Strip copies the image in r to w without its metadata: EXIF, XMP, IPTC,
+// comments and text chunks, which can carry the location and camera a
+// photo was taken with. The orientation of JPEG photos is kept, in an EXIF
+// segment of its own, so that they aren't shown on their side. Color
+// profiles are kept too. Files of other types are copied as they are.
+func Strip(w io.Writer, r io.Reader, contentType string) error {
+	var err error
+	switch contentType {
+	case "image/jpeg":
+		err = stripJPEG(w, bufio.NewReader(r))
+	case "image/png":
+		err = stripPNG(w, r)
+	case "image/webp":
+		err = stripWebP(w, r)
+	default:
+		_, err = io.Copy(w, r)
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w: truncated file", ErrMalformed)
+	}
+	return err
+}
+
+// JPEG markers
+const (
+	markerSOS  = 0xda // start of scan, followed by the compressed image
+	markerAPP0 = 0xe0 // JFIF
+	markerAPP1 = 0xe1 // EXIF or XMP
+	markerAPP2 = 0xe2 // ICC color profile
+	markerAPPE = 0xee // Adobe color transform
+	markerAPPF = 0xef
+	markerCOM  = 0xfe
+)
+
+// exifHeader starts the APP1 segment of EXIF data
+var exifHeader = []byte("Exif\x00\x00")
+
+// stripJPEG copies the segments of a JPEG file up to the image data, leaving
+// out application segments other than JFIF, ICC profiles and the Adobe color
+// transform, as well as comments
+func stripJPEG(w io.Writer, r *bufio.Reader) error {
+	var soi [2]byte
+	if _, err := io.ReadFull(r, soi[:]); err != nil {
+		return err
+	}
+	if soi != [2]byte{0xff, 0xd8} {
+		return fmt.Errorf("%w: no JPEG start of image", ErrMalformed)
+	}
+	if _, err := w.Write(soi[:]); err != nil {
+		return err
+	}
+
+	for {
+		marker, err := readMarker(r)
+		if err != nil {
+			return err
+		}
+		// Markers without a segment, like restart markers
+		if marker == 0x01 || (marker >= 0xd0 && marker <= 0xd7) {
+			if _, err := w.Write([]byte{0xff, marker}); err != nil {
+				return err
+			}
+			continue
+		}
+		if marker == markerSOS {
+			if _, err := w.Write([]byte{0xff, marker}); err != nil {
+				return err
+			}
+			_, err := io.Copy(w, r)
+			return err
+		}
+
+		var length [2]byte
+		if _, err := io.ReadFull(r, length[:]); err != nil {
+			return err
+		}
+		size := int(binary.BigEndian.Uint16(length[:]))
+		if size < 2 {
+			return fmt.Errorf("%w: JPEG segment of %d bytes", ErrMalformed, size)
+		}
+		segment := make([]byte, size-2)
+		if _, err := io.ReadFull(r, segment); err != nil {
+			return err
+		}
+
+		switch {
+		case marker == markerAPP1 && bytes.HasPrefix(segment, exifHeader):
+			if orientation := exifOrientation(segment[len(exifHeader):]); orientation > 1 {
+				if _, err := w.Write(orientationSegment(orientation)); err != nil {
+					return err
+				}
+			}
+			continue
+		case marker == markerCOM, marker >= markerAPP0 && marker <= markerAPPF &&
+			marker != markerAPP0 && marker != markerAPP2 && marker != markerAPPE:
+			continue
+		}
+
+		if _, err := w.Write([]byte{0xff, marker, length[0], length[1]}); err != nil {
+			return err
+		}
+		if _, err := w.Write(segment); err != nil {
+			return err
+		}
+	}
+}
+
+// readMarker reads the next JPEG marker, skipping the fill bytes before it
+func readMarker(r *bufio.Reader) (byte, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if b != 0xff {
+		return 0, fmt.Errorf("%w: expected a JPEG marker", ErrMalformed)
+	}
+	for b == 0xff {
+		if b, err = r.ReadByte(); err != nil {
+			return 0, err
+		}
+	}
+	return b, nil
+}
+
+// orientationTag is the EXIF tag of the orientation of the image
+const orientationTag = 0x0112
+
+// exifOrientation returns the orientation recorded in EXIF data, from 1 to
+// 8, or 0 when there is none. tiff is the data after the EXIF header.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 0
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 0
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:]) != orientationTag {
+			continue
+		}
+		if orientation := int(order.Uint16(tiff[entry+8:])); orientation >= 1 && orientation <= 8 {
+			return orientation
+		}
+		return 0
+	}
+	return 0
+}
+
+// orientationSegment returns an APP1 segment of EXIF data holding nothing
+// but the orientation
+func orientationSegment(orientation int) []byte {
+	tiff := []byte{
+		'M', 'M', 0x00, 0x2a, // big-endian TIFF
+		0x00, 0x00, 0x00, 0x08, // the first IFD follows the header
+		0x00, 0x01, // one entry
+		0x01, 0x12, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01, // orientation, one SHORT
+		0x00, byte(orientation), 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, // no next IFD
+	}
+	size := 2 + len(exifHeader) + len(tiff)
+	segment := []byte{0xff, markerAPP1, byte(size >> 8), byte(size)}
+	segment = append(segment, exifHeader...)
+	return append(segment, tiff...)
+}
+
+// pngSignature starts every PNG file
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// pngMetadata are the PNG chunks left out: EXIF, text (which holds XMP too)
+// and the modification time
+var pngMetadata = map[string]bool{"eXIf": true, "tEXt": true, "zTXt": true, "iTXt": true, "tIME": true}
+
+// stripPNG copies the chunks of a PNG file but its metadata
+func stripPNG(w io.Writer, r io.Reader) error {
+	signature := make([]byte, len(pngSignature))
+	if _, err := io.ReadFull(r, signature); err != nil {
+		return err
+	}
+	if !bytes.Equal(signature, pngSignature) {
+		return fmt.Errorf("%w: no PNG signature", ErrMalformed)
+	}
+	if _, err := w.Write(signature); err != nil {
+		return err
+	}
+
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return err
+		}
+		// The chunk data is followed by its CRC
+		size := int64(binary.BigEndian.Uint32(header[:4])) + 4
+		kind := string(header[4:])
+
+		if pngMetadata[kind] {
+			if _, err := io.CopyN(io.Discard, r, size); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := w.Write(header[:]); err != nil {
+			return err
+		}
+		if _, err := io.CopyN(w, r, size); err != nil {
+			return err
+		}
+		if kind == "IEND" {
+			return nil
+		}
+	}
+}
+
+// VP8X flags of the metadata chunks of a WebP file
+const (
+	webpFlagEXIF = 0x08
+	webpFlagXMP  = 0x04
+)
+
+// stripWebP rewrites a WebP file without its EXIF and XMP chunks. The RIFF
+// container starts with the size of the whole file, so the file is read
+// into memory first.
+func stripWebP(w io.Writer, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return fmt.Errorf("%w: no WebP header", ErrMalformed)
+	}
+
+	out := append([]byte(nil), data[:12]...)
+	for rest := data[12:]; len(rest) > 0; {
+		if len(rest) < 8 {
+			return fmt.Errorf("%w: truncated WebP chunk", ErrMalformed)
+		}
+		kind := string(rest[:4])
+		// Chunks are padded to an even size
+		size := int(binary.LittleEndian.Uint32(rest[4:8]))
+		end := 8 + size + size%2
+		if size < 0 || end > len(rest) {
+			return fmt.Errorf("%w: truncated WebP chunk", ErrMalformed)
+		}
+		chunk := rest[:end]
+		rest = rest[end:]
+
+		switch kind {
+		case "EXIF", "XMP ":
+			continue
+		case "VP8X":
+			if size < 1 {
+				return fmt.Errorf("%w: empty VP8X chunk", ErrMalformed)
+			}
+			chunk = append([]byte(nil), chunk...)
+			chunk[8] &^= webpFlagEXIF | webpFlagXMP
+		}
+		out = append(out, chunk...)
+	}
+
+	binary.LittleEndian.PutUint32(out[4:8], uint32(len(out)-8))
+	_, err = w.Write(out)
+	return err
+}
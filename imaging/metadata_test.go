@@ -0,0 +1,125 @@
+//go:build unit
+
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exifSegment returns an APP1 segment of little-endian EXIF data with the
+// given orientation, followed by a camera model
+func exifSegment(orientation int) []byte {
+	tiff := []byte{'I', 'I', 0x2a, 0x00, 0x08, 0x00, 0x00, 0x00, 0x02, 0x00}
+	// Camera model, an ASCII string stored after the IFD
+	tiff = append(tiff, 0x10, 0x01, 0x02, 0x00, 0x0b, 0x00, 0x00, 0x00, 0x26, 0x00, 0x00, 0x00)
+	tiff = append(tiff, 0x12, 0x01, 0x03, 0x00, 0x01, 0x00, 0x00, 0x00, byte(orientation), 0x00, 0x00, 0x00)
+	tiff = append(tiff, 0x00, 0x00, 0x00, 0x00)
+	tiff = append(tiff, "Spy Camera\x00"...)
+
+	size := 2 + len(exifHeader) + len(tiff)
+	segment := []byte{0xff, markerAPP1, byte(size >> 8), byte(size)}
+	segment = append(segment, exifHeader...)
+	return append(segment, tiff...)
+}
+
+// testJPEG encodes a width by height image as JPEG, with segments inserted
+// after the start of image
+func testJPEG(t *testing.T, width, height int, segments ...[]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height)), nil))
+
+	data := buf.Bytes()
+	out := append([]byte(nil), data[:2]...)
+	for _, segment := range segments {
+		out = append(out, segment...)
+	}
+	return append(out, data[2:]...)
+}
+
+func TestStrip_JPEG(t *testing.T) {
+	comment := append([]byte{0xff, markerCOM, 0x00, 0x0b}, "Home town"...)
+	original := testJPEG(t, 8, 4, exifSegment(6), comment)
+
+	var stripped bytes.Buffer
+	require.NoError(t, Strip(&stripped, bytes.NewReader(original), "image/jpeg"))
+	assert.NotContains(t, stripped.String(), "Spy Camera")
+	assert.NotContains(t, stripped.String(), "Home town")
+	assert.Contains(t, stripped.String(), string(orientationSegment(6)), "the orientation is kept")
+
+	config, err := Inspect(bytes.NewReader(stripped.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, Config{Width: 4, Height: 8, Orientation: 6}, config)
+	_, err = jpeg.Decode(bytes.NewReader(stripped.Bytes()))
+	assert.NoError(t, err)
+
+	t.Run("upright", func(t *testing.T) {
+		var stripped bytes.Buffer
+		require.NoError(t, Strip(&stripped, bytes.NewReader(testJPEG(t, 8, 4, exifSegment(1))), "image/jpeg"))
+		assert.NotContains(t, stripped.String(), "Exif", "no EXIF segment is needed")
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		err := Strip(&bytes.Buffer{}, bytes.NewReader(original[:10]), "image/jpeg")
+		assert.ErrorIs(t, err, ErrMalformed)
+	})
+}
+
+// pngChunk returns a PNG chunk
+func pngChunk(kind string, data []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(chunk, kind...)
+	chunk = append(chunk, data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+func TestStrip_PNG(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 3, 2))))
+	data := buf.Bytes()
+	// After the signature and the IHDR chunk
+	ihdrEnd := len(pngSignature) + 8 + 13 + 4
+	original := append([]byte(nil), data[:ihdrEnd]...)
+	original = append(original, pngChunk("tEXt", []byte("Author\x00Jane Doe"))...)
+	original = append(original, pngChunk("eXIf", exifSegment(1)[10:])...)
+	original = append(original, data[ihdrEnd:]...)
+
+	var stripped bytes.Buffer
+	require.NoError(t, Strip(&stripped, bytes.NewReader(original), "image/png"))
+	assert.Equal(t, data, stripped.Bytes())
+}
+
+func TestStrip_WebP(t *testing.T) {
+	chunk := func(kind, data string) string {
+		size := binary.LittleEndian.AppendUint32(nil, uint32(len(data)))
+		if len(data)%2 == 1 {
+			data += "\x00"
+		}
+		return kind + string(size) + data
+	}
+	webp := func(chunks ...string) string {
+		body := "WEBP" + strings.Join(chunks, "")
+		return "RIFF" + string(binary.LittleEndian.AppendUint32(nil, uint32(len(body)))) + body
+	}
+
+	original := webp(chunk("VP8X", "\x0c\x00\x00\x00\x00\x00\x00\x00\x00\x00"), chunk("VP8 ", "frame"), chunk("EXIF", "Spy Camera"), chunk("XMP ", "<x/>"))
+	var stripped bytes.Buffer
+	require.NoError(t, Strip(&stripped, strings.NewReader(original), "image/webp"))
+	assert.Equal(t, webp(chunk("VP8X", "\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00"), chunk("VP8 ", "frame")), stripped.String())
+}
+
+func TestStrip_Other(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, Strip(&out, strings.NewReader("%PDF-1.7"), "application/pdf"))
+	assert.Equal(t, "%PDF-1.7", out.String())
+}
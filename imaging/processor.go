@@ -0,0 +1,190 @@
+package imaging
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gekich/news-app/media"
+	"github.com/gekich/news-app/models"
+)
+
+const (
+	// queueSize is how many images may wait for a worker. Uploads beyond it
+	// are shown at full size until "newsctl media thumbnails" catches up.
+	queueSize = 256
+	// jobTimeout bounds the time spent on the copies of one image
+	jobTimeout = 2 * time.Minute
+	// jpegQuality is the quality resized JPEGs are encoded at
+	jpegQuality = 85
+)
+
+// Recorder saves the variants made of an image attachment on the post that
+// has it. It fails when no post has the attachment anymore.
+type Recorder interface {
+	SetImageVariants(ctx context.Context, attachmentID string, variants []models.ImageVariant) error
+}
+
+// Processor makes resized copies of image attachments, one for each of its
+// widths narrower than the image. Encoding the copies drops the metadata of
+// the original. Uploads queue their images, which a pool of workers
+// processes in the background, so that saving a post doesn't wait on them.
+type Processor struct {
+	store     media.Store
+	recorder  Recorder
+	widths    []int
+	maxPixels int64
+
+	jobs   chan models.Attachment
+	mu     sync.Mutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewProcessor returns a processor saving copies of images in store and
+// recording them with recorder. Images of more than maxPixels pixels aren't
+// decoded. Call Start to process queued images.
+func NewProcessor(store media.Store, recorder Recorder, widths []int, maxPixels int64) *Processor {
+	widths = slices.Clone(widths)
+	slices.Sort(widths)
+	return &Processor{
+		store:     store,
+		recorder:  recorder,
+		widths:    slices.Compact(widths),
+		maxPixels: maxPixels,
+		jobs:      make(chan models.Attachment, queueSize),
+	}
+}
+
+// Start runs workers processing the queued images until Close
+func (p *Processor) Start(workers int) {
+	for i := 0; i < max(1, workers); i++ {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			for attachment := range p.jobs {
+				ctx, cancel := context.WithTimeout(context.Background(), jobTimeout)
+				if err := p.Process(ctx, attachment); err != nil {
+					log.Printf("Failed to resize image %s: %v", attachment.ID, err)
+				}
+				cancel()
+			}
+		}()
+	}
+}
+
+// Enqueue queues the copies of an image attachment to be made. Attachments
+// that aren't resizable images are skipped. It reports false when the queue
+// is full or closed.
+func (p *Processor) Enqueue(attachment models.Attachment) bool {
+	if !Resizable(attachment.ContentType) {
+		return true
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return false
+	}
+	select {
+	case p.jobs <- attachment:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close stops taking images and waits for the workers to finish the queued
+// ones
+func (p *Processor) Close() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.jobs)
+	}
+	p.mu.Unlock()
+	p.wg.Wait()
+}
+
+// Process makes the copies of an image attachment and records them. When
+// they can't be recorded, because the post or the attachment is gone, the
+// copies are deleted again.
+func (p *Processor) Process(ctx context.Context, attachment models.Attachment) error {
+	if !Resizable(attachment.ContentType) {
+		return nil
+	}
+
+	file, err := p.store.Open(ctx, attachment.ID)
+	if err != nil {
+		return err
+	}
+	img, err := Decode(file, p.maxPixels)
+	file.Close()
+	if err != nil {
+		return err
+	}
+
+	var variants []models.ImageVariant
+	for _, width := range p.widths {
+		if width >= img.Bounds().Dx() {
+			break
+		}
+		variant, err := p.save(ctx, attachment, Resize(img, width))
+		if err != nil {
+			p.discard(ctx, variants)
+			return err
+		}
+		variants = append(variants, variant)
+	}
+	if len(variants) == 0 {
+		return nil
+	}
+
+	if err := p.recorder.SetImageVariants(ctx, attachment.ID, variants); err != nil {
+		p.discard(ctx, variants)
+		return err
+	}
+	return nil
+}
+
+// save encodes a copy of an image attachment in the attachment's format and
+// stores it
+func (p *Processor) save(ctx context.Context, attachment models.Attachment, img *image.RGBA) (models.ImageVariant, error) {
+	var buf bytes.Buffer
+	var err error
+	if attachment.ContentType == "image/png" {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	}
+	if err != nil {
+		return models.ImageVariant{}, err
+	}
+
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	ext := path.Ext(attachment.Filename)
+	filename := strings.TrimSuffix(attachment.Filename, ext) + "-" + strconv.Itoa(width) + "w" + ext
+	info, err := p.store.Save(ctx, filename, attachment.ContentType, &buf)
+	if err != nil {
+		return models.ImageVariant{}, err
+	}
+	return models.ImageVariant{ID: info.ID, ContentType: info.ContentType, Width: width, Height: height}, nil
+}
+
+// discard deletes copies that won't be recorded
+func (p *Processor) discard(ctx context.Context, variants []models.ImageVariant) {
+	for _, variant := range variants {
+		if err := p.store.Delete(ctx, variant.ID); err != nil {
+			log.Printf("Failed to delete image variant %s: %v", variant.ID, err)
+		}
+	}
+}
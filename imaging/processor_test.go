@@ -0,0 +1,104 @@
+//go:build unit
+
+package imaging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/gekich/news-app/media"
+	"github.com/gekich/news-app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryRecorder records variants in memory, failing for unknown attachments
+type memoryRecorder struct {
+	mu       sync.Mutex
+	known    map[string]bool
+	variants map[string][]models.ImageVariant
+}
+
+func (r *memoryRecorder) SetImageVariants(ctx context.Context, attachmentID string, variants []models.ImageVariant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.known[attachmentID] {
+		return errors.New("no post has the attachment")
+	}
+	r.variants[attachmentID] = variants
+	return nil
+}
+
+// storeImage saves a JPEG of the given size with EXIF data and returns it as
+// an attachment
+func storeImage(t *testing.T, store media.Store, width, height int) models.Attachment {
+	t.Helper()
+	info, err := store.Save(context.Background(), "photo.jpg", "image/jpeg", bytes.NewReader(testJPEG(t, width, height, exifSegment(1))))
+	require.NoError(t, err)
+	return models.Attachment{ID: info.ID, Filename: info.Filename, ContentType: info.ContentType, Size: info.Size}
+}
+
+// storedIDs returns the IDs of the files in store
+func storedIDs(t *testing.T, store media.Store) []string {
+	t.Helper()
+	var ids []string
+	require.NoError(t, store.Walk(context.Background(), func(info media.Info) error {
+		ids = append(ids, info.ID)
+		return nil
+	}))
+	return ids
+}
+
+func TestProcessor(t *testing.T) {
+	store, err := media.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	photo := storeImage(t, store, 160, 120)
+	recorder := &memoryRecorder{known: map[string]bool{photo.ID: true}, variants: map[string][]models.ImageVariant{}}
+
+	processor := NewProcessor(store, recorder, []int{640, 40, 80, 80}, 40_000_000)
+	processor.Start(2)
+	assert.True(t, processor.Enqueue(photo))
+	assert.True(t, processor.Enqueue(models.Attachment{ID: "document", ContentType: "application/pdf"}), "other files are skipped")
+	processor.Close()
+	assert.False(t, processor.Enqueue(photo), "closed processors take no images")
+
+	variants := recorder.variants[photo.ID]
+	require.Len(t, variants, 2, "one copy per width narrower than the image")
+	assert.Equal(t, []int{40, 30, 80, 60}, []int{variants[0].Width, variants[0].Height, variants[1].Width, variants[1].Height})
+
+	f, err := store.Open(context.Background(), variants[1].ID)
+	require.NoError(t, err)
+	defer f.Close()
+	assert.Equal(t, "photo-80w.jpg", f.Info().Filename)
+	assert.Equal(t, "image/jpeg", f.Info().ContentType)
+	config, err := Inspect(f)
+	require.NoError(t, err)
+	assert.Equal(t, Config{Width: 80, Height: 60, Orientation: 1}, config)
+}
+
+func TestProcessor_Discard(t *testing.T) {
+	store, err := media.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	recorder := &memoryRecorder{known: map[string]bool{}, variants: map[string][]models.ImageVariant{}}
+	processor := NewProcessor(store, recorder, []int{40, 80}, 40_000_000)
+
+	t.Run("attachment gone", func(t *testing.T) {
+		photo := storeImage(t, store, 160, 120)
+		assert.Error(t, processor.Process(context.Background(), photo))
+		assert.Equal(t, []string{photo.ID}, storedIDs(t, store), "the copies are deleted again")
+		require.NoError(t, store.Delete(context.Background(), photo.ID))
+	})
+
+	t.Run("decompression bomb", func(t *testing.T) {
+		info, err := store.Save(context.Background(), "bomb.png", "image/png", bytes.NewReader(pngHeader(50000, 50000)))
+		require.NoError(t, err)
+		bomb := models.Attachment{ID: info.ID, ContentType: "image/png"}
+		recorder.known[bomb.ID] = true
+
+		assert.ErrorIs(t, processor.Process(context.Background(), bomb), ErrTooManyPixels)
+		assert.Empty(t, recorder.variants)
+	})
+}
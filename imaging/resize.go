@@ -0,0 +1,94 @@
+package imaging
+
+import (
+	"image"
+)
+
+// Resize scales src down to width, keeping its aspect ratio. Each pixel of
+// the result averages the pixels of src it covers, which keeps downscaled
+// photos smooth. Images no wider than width are returned as they are.
+func Resize(src *image.RGBA, width int) *image.RGBA {
+	bounds := src.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	if width <= 0 || width >= srcWidth {
+		return src
+	}
+	height := max(1, (srcHeight*width+srcWidth/2)/srcWidth)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := span(y, height, srcHeight)
+		for x := 0; x < width; x++ {
+			x0, x1 := span(x, width, srcWidth)
+
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				i := src.PixOffset(bounds.Min.X+x0, bounds.Min.Y+sy)
+				for sx := x0; sx < x1; sx++ {
+					sum[0] += int(src.Pix[i])
+					sum[1] += int(src.Pix[i+1])
+					sum[2] += int(src.Pix[i+2])
+					sum[3] += int(src.Pix[i+3])
+					i += 4
+				}
+			}
+
+			n := (x1 - x0) * (y1 - y0)
+			j := dst.PixOffset(x, y)
+			for c := range sum {
+				dst.Pix[j+c] = uint8((sum[c] + n/2) / n)
+			}
+		}
+	}
+	return dst
+}
+
+// span returns the range of source pixels the i-th of n pixels covers
+func span(i, n, size int) (int, int) {
+	start, end := i*size/n, (i+1)*size/n
+	if end <= start {
+		end = start + 1
+	}
+	return start, end
+}
+
+// orient turns an image with the given EXIF orientation upright
+func orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation <= 1 || orientation > 8 {
+		return src
+	}
+
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			// The source pixel shown at x, y, after the turn the orientation asks for
+			var sx, sy int
+			switch orientation {
+			case 2: // flipped left to right
+				sx, sy = w-1-x, y
+			case 3: // half a turn
+				sx, sy = w-1-x, h-1-y
+			case 4: // flipped upside down
+				sx, sy = x, h-1-y
+			case 5: // flipped along the diagonal
+				sx, sy = y, x
+			case 6: // a quarter turn clockwise
+				sx, sy = y, h-1-x
+			case 7: // flipped along the other diagonal
+				sx, sy = w-1-y, h-1-x
+			case 8: // a quarter turn counterclockwise
+				sx, sy = w-1-y, x
+			}
+			i := src.PixOffset(bounds.Min.X+sx, bounds.Min.Y+sy)
+			copy(dst.Pix[dst.PixOffset(x, y):], src.Pix[i:i+4])
+		}
+	}
+	return dst
+}
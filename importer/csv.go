@@ -0,0 +1,169 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gekich/news-app/models"
+)
+
+// TagSeparator separates tags within a single CSV column
+const TagSeparator = "|"
+
+// csvFields are the post fields a CSV column can map to
+var csvFields = map[string]bool{
+	"title":             true,
+	"content":           true,
+	"author":            true,
+	"tags":              true,
+	"status":            true,
+	"language":          true,
+	"translation_group": true,
+	"created_at":        true,
+	"updated_at":        true,
+}
+
+// timeLayouts are the timestamp formats accepted in CSV columns
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// csvReader maps the columns of each CSV record onto post fields
+type csvReader struct {
+	reader *csv.Reader
+	// fields holds the post field for each column index, or "" to ignore the column
+	fields []string
+}
+
+func newCSVReader(r io.Reader, columns map[string]string) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	for column, field := range columns {
+		if !csvFields[field] {
+			return nil, fmt.Errorf("column %q maps to unknown field %q", column, field)
+		}
+	}
+
+	fields := make([]string, len(header))
+	mapped := make(map[string]bool)
+	for i, column := range header {
+		column = strings.TrimSpace(strings.TrimPrefix(column, "\ufeff"))
+		field, ok := columns[column]
+		if !ok {
+			field = strings.ToLower(column)
+		}
+		if csvFields[field] {
+			fields[i] = field
+			mapped[field] = true
+		}
+	}
+
+	if !mapped["title"] || !mapped["content"] {
+		return nil, fmt.Errorf("CSV header must map columns to both title and content")
+	}
+
+	return &csvReader{reader: reader, fields: fields}, nil
+}
+
+// Next decodes the next CSV record
+func (r *csvReader) Next() (models.Post, error) {
+	var post models.Post
+
+	record, err := r.reader.Read()
+	if err == io.EOF {
+		return post, io.EOF
+	}
+	if err != nil {
+		if parseErr, ok := err.(*csv.ParseError); ok {
+			return post, &RowError{Message: parseErr.Err.Error()}
+		}
+		return post, err
+	}
+
+	for i, value := range record {
+		if i >= len(r.fields) {
+			break
+		}
+
+		switch r.fields[i] {
+		case "title":
+			post.Title = value
+		case "content":
+			post.Content = value
+		case "author":
+			post.Author = strings.TrimSpace(value)
+		case "tags":
+			post.Tags = splitTags(value)
+		case "status":
+			post.Status = strings.TrimSpace(value)
+		case "language":
+			post.Language = strings.TrimSpace(value)
+		case "translation_group":
+			post.TranslationGroup = strings.TrimSpace(value)
+		case "created_at":
+			if post.CreatedAt, err = parseTime(value); err != nil {
+				return post, &RowError{Field: "created_at", Message: err.Error()}
+			}
+		case "updated_at":
+			if post.UpdatedAt, err = parseTime(value); err != nil {
+				return post, &RowError{Field: "updated_at", Message: err.Error()}
+			}
+		}
+	}
+
+	return post, nil
+}
+
+// splitTags splits a tag column, dropping empty entries
+func splitTags(value string) []string {
+	var tags []string
+	for _, tag := range strings.Split(value, TagSeparator) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// parseTime parses a timestamp in any of the accepted layouts. Empty values yield the zero time.
+func parseTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q", value)
+}
+
+// ParseColumns parses a column mapping such as "headline=title,body=content"
+func ParseColumns(s string) (map[string]string, error) {
+	columns := make(map[string]string)
+	if strings.TrimSpace(s) == "" {
+		return columns, nil
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		column, field, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid column mapping %q, expected column=field", pair)
+		}
+		columns[strings.TrimSpace(column)] = strings.TrimSpace(field)
+	}
+	return columns, nil
+}
@@ -0,0 +1,238 @@
+package importer
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+var (
+	// blankLines collapses runs of blank lines left behind by nested blocks
+	blankLines = regexp.MustCompile(`\n{3,}`)
+	// inlineSpace collapses whitespace in text outside of code blocks
+	inlineSpace = regexp.MustCompile(`\s+`)
+)
+
+// HTMLToMarkdown converts post HTML, as stored by WordPress, into the
+// Markdown used for post content. WordPress stores paragraphs as blank-line
+// separated text rather than <p> tags, so those are preserved. Block editor
+// comments and unsupported elements are dropped, keeping their text.
+func HTMLToMarkdown(s string) string {
+	if strings.TrimSpace(s) == "" {
+		return ""
+	}
+
+	nodes, err := html.ParseFragment(strings.NewReader(autop(s)), &html.Node{
+		Type:     html.ElementNode,
+		Data:     "body",
+		DataAtom: atom.Body,
+	})
+	if err != nil {
+		return strings.TrimSpace(s)
+	}
+
+	c := &markdownConverter{}
+	for _, node := range nodes {
+		c.node(node)
+	}
+	return c.String()
+}
+
+// autop turns blank-line separated text into paragraphs like WordPress's
+// wpautop. Content that already has explicit paragraphs or preformatted
+// blocks, as written by the block editor, is left untouched.
+func autop(s string) string {
+	if strings.Contains(s, "<p") || strings.Contains(s, "<pre") {
+		return s
+	}
+
+	var paragraphs []string
+	for _, p := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n\n") {
+		if p = strings.TrimSpace(p); p != "" {
+			paragraphs = append(paragraphs, "<p>"+p+"</p>")
+		}
+	}
+	return strings.Join(paragraphs, "\n")
+}
+
+// markdownConverter writes Markdown for a parsed HTML tree
+type markdownConverter struct {
+	b     strings.Builder
+	lists []listState
+	pre   bool
+}
+
+// listState tracks the kind and position within an open list
+type listState struct {
+	ordered bool
+	index   int
+}
+
+// String returns the Markdown written so far
+func (c *markdownConverter) String() string {
+	return strings.TrimSpace(blankLines.ReplaceAllString(c.b.String(), "\n\n"))
+}
+
+// block starts a new block, separated from the previous one by a blank line
+func (c *markdownConverter) block() {
+	c.b.WriteString("\n\n")
+}
+
+func (c *markdownConverter) children(n *html.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		c.node(child)
+	}
+}
+
+func (c *markdownConverter) node(n *html.Node) {
+	switch n.Type {
+	case html.TextNode:
+		c.text(n.Data)
+		return
+	case html.ElementNode:
+	default:
+		// Comments include the Gutenberg block delimiters
+		return
+	}
+
+	switch n.Data {
+	case "p", "div", "figure", "section", "article":
+		c.block()
+		c.children(n)
+		c.block()
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		c.block()
+		c.b.WriteString(strings.Repeat("#", int(n.Data[1]-'0')) + " ")
+		c.children(n)
+		c.block()
+	case "br":
+		c.b.WriteString("\n")
+		c.indent()
+	case "hr":
+		c.block()
+		c.b.WriteString("---")
+		c.block()
+	case "strong", "b":
+		c.wrap(n, "**")
+	case "em", "i":
+		c.wrap(n, "*")
+	case "del", "s", "strike":
+		c.wrap(n, "~~")
+	case "code":
+		if c.pre {
+			c.children(n)
+		} else {
+			c.wrap(n, "`")
+		}
+	case "pre":
+		c.block()
+		c.b.WriteString("```\n")
+		c.pre = true
+		c.children(n)
+		c.pre = false
+		c.b.WriteString("\n```")
+		c.block()
+	case "a":
+		href := attr(n, "href")
+		if href == "" {
+			c.children(n)
+			return
+		}
+		c.b.WriteString("[")
+		c.children(n)
+		c.b.WriteString("](" + href + ")")
+	case "img":
+		if src := attr(n, "src"); src != "" {
+			c.b.WriteString("![" + attr(n, "alt") + "](" + src + ")")
+		}
+	case "blockquote":
+		c.blockquote(n)
+	case "ul", "ol":
+		c.block()
+		c.lists = append(c.lists, listState{ordered: n.Data == "ol"})
+		c.children(n)
+		c.lists = c.lists[:len(c.lists)-1]
+		c.block()
+	case "li":
+		c.listItem(n)
+	case "script", "style", "iframe":
+		// Embedded scripts and players have no Markdown equivalent
+	default:
+		c.children(n)
+	}
+}
+
+// listItem writes a bullet or number for the innermost list
+func (c *markdownConverter) listItem(n *html.Node) {
+	marker := "- "
+	if len(c.lists) > 0 {
+		list := &c.lists[len(c.lists)-1]
+		list.index++
+		if list.ordered {
+			marker = strconv.Itoa(list.index) + ". "
+		}
+	}
+
+	c.b.WriteString("\n")
+	if len(c.lists) > 1 {
+		c.b.WriteString(strings.Repeat("  ", len(c.lists)-1))
+	}
+	c.b.WriteString(marker)
+	c.children(n)
+}
+
+// blockquote converts the quoted content on its own and prefixes every line
+func (c *markdownConverter) blockquote(n *html.Node) {
+	quoted := &markdownConverter{}
+	quoted.children(n)
+
+	lines := strings.Split(quoted.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight("> "+line, " ")
+	}
+
+	c.block()
+	c.b.WriteString(strings.Join(lines, "\n"))
+	c.block()
+}
+
+// indent continues a list item on a new line
+func (c *markdownConverter) indent() {
+	if len(c.lists) > 0 {
+		c.b.WriteString(strings.Repeat("  ", len(c.lists)))
+	}
+}
+
+// wrap surrounds the element's text with a Markdown marker
+func (c *markdownConverter) wrap(n *html.Node, marker string) {
+	c.b.WriteString(marker)
+	c.children(n)
+	c.b.WriteString(marker)
+}
+
+// text writes a text node, collapsing whitespace outside preformatted blocks
+func (c *markdownConverter) text(s string) {
+	if c.pre {
+		c.b.WriteString(s)
+		return
+	}
+	s = inlineSpace.ReplaceAllString(s, " ")
+	// Avoid leading spaces at the start of a line
+	if out := c.b.String(); out == "" || strings.HasSuffix(out, "\n") {
+		s = strings.TrimLeft(s, " ")
+	}
+	c.b.WriteString(s)
+}
+
+// attr returns the value of an attribute, or "" when it's missing
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
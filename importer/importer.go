@@ -0,0 +1,316 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gekich/news-app/i18n"
+	"github.com/gekich/news-app/models"
+	"github.com/gekich/news-app/validation"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DefaultBatchSize is the number of valid rows written at a time
+const DefaultBatchSize = 500
+
+// DefaultMaxFileBytes is the largest file read from a Markdown archive
+const DefaultMaxFileBytes = 4 << 20
+
+// Format identifies the layout of an import file
+type Format string
+
+const (
+	// FormatJSONL is one JSON encoded post per line
+	FormatJSONL Format = "jsonl"
+	// FormatCSV is a CSV file with a header row naming the columns
+	FormatCSV Format = "csv"
+	// FormatMarkdown is a zip archive of Markdown files with YAML frontmatter
+	FormatMarkdown Format = "markdown"
+	// FormatWXR is a WordPress eXtended RSS export file
+	FormatWXR Format = "wxr"
+)
+
+// ParseFormat converts a format name into a Format
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(s) {
+	case "jsonl", "ndjson":
+		return FormatJSONL, nil
+	case "csv":
+		return FormatCSV, nil
+	case "markdown", "md", "zip":
+		return FormatMarkdown, nil
+	case "wxr", "xml", "wordpress":
+		return FormatWXR, nil
+	default:
+		return "", fmt.Errorf("unsupported import format %q", s)
+	}
+}
+
+// FormatFromFilename guesses the format from a file extension
+func FormatFromFilename(name string) (Format, error) {
+	return ParseFormat(strings.TrimPrefix(filepath.Ext(name), "."))
+}
+
+// Options configures an import
+type Options struct {
+	Format Format
+	// DryRun validates every row without writing anything
+	DryRun bool
+	// BatchSize is the number of posts written at a time, DefaultBatchSize when zero
+	BatchSize int
+	// MaxFileBytes is the largest uncompressed file of a Markdown archive,
+	// DefaultMaxFileBytes when zero. Larger files are rejected rows.
+	MaxFileBytes int64
+	// Columns maps CSV header names to post fields for files whose headers
+	// don't already use the field names (title, content, author, tags, status,
+	// language, translation_group, created_at, updated_at)
+	Columns map[string]string
+	// Translator translates the validation messages of rejected rows, into
+	// the default locale when nil
+	Translator *i18n.Translator
+}
+
+// Store is the subset of repository.PostStore used for importing
+type Store interface {
+	CreateMany(ctx context.Context, posts []models.Post) ([]string, error)
+	ExistingSourceGUIDs(ctx context.Context, guids []string) (map[string]bool, error)
+}
+
+// RowError describes why a row was rejected. Row numbers start at 1 and
+// count data rows, not the CSV header.
+type RowError struct {
+	Row     int
+	Field   string
+	Message string
+}
+
+// SkippedRow describes a row that was deliberately not imported, such as a
+// duplicate of an already imported post or a WordPress page
+type SkippedRow struct {
+	Row    int
+	Title  string
+	Reason string
+}
+
+// Report summarizes an import
+type Report struct {
+	DryRun      bool
+	Total       int
+	Imported    int
+	Failed      int
+	Errors      []RowError
+	Skipped     int
+	SkippedRows []SkippedRow
+}
+
+// skip records a skipped row
+func (r *Report) skip(row SkippedRow) {
+	r.Skipped++
+	r.SkippedRows = append(r.SkippedRows, row)
+}
+
+// rowReader yields posts one row at a time. It returns io.EOF when the input
+// is exhausted, a *RowError for rows that cannot be decoded and a *SkippedRow
+// for rows that should be left out.
+type rowReader interface {
+	Next() (models.Post, error)
+}
+
+// Import streams posts from r, validates each row and writes valid rows to
+// store in batches. Invalid rows are recorded in the report and skipped, as
+// are rows whose source GUID has already been imported. Imported posts get
+// new IDs, so each translation group of the file becomes a new group shared
+// by its imported posts.
+func Import(ctx context.Context, store Store, r io.Reader, opts Options) (Report, error) {
+	report := Report{DryRun: opts.DryRun}
+
+	rows, err := newRowReader(r, opts)
+	if err != nil {
+		return report, err
+	}
+	if closer, ok := rows.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	batch := make([]models.Post, 0, batchSize)
+	batchRows := make([]int, 0, batchSize)
+	seenGUIDs := make(map[string]bool)
+	groups := make(map[string]string)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		posts, err := dropImported(ctx, store, batch, batchRows, &report)
+		if err != nil {
+			return err
+		}
+
+		if !opts.DryRun && len(posts) > 0 {
+			if _, err := store.CreateMany(ctx, posts); err != nil {
+				return fmt.Errorf("failed to write rows %d-%d: %w", batchRows[0], batchRows[len(batchRows)-1], err)
+			}
+		}
+		report.Imported += len(posts)
+		batch = batch[:0]
+		batchRows = batchRows[:0]
+		return nil
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		post, err := rows.Next()
+		if err == io.EOF {
+			break
+		}
+		report.Total++
+
+		if err != nil {
+			switch rowErr := err.(type) {
+			case *RowError:
+				rowErr.Row = report.Total
+				report.Failed++
+				report.Errors = append(report.Errors, *rowErr)
+				continue
+			case *SkippedRow:
+				rowErr.Row = report.Total
+				report.skip(*rowErr)
+				continue
+			default:
+				return report, err
+			}
+		}
+
+		if rowErrors := validateRow(report.Total, post, opts.Translator); len(rowErrors) > 0 {
+			report.Failed++
+			report.Errors = append(report.Errors, rowErrors...)
+			continue
+		}
+
+		if post.SourceGUID != "" {
+			if seenGUIDs[post.SourceGUID] {
+				report.skip(SkippedRow{Row: report.Total, Title: post.Title, Reason: "duplicate of an earlier row"})
+				continue
+			}
+			seenGUIDs[post.SourceGUID] = true
+		}
+
+		if post.TranslationGroup != "" {
+			group, ok := groups[post.TranslationGroup]
+			if !ok {
+				group = primitive.NewObjectID().Hex()
+				groups[post.TranslationGroup] = group
+			}
+			post.TranslationGroup = group
+		}
+
+		batch = append(batch, post)
+		batchRows = append(batchRows, report.Total)
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return report, err
+			}
+		}
+	}
+
+	err = flush()
+	sort.SliceStable(report.SkippedRows, func(i, j int) bool {
+		return report.SkippedRows[i].Row < report.SkippedRows[j].Row
+	})
+	return report, err
+}
+
+// dropImported removes posts whose source GUID already exists in the store,
+// recording them as skipped
+func dropImported(ctx context.Context, store Store, batch []models.Post, rows []int, report *Report) ([]models.Post, error) {
+	var guids []string
+	for _, post := range batch {
+		if post.SourceGUID != "" {
+			guids = append(guids, post.SourceGUID)
+		}
+	}
+	if len(guids) == 0 {
+		return batch, nil
+	}
+
+	existing, err := store.ExistingSourceGUIDs(ctx, guids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for previously imported rows: %w", err)
+	}
+
+	posts := make([]models.Post, 0, len(batch))
+	for i, post := range batch {
+		if existing[post.SourceGUID] {
+			report.skip(SkippedRow{Row: rows[i], Title: post.Title, Reason: "already imported"})
+			continue
+		}
+		posts = append(posts, post)
+	}
+
+	return posts, nil
+}
+
+// Error implements the error interface
+func (e *SkippedRow) Error() string {
+	return fmt.Sprintf("row %d skipped: %s", e.Row, e.Reason)
+}
+
+// Error implements the error interface
+func (e *RowError) Error() string {
+	if e.Field != "" {
+		return fmt.Sprintf("row %d: %s: %s", e.Row, e.Field, e.Message)
+	}
+	return fmt.Sprintf("row %d: %s", e.Row, e.Message)
+}
+
+// newRowReader creates the row reader for the configured format
+func newRowReader(r io.Reader, opts Options) (rowReader, error) {
+	switch opts.Format {
+	case FormatJSONL:
+		return newJSONLReader(r), nil
+	case FormatCSV:
+		return newCSVReader(r, opts.Columns)
+	case FormatMarkdown:
+		maxFileBytes := opts.MaxFileBytes
+		if maxFileBytes <= 0 {
+			maxFileBytes = DefaultMaxFileBytes
+		}
+		return newMarkdownReader(r, maxFileBytes)
+	case FormatWXR:
+		return newWXRReader(r), nil
+	default:
+		return nil, fmt.Errorf("unsupported import format %q", opts.Format)
+	}
+}
+
+// validateRow runs the post validation rules and converts failures into row errors
+func validateRow(row int, post models.Post, tr *i18n.Translator) []RowError {
+	postErrors, valid := validation.ValidatePost(post, tr)
+	if valid {
+		return nil
+	}
+
+	var rowErrors []RowError
+	if postErrors.Title != "" {
+		rowErrors = append(rowErrors, RowError{Row: row, Field: "title", Message: postErrors.Title})
+	}
+	if postErrors.Content != "" {
+		rowErrors = append(rowErrors, RowError{Row: row, Field: "content", Message: postErrors.Content})
+	}
+	if postErrors.Status != "" {
+		rowErrors = append(rowErrors, RowError{Row: row, Field: "status", Message: postErrors.Status})
+	}
+	return rowErrors
+}
@@ -0,0 +1,215 @@
+//go:build unit
+
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gekich/news-app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore struct {
+	posts   []models.Post
+	batches int
+	fail    bool
+}
+
+func (s *memoryStore) CreateMany(ctx context.Context, posts []models.Post) ([]string, error) {
+	if s.fail {
+		return nil, fmt.Errorf("store unavailable")
+	}
+	s.batches++
+	ids := make([]string, len(posts))
+	for i := range posts {
+		ids[i] = fmt.Sprintf("%d", len(s.posts)+i+1)
+	}
+	s.posts = append(s.posts, posts...)
+	return ids, nil
+}
+
+func (s *memoryStore) ExistingSourceGUIDs(ctx context.Context, guids []string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	for _, guid := range guids {
+		for _, post := range s.posts {
+			if post.SourceGUID == guid {
+				existing[guid] = true
+			}
+		}
+	}
+	return existing, nil
+}
+
+const jsonlInput = `{"title":"First imported post","content":"This content is long enough.","author":"Alice","tags":["news"],"created_at":"2020-05-01T10:00:00Z"}
+
+{"title":"No","content":"This content is long enough."}
+{not json}
+{"id":"5f1d7f0c8e3a4b2a9c0d1e2f","title":"Second imported post","content":"Also long enough content.","created_at":"2020-05-02T10:00:00Z","updated_at":"2020-06-01T10:00:00Z","version":7,"previous_slugs":["second-post"],"attachments":[{"id":"5f1d7f0c8e3a4b2a9c0d1e30","filename":"photo.png","content_type":"image/png","size":5}],"featured_image":"5f1d7f0c8e3a4b2a9c0d1e30"}
+`
+
+func TestImport_JSONL(t *testing.T) {
+	store := &memoryStore{}
+	report, err := Import(context.Background(), store, strings.NewReader(jsonlInput), Options{Format: FormatJSONL})
+	require.NoError(t, err)
+
+	assert.Equal(t, 4, report.Total)
+	assert.Equal(t, 2, report.Imported)
+	assert.Equal(t, 2, report.Failed)
+	require.Len(t, report.Errors, 2)
+	assert.Equal(t, RowError{Row: 2, Field: "title", Message: "This field must be at least 3 characters long"}, report.Errors[0])
+	assert.Equal(t, 3, report.Errors[1].Row)
+	assert.Contains(t, report.Errors[1].Message, "invalid JSON")
+
+	require.Len(t, store.posts, 2)
+	assert.Equal(t, time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC), store.posts[0].CreatedAt)
+	assert.Equal(t, []string{"news"}, store.posts[0].Tags)
+	assert.True(t, store.posts[1].ID.IsZero(), "imported IDs are discarded")
+	assert.Equal(t, time.Date(2020, 6, 1, 10, 0, 0, 0, time.UTC), store.posts[1].UpdatedAt)
+	assert.Zero(t, store.posts[1].Version, "imported posts start a new history")
+	assert.Empty(t, store.posts[1].PreviousSlugs)
+	assert.Empty(t, store.posts[1].Attachments, "the files aren't exported")
+	assert.Empty(t, store.posts[1].FeaturedImage)
+}
+
+func TestImport_CSV(t *testing.T) {
+	input := "headline,body,author,tags,created_at,extra\n" +
+		"CSV post one,Content of the first CSV post,Ben,local|culture,2021-03-04,ignored\n" +
+		"CSV post two,Content of the second CSV post,,,2021-03-05 08:30:00,ignored\n" +
+		"Bad date,Content with a bad timestamp,,,yesterday,ignored\n"
+
+	store := &memoryStore{}
+	report, err := Import(context.Background(), store, strings.NewReader(input), Options{
+		Format:  FormatCSV,
+		Columns: map[string]string{"headline": "title", "body": "content"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, report.Total)
+	assert.Equal(t, 2, report.Imported)
+	require.Len(t, report.Errors, 1)
+	assert.Equal(t, 3, report.Errors[0].Row)
+	assert.Equal(t, "created_at", report.Errors[0].Field)
+
+	require.Len(t, store.posts, 2)
+	assert.Equal(t, []string{"local", "culture"}, store.posts[0].Tags)
+	assert.Equal(t, time.Date(2021, 3, 5, 8, 30, 0, 0, time.UTC), store.posts[1].CreatedAt)
+}
+
+func TestImport_MarkdownFileTooLarge(t *testing.T) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"small.md": "---\ntitle: Small post\ncreated_at: \"\"\nupdated_at: \"\"\n---\nShort but long enough content.\n",
+		"bomb.md":  "---\ntitle: Bomb\n---\n" + strings.Repeat("a", 10<<10),
+	} {
+		w, err := archive.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, archive.Close())
+
+	store := &memoryStore{}
+	report, err := Import(context.Background(), store, bytes.NewReader(buf.Bytes()), Options{Format: FormatMarkdown, MaxFileBytes: 1 << 10})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, report.Imported)
+	require.Len(t, report.Errors, 1)
+	assert.Contains(t, report.Errors[0].Message, "bomb.md: file is larger than 1024 bytes")
+}
+
+func TestMarkdownReader_ForgedSize(t *testing.T) {
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	w, err := archive.Create("bomb.md")
+	require.NoError(t, err)
+	_, err = w.Write([]byte(strings.Repeat("a", 10<<10)))
+	require.NoError(t, err)
+	require.NoError(t, archive.Close())
+
+	reader, err := newMarkdownReader(bytes.NewReader(buf.Bytes()), 1<<10)
+	require.NoError(t, err)
+	// The header claims a file small enough to be read, but no more than
+	// the limit is ever read from it
+	reader.files[0].UncompressedSize64 = 1 << 10
+
+	_, err = reader.Next()
+	var rowErr *RowError
+	require.ErrorAs(t, err, &rowErr, "the file is a rejected row")
+	assert.Contains(t, rowErr.Message, "bomb.md: ")
+}
+
+func TestImport_CSVRequiresTitleAndContent(t *testing.T) {
+	_, err := Import(context.Background(), &memoryStore{}, strings.NewReader("name,body\n"), Options{Format: FormatCSV})
+	assert.Error(t, err)
+}
+
+func TestImport_DryRun(t *testing.T) {
+	store := &memoryStore{}
+	report, err := Import(context.Background(), store, strings.NewReader(jsonlInput), Options{Format: FormatJSONL, DryRun: true})
+	require.NoError(t, err)
+
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 2, report.Imported)
+	assert.Empty(t, store.posts)
+}
+
+func TestImport_Batches(t *testing.T) {
+	var input strings.Builder
+	for i := 0; i < 25; i++ {
+		fmt.Fprintf(&input, `{"title":"Post number %d","content":"Generated content for batching."}`+"\n", i)
+	}
+
+	store := &memoryStore{}
+	report, err := Import(context.Background(), store, strings.NewReader(input.String()), Options{Format: FormatJSONL, BatchSize: 10})
+	require.NoError(t, err)
+
+	assert.Equal(t, 25, report.Imported)
+	assert.Equal(t, 3, store.batches)
+}
+
+func TestImport_StoreFailure(t *testing.T) {
+	store := &memoryStore{fail: true}
+	_, err := Import(context.Background(), store, strings.NewReader(jsonlInput), Options{Format: FormatJSONL})
+	assert.Error(t, err)
+}
+
+func TestParseColumns(t *testing.T) {
+	columns, err := ParseColumns("headline=title, body = content")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"headline": "title", "body": "content"}, columns)
+
+	_, err = ParseColumns("headline")
+	assert.Error(t, err)
+}
+
+func TestFormatFromFilename(t *testing.T) {
+	format, err := FormatFromFilename("archive.ndjson")
+	require.NoError(t, err)
+	assert.Equal(t, FormatJSONL, format)
+
+	format, err = FormatFromFilename("archive.CSV")
+	require.NoError(t, err)
+	assert.Equal(t, FormatCSV, format)
+
+	format, err = FormatFromFilename("wordpress.2024-01-01.xml")
+	require.NoError(t, err)
+	assert.Equal(t, FormatWXR, format)
+
+	_, err = FormatFromFilename("archive.txt")
+	assert.Error(t, err)
+}
+
+func TestWriteErrorsCSV(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteErrorsCSV(&buf, Report{Errors: []RowError{{Row: 2, Field: "title", Message: "required"}}})
+	require.NoError(t, err)
+	assert.Equal(t, "row,field,message\n2,title,required\n", buf.String())
+}
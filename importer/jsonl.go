@@ -0,0 +1,55 @@
+package importer
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/gekich/news-app/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxLineSize bounds the length of a single JSON Lines record
+const maxLineSize = 16 * 1024 * 1024
+
+// jsonlReader reads one JSON encoded post per line, skipping blank lines
+type jsonlReader struct {
+	scanner *bufio.Scanner
+}
+
+func newJSONLReader(r io.Reader) *jsonlReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	return &jsonlReader{scanner: scanner}
+}
+
+// Next decodes the next non-blank line
+func (r *jsonlReader) Next() (models.Post, error) {
+	for r.scanner.Scan() {
+		line := strings.TrimSpace(r.scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var post models.Post
+		if err := json.Unmarshal([]byte(line), &post); err != nil {
+			return post, &RowError{Message: "invalid JSON: " + err.Error()}
+		}
+
+		// IDs are always assigned by the store so re-importing an export creates new posts
+		post.ID = primitive.NilObjectID
+		// The new posts start their own history, and the files of the
+		// exported posts aren't part of the export
+		post.Version = 0
+		post.PreviousSlugs = nil
+		post.Attachments = nil
+		post.FeaturedImage = ""
+		return post, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return models.Post{}, err
+	}
+	return models.Post{}, io.EOF
+}
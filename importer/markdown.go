@@ -0,0 +1,182 @@
+package importer
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/gekich/news-app/models"
+	"gopkg.in/yaml.v3"
+)
+
+// Frontmatter is the YAML header of a Markdown post file
+type Frontmatter struct {
+	ID               string   `yaml:"id,omitempty"`
+	Title            string   `yaml:"title"`
+	Slug             string   `yaml:"slug,omitempty"`
+	Author           string   `yaml:"author,omitempty"`
+	Tags             []string `yaml:"tags,omitempty"`
+	Status           string   `yaml:"status,omitempty"`
+	Language         string   `yaml:"language,omitempty"`
+	TranslationGroup string   `yaml:"translation_group,omitempty"`
+	CreatedAt        string   `yaml:"created_at"`
+	UpdatedAt        string   `yaml:"updated_at"`
+}
+
+const frontmatterDelimiter = "---\n"
+
+// markdownReader reads one post from every .md file in a zip archive
+type markdownReader struct {
+	files []*zip.File
+	next  int
+	// maxBytes bounds the uncompressed size of a file, so a small archive
+	// can't expand into more than memory holds
+	maxBytes int64
+	cleanup  func()
+}
+
+func newMarkdownReader(r io.Reader, maxBytes int64) (*markdownReader, error) {
+	archive, cleanup, err := openZip(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*zip.File
+	for _, file := range archive.File {
+		if !file.FileInfo().IsDir() && strings.EqualFold(path.Ext(file.Name), ".md") {
+			files = append(files, file)
+		}
+	}
+
+	return &markdownReader{files: files, maxBytes: maxBytes, cleanup: cleanup}, nil
+}
+
+// Next parses the next Markdown file in the archive
+func (r *markdownReader) Next() (models.Post, error) {
+	if r.next >= len(r.files) {
+		return models.Post{}, io.EOF
+	}
+	file := r.files[r.next]
+	r.next++
+
+	tooLarge := &RowError{Message: fmt.Sprintf("%s: file is larger than %d bytes", file.Name, r.maxBytes)}
+	if file.UncompressedSize64 > uint64(r.maxBytes) {
+		return models.Post{}, tooLarge
+	}
+
+	rc, err := file.Open()
+	if err != nil {
+		return models.Post{}, &RowError{Message: fmt.Sprintf("%s: %v", file.Name, err)}
+	}
+	defer rc.Close()
+
+	// The size in the header is whatever the archive claims
+	data, err := io.ReadAll(io.LimitReader(rc, r.maxBytes+1))
+	if err != nil {
+		return models.Post{}, &RowError{Message: fmt.Sprintf("%s: %v", file.Name, err)}
+	}
+	if int64(len(data)) > r.maxBytes {
+		return models.Post{}, tooLarge
+	}
+
+	post, err := ParseMarkdown(bytes.NewReader(data))
+	if err != nil {
+		return post, &RowError{Message: fmt.Sprintf("%s: %v", file.Name, err)}
+	}
+	return post, nil
+}
+
+// Close removes the temporary copy of the archive, if one was made
+func (r *markdownReader) Close() error {
+	r.cleanup()
+	return nil
+}
+
+// ParseMarkdown reads a post from YAML frontmatter followed by its content
+func ParseMarkdown(r io.Reader) (models.Post, error) {
+	var post models.Post
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return post, err
+	}
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+
+	if !bytes.HasPrefix(data, []byte(frontmatterDelimiter)) {
+		return post, fmt.Errorf("missing frontmatter")
+	}
+	header, body, found := bytes.Cut(data[len(frontmatterDelimiter):], []byte("\n"+frontmatterDelimiter))
+	if !found {
+		return post, fmt.Errorf("unterminated frontmatter")
+	}
+
+	var frontmatter Frontmatter
+	if err := yaml.Unmarshal(header, &frontmatter); err != nil {
+		return post, fmt.Errorf("invalid frontmatter: %w", err)
+	}
+
+	post.Title = frontmatter.Title
+	post.Slug = frontmatter.Slug
+	post.Author = frontmatter.Author
+	post.Tags = frontmatter.Tags
+	post.Status = frontmatter.Status
+	post.Language = frontmatter.Language
+	post.TranslationGroup = frontmatter.TranslationGroup
+	// Files end with a newline that is not part of the content
+	post.Content = strings.TrimSuffix(string(body), "\n")
+
+	if post.CreatedAt, err = parseTime(frontmatter.CreatedAt); err != nil {
+		return post, err
+	}
+	if post.UpdatedAt, err = parseTime(frontmatter.UpdatedAt); err != nil {
+		return post, err
+	}
+
+	return post, nil
+}
+
+// openZip opens r as a zip archive. Archives need random access, so readers
+// that don't provide it are first copied to a temporary file.
+func openZip(r io.Reader) (*zip.Reader, func(), error) {
+	if file, ok := r.(interface {
+		io.ReaderAt
+		io.Seeker
+	}); ok {
+		size, err := file.Seek(0, io.SeekEnd)
+		if err != nil {
+			return nil, nil, err
+		}
+		archive, err := zip.NewReader(file, size)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid zip archive: %w", err)
+		}
+		return archive, func() {}, nil
+	}
+
+	tmp, err := os.CreateTemp("", "news-import-*.zip")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}
+
+	size, err := io.Copy(tmp, r)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	archive, err := zip.NewReader(tmp, size)
+	if err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("invalid zip archive: %w", err)
+	}
+
+	return archive, cleanup, nil
+}
@@ -0,0 +1,24 @@
+package importer
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// WriteErrorsCSV writes the per-row errors of a report as CSV with a row, field and message column
+func WriteErrorsCSV(w io.Writer, report Report) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"row", "field", "message"}); err != nil {
+		return err
+	}
+
+	for _, rowErr := range report.Errors {
+		if err := writer.Write([]string{strconv.Itoa(rowErr.Row), rowErr.Field, rowErr.Message}); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
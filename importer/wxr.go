@@ -0,0 +1,205 @@
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gekich/news-app/models"
+)
+
+// wxrDateLayout is the format of wp:post_date and wp:post_date_gmt
+const wxrDateLayout = "2006-01-02 15:04:05"
+
+// wxrItem is a single <item> of a WXR channel. WordPress versions use
+// different namespace URIs for the wp: prefix, so those fields are matched by
+// local name only.
+type wxrItem struct {
+	Title       string        `xml:"title"`
+	GUID        string        `xml:"guid"`
+	Link        string        `xml:"link"`
+	Creator     string        `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Content     string        `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	PostDate    string        `xml:"post_date"`
+	PostDateGMT string        `xml:"post_date_gmt"`
+	Modified    string        `xml:"post_modified"`
+	ModifiedGMT string        `xml:"post_modified_gmt"`
+	Status      string        `xml:"status"`
+	PostType    string        `xml:"post_type"`
+	Categories  []wxrCategory `xml:"category"`
+}
+
+// wxrCategory is a category or tag assigned to an item
+type wxrCategory struct {
+	Domain   string `xml:"domain,attr"`
+	Nicename string `xml:"nicename,attr"`
+	Name     string `xml:",chardata"`
+}
+
+// wxrAuthor is a wp:author entry of the channel
+type wxrAuthor struct {
+	Login       string `xml:"author_login"`
+	DisplayName string `xml:"author_display_name"`
+}
+
+// wxrReader streams posts out of a WordPress export. Authors are declared at
+// the top of the channel, before any item, so their display names are known
+// by the time posts are read.
+type wxrReader struct {
+	decoder *xml.Decoder
+	authors map[string]string
+}
+
+func newWXRReader(r io.Reader) *wxrReader {
+	decoder := xml.NewDecoder(r)
+	// WordPress declares UTF-8, but older exports occasionally claim other
+	// charsets; read them as-is rather than failing the whole file
+	decoder.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) {
+		return input, nil
+	}
+	decoder.Strict = false
+	return &wxrReader{decoder: decoder, authors: make(map[string]string)}
+}
+
+// Next decodes the next item of the channel
+func (r *wxrReader) Next() (models.Post, error) {
+	for {
+		token, err := r.decoder.Token()
+		if err == io.EOF {
+			return models.Post{}, io.EOF
+		}
+		if err != nil {
+			return models.Post{}, fmt.Errorf("invalid WXR file: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "author":
+			var author wxrAuthor
+			if err := r.decoder.DecodeElement(&author, &start); err != nil {
+				return models.Post{}, fmt.Errorf("invalid WXR author: %w", err)
+			}
+			if author.Login != "" && author.DisplayName != "" {
+				r.authors[author.Login] = author.DisplayName
+			}
+		case "item":
+			var item wxrItem
+			if err := r.decoder.DecodeElement(&item, &start); err != nil {
+				return models.Post{}, fmt.Errorf("invalid WXR item: %w", err)
+			}
+			return r.post(item)
+		}
+	}
+}
+
+// post maps a WordPress item to a post
+func (r *wxrReader) post(item wxrItem) (models.Post, error) {
+	title := strings.TrimSpace(item.Title)
+
+	postType := strings.TrimSpace(item.PostType)
+	if postType != "" && postType != "post" {
+		return models.Post{}, &SkippedRow{Title: title, Reason: fmt.Sprintf("%s is not a post", postType)}
+	}
+
+	status, ok := wxrStatus(item.Status)
+	if !ok {
+		return models.Post{}, &SkippedRow{Title: title, Reason: fmt.Sprintf("status %q is not imported", item.Status)}
+	}
+
+	post := models.Post{
+		Title:      title,
+		Content:    HTMLToMarkdown(item.Content),
+		Author:     r.author(strings.TrimSpace(item.Creator)),
+		Tags:       wxrTags(item.Categories),
+		Status:     status,
+		SourceGUID: wxrGUID(item),
+	}
+
+	var err error
+	if post.CreatedAt, err = wxrDate(item.PostDateGMT, item.PostDate); err != nil {
+		return post, &RowError{Field: "created_at", Message: err.Error()}
+	}
+	if post.UpdatedAt, err = wxrDate(item.ModifiedGMT, item.Modified); err != nil {
+		return post, &RowError{Field: "updated_at", Message: err.Error()}
+	}
+	if post.UpdatedAt.IsZero() || post.UpdatedAt.Before(post.CreatedAt) {
+		post.UpdatedAt = post.CreatedAt
+	}
+
+	return post, nil
+}
+
+// author resolves a login to the display name declared in the channel
+func (r *wxrReader) author(login string) string {
+	if name, ok := r.authors[login]; ok {
+		return name
+	}
+	return login
+}
+
+// wxrStatus maps a WordPress post status. Trashed posts and auto-drafts are
+// not imported; every other unpublished status becomes a draft.
+func wxrStatus(status string) (string, bool) {
+	switch strings.TrimSpace(status) {
+	case "publish", "":
+		return models.StatusPublished, true
+	case "trash", "auto-draft", "inherit":
+		return "", false
+	default:
+		return models.StatusDraft, true
+	}
+}
+
+// wxrTags collects category and tag names, skipping the default category
+func wxrTags(categories []wxrCategory) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, category := range categories {
+		if category.Domain != "category" && category.Domain != "post_tag" {
+			continue
+		}
+		if category.Nicename == "uncategorized" {
+			continue
+		}
+		name := strings.TrimSpace(category.Name)
+		key := strings.ToLower(name)
+		if name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		tags = append(tags, name)
+	}
+	return tags
+}
+
+// wxrGUID identifies an item across exports. The GUID is preferred; the
+// permalink is used for exports that omit it.
+func wxrGUID(item wxrItem) string {
+	if guid := strings.TrimSpace(item.GUID); guid != "" {
+		return guid
+	}
+	return strings.TrimSpace(item.Link)
+}
+
+// wxrDate parses the GMT date, falling back to the site-local date for
+// unpublished posts, which WordPress exports with a zero GMT date
+func wxrDate(gmt, local string) (time.Time, error) {
+	for _, value := range []string{gmt, local} {
+		value = strings.TrimSpace(value)
+		if value == "" || strings.HasPrefix(value, "0000-00-00") {
+			continue
+		}
+		t, err := time.Parse(wxrDateLayout, value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid date %q", value)
+		}
+		return t.UTC(), nil
+	}
+	return time.Time{}, nil
+}
@@ -0,0 +1,90 @@
+//go:build unit
+
+package importer
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gekich/news-app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func importWXR(t *testing.T, store *memoryStore) Report {
+	t.Helper()
+
+	file, err := os.Open("../testdata/wordpress/export.xml")
+	require.NoError(t, err)
+	defer file.Close()
+
+	report, err := Import(context.Background(), store, file, Options{Format: FormatWXR})
+	require.NoError(t, err)
+	return report
+}
+
+func TestImport_WXR(t *testing.T) {
+	store := &memoryStore{}
+	report := importWXR(t, store)
+
+	assert.Equal(t, 4, report.Total)
+	assert.Equal(t, 2, report.Imported)
+	assert.Equal(t, 2, report.Skipped)
+	assert.Empty(t, report.Errors)
+	require.Len(t, report.SkippedRows, 2)
+	assert.Equal(t, SkippedRow{Row: 3, Title: "About us", Reason: "page is not a post"}, report.SkippedRows[0])
+	assert.Equal(t, 4, report.SkippedRows[1].Row)
+
+	require.Len(t, store.posts, 2)
+	published := store.posts[0]
+	assert.Equal(t, "Council approves new bike lanes", published.Title)
+	assert.Equal(t, "The city council voted on **Tuesday** to approve the plan.\n\nConstruction starts in [spring](https://gazette.example.com/schedule).", published.Content)
+	assert.Equal(t, "Alice Example", published.Author)
+	assert.Equal(t, []string{"Local", "Cycling"}, published.Tags)
+	assert.Equal(t, models.StatusPublished, published.Status)
+	assert.Equal(t, "https://gazette.example.com/?p=101", published.SourceGUID)
+	assert.Equal(t, time.Date(2024, 3, 5, 9, 30, 0, 0, time.UTC), published.CreatedAt)
+	assert.Equal(t, time.Date(2024, 3, 6, 11, 0, 0, 0, time.UTC), published.UpdatedAt)
+
+	draft := store.posts[1]
+	assert.Equal(t, models.StatusDraft, draft.Status)
+	assert.Equal(t, "bob", draft.Author)
+	assert.Equal(t, "The library will extend its opening hours.\n\n- Monday to Friday\n- Saturday mornings", draft.Content)
+	assert.Equal(t, time.Date(2024, 3, 7, 8, 0, 0, 0, time.UTC), draft.CreatedAt)
+}
+
+func TestImport_WXRSkipsPreviouslyImported(t *testing.T) {
+	store := &memoryStore{}
+	importWXR(t, store)
+
+	report := importWXR(t, store)
+	assert.Equal(t, 0, report.Imported)
+	assert.Equal(t, 4, report.Skipped)
+	assert.Equal(t, "already imported", report.SkippedRows[0].Reason)
+	assert.Len(t, store.posts, 2)
+}
+
+func TestHTMLToMarkdown(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{"plain text paragraphs", "First line\n\nSecond line", "First line\n\nSecond line"},
+		{"headings and emphasis", "<h2>Title</h2><p>Some <em>soft</em> and <b>bold</b> text</p>", "## Title\n\nSome *soft* and **bold** text"},
+		{"ordered list", "<ol><li>One</li><li>Two</li></ol>", "1. One\n2. Two"},
+		{"blockquote", "<blockquote><p>Quoted</p></blockquote>", "> Quoted"},
+		{"code block", "<pre><code>x := 1\n\ny := 2</code></pre>", "```\nx := 1\n\ny := 2\n```"},
+		{"image", `<p><img src="/a.png" alt="A chart"></p>`, "![A chart](/a.png)"},
+		{"block comments and scripts", "<!-- wp:html --><script>alert(1)</script><p>Safe</p>", "Safe"},
+		{"empty", "  ", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, HTMLToMarkdown(tt.html))
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// bucketName names the GridFS collections, media.files and media.chunks
+const bucketName = "media"
+
+// GridFSStore keeps files in a GridFS bucket of the database, with their
+// content type in the metadata of the file
+type GridFSStore struct {
+	bucket *gridfs.Bucket
+}
+
+var _ Store = (*GridFSStore)(nil)
+
+// gridFSFile is a document of the files collection
+type gridFSFile struct {
+	ID         primitive.ObjectID `bson:"_id"`
+	Length     int64              `bson:"length"`
+	UploadDate time.Time          `bson:"uploadDate"`
+	Filename   string             `bson:"filename"`
+	Metadata   struct {
+		ContentType string `bson:"content_type"`
+	} `bson:"metadata"`
+}
+
+func (f gridFSFile) info() Info {
+	return Info{
+		ID:          f.ID.Hex(),
+		Filename:    f.Filename,
+		ContentType: f.Metadata.ContentType,
+		Size:        f.Length,
+		UploadedAt:  f.UploadDate,
+	}
+}
+
+// NewGridFSStore returns a store in the media bucket of database
+func NewGridFSStore(database *mongo.Database) (*GridFSStore, error) {
+	if database == nil {
+		return nil, errors.New("GridFS media store needs a database")
+	}
+	bucket, err := gridfs.NewBucket(database, options.GridFSBucket().SetName(bucketName))
+	if err != nil {
+		return nil, err
+	}
+	return &GridFSStore{bucket: bucket}, nil
+}
+
+func (s *GridFSStore) Save(ctx context.Context, filename, contentType string, r io.Reader) (Info, error) {
+	opts := options.GridFSUpload().SetMetadata(bson.M{"content_type": contentType})
+	stream, err := s.bucket.OpenUploadStream(filename, opts)
+	if err != nil {
+		return Info{}, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		stream.SetWriteDeadline(deadline)
+	}
+
+	size, err := io.Copy(stream, r)
+	if err != nil {
+		stream.Abort()
+		return Info{}, err
+	}
+	if err := stream.Close(); err != nil {
+		return Info{}, err
+	}
+
+	id, ok := stream.FileID.(primitive.ObjectID)
+	if !ok {
+		return Info{}, fmt.Errorf("unexpected GridFS file ID %v", stream.FileID)
+	}
+	return Info{
+		ID:          id.Hex(),
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        size,
+		UploadedAt:  id.Timestamp().UTC(),
+	}, nil
+}
+
+func (s *GridFSStore) Open(ctx context.Context, id string) (File, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	cursor, err := s.bucket.FindContext(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+	if !cursor.Next(ctx) {
+		if err := cursor.Err(); err != nil {
+			return nil, err
+		}
+		return nil, ErrNotFound
+	}
+
+	var doc gridFSFile
+	if err := cursor.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return &gridFSReader{bucket: s.bucket, id: objectID, info: doc.info()}, nil
+}
+
+func (s *GridFSStore) Delete(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil
+	}
+
+	err = s.bucket.DeleteContext(ctx, objectID)
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (s *GridFSStore) Walk(ctx context.Context, fn func(Info) error) error {
+	cursor, err := s.bucket.FindContext(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc gridFSFile
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+		if err := fn(doc.info()); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// gridFSReader reads a GridFS file. GridFS download streams only read
+// forward, so seeking back opens a new stream on the next read, and
+// seeking ahead skips to the offset.
+type gridFSReader struct {
+	bucket *gridfs.Bucket
+	id     primitive.ObjectID
+	info   Info
+
+	stream *gridfs.DownloadStream
+	// offset is where the next read starts, position is where the stream is
+	offset, position int64
+}
+
+func (f *gridFSReader) Info() Info { return f.info }
+
+func (f *gridFSReader) Read(p []byte) (int, error) {
+	if f.offset >= f.info.Size {
+		return 0, io.EOF
+	}
+
+	if f.stream == nil || f.offset < f.position {
+		if f.stream != nil {
+			f.stream.Close()
+		}
+		stream, err := f.bucket.OpenDownloadStream(f.id)
+		if err != nil {
+			f.stream = nil
+			return 0, err
+		}
+		f.stream, f.position = stream, 0
+	}
+	if f.offset > f.position {
+		skipped, err := f.stream.Skip(f.offset - f.position)
+		f.position += skipped
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.stream.Read(p)
+	f.offset += int64(n)
+	f.position += int64(n)
+	return n, err
+}
+
+func (f *gridFSReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.info.Size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *gridFSReader) Close() error {
+	if f.stream == nil {
+		return nil
+	}
+	return f.stream.Close()
+}
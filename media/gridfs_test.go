@@ -0,0 +1,131 @@
+//go:build integration
+
+package media
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/ory/dockertest/v3/docker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var mongoDB *mongo.Database
+
+func TestMain(m *testing.M) {
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		log.Fatalf("Could not connect to docker: %s", err)
+	}
+
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: "mongo",
+		Tag:        "4.4",
+		Env: []string{
+			"MONGO_INITDB_DATABASE=test_db",
+		},
+	}, func(config *docker.HostConfig) {
+		config.AutoRemove = true
+		config.RestartPolicy = docker.RestartPolicy{
+			Name: "no",
+		}
+	})
+	if err != nil {
+		log.Fatalf("Could not start resource: %s", err)
+	}
+
+	var client *mongo.Client
+	if err := pool.Retry(func() error {
+		var err error
+		mongoURI := fmt.Sprintf("mongodb://localhost:%s", resource.GetPort("27017/tcp"))
+
+		client, err = mongo.Connect(context.Background(), options.Client().ApplyURI(mongoURI))
+		if err != nil {
+			return err
+		}
+		return client.Ping(context.Background(), nil)
+	}); err != nil {
+		log.Fatalf("Could not connect to docker: %s", err)
+	}
+	mongoDB = client.Database("test_db")
+
+	code := m.Run()
+
+	if err := client.Disconnect(context.Background()); err != nil {
+		log.Fatalf("Could not disconnect from MongoDB: %s", err)
+	}
+	if err := pool.Purge(resource); err != nil {
+		log.Fatalf("Could not purge resource: %s", err)
+	}
+
+	os.Exit(code)
+}
+
+func TestGridFSStore(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	store, err := NewGridFSStore(mongoDB)
+	require.NoError(t, err)
+
+	// Larger than a chunk, so seeking crosses chunk boundaries
+	content := bytes.Repeat([]byte("0123456789"), 50000)
+	info, err := store.Save(ctx, "digits.txt", "text/plain", bytes.NewReader(content))
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(content)), info.Size)
+
+	t.Run("open and seek", func(t *testing.T) {
+		f, err := store.Open(ctx, info.ID)
+		require.NoError(t, err)
+		defer f.Close()
+
+		assert.Equal(t, "digits.txt", f.Info().Filename)
+		assert.Equal(t, "text/plain", f.Info().ContentType)
+
+		size, err := f.Seek(0, io.SeekEnd)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(content)), size)
+
+		for _, offset := range []int64{300000, 5, 299995} {
+			_, err := f.Seek(offset, io.SeekStart)
+			require.NoError(t, err)
+			buf := make([]byte, 10)
+			_, err = io.ReadFull(f, buf)
+			require.NoError(t, err)
+			assert.Equal(t, content[offset:offset+10], buf, "at %d", offset)
+		}
+	})
+
+	t.Run("unknown IDs", func(t *testing.T) {
+		for _, id := range []string{"000000000000000000000001", "not-an-id"} {
+			_, err := store.Open(ctx, id)
+			assert.ErrorIs(t, err, ErrNotFound, id)
+		}
+	})
+
+	t.Run("walk", func(t *testing.T) {
+		var ids []string
+		require.NoError(t, store.Walk(ctx, func(info Info) error {
+			ids = append(ids, info.ID)
+			return nil
+		}))
+		assert.Contains(t, ids, info.ID)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, store.Delete(ctx, info.ID))
+		_, err := store.Open(ctx, info.ID)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, store.Delete(ctx, info.ID), "deleting twice")
+	})
+}
@@ -0,0 +1,174 @@
+package media
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// infoSuffix names the file next to each stored file that holds its Info
+const infoSuffix = ".json"
+
+// LocalStore keeps files in a directory of the local filesystem. Each file
+// is stored under its ID, with its Info in a JSON file next to it.
+type LocalStore struct {
+	dir string
+}
+
+var _ Store = (*LocalStore)(nil)
+
+// NewLocalStore returns a store in dir, creating the directory if needed
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if dir == "" {
+		return nil, errors.New("media directory is not set")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &LocalStore{dir: dir}, nil
+}
+
+// path returns the path of the file with id. IDs are ObjectIDs, which keeps
+// other names from reaching outside the directory.
+func (s *LocalStore) path(id string) (string, error) {
+	if !primitive.IsValidObjectID(id) {
+		return "", ErrNotFound
+	}
+	return filepath.Join(s.dir, id), nil
+}
+
+func (s *LocalStore) Save(ctx context.Context, filename, contentType string, r io.Reader) (Info, error) {
+	info := Info{
+		ID:          primitive.NewObjectID().Hex(),
+		Filename:    filename,
+		ContentType: contentType,
+		UploadedAt:  time.Now().UTC(),
+	}
+
+	// Files are written under a temporary name and renamed into place, so
+	// a failed upload never leaves a partial file behind
+	tmp, err := os.CreateTemp(s.dir, ".upload-*")
+	if err != nil {
+		return Info{}, err
+	}
+	defer os.Remove(tmp.Name())
+
+	info.Size, err = io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return Info{}, err
+	}
+
+	data, err := json.Marshal(info)
+	if err != nil {
+		return Info{}, err
+	}
+	name := filepath.Join(s.dir, info.ID)
+	if err := os.WriteFile(name+infoSuffix, data, 0644); err != nil {
+		return Info{}, err
+	}
+	if err := os.Rename(tmp.Name(), name); err != nil {
+		os.Remove(name + infoSuffix)
+		return Info{}, err
+	}
+	return info, nil
+}
+
+func (s *LocalStore) Open(ctx context.Context, id string) (File, error) {
+	name, err := s.path(id)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := readInfo(name + infoSuffix)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &localFile{File: f, info: info}, nil
+}
+
+func (s *LocalStore) Delete(ctx context.Context, id string) error {
+	name, err := s.path(id)
+	if err != nil {
+		return nil
+	}
+
+	// The file goes first, so a failure leaves its Info for Walk to find
+	for _, path := range []string{name, name + infoSuffix} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *LocalStore) Walk(ctx context.Context, fn func(Info) error) error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), infoSuffix)
+		if !ok || !primitive.IsValidObjectID(id) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		info, err := readInfo(filepath.Join(s.dir, entry.Name()))
+		if errors.Is(err, ErrNotFound) {
+			// Deleted since the directory was read
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readInfo reads the Info of a stored file
+func readInfo(name string) (Info, error) {
+	data, err := os.ReadFile(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return Info{}, ErrNotFound
+	}
+	if err != nil {
+		return Info{}, err
+	}
+
+	var info Info
+	if err := json.Unmarshal(data, &info); err != nil {
+		return Info{}, err
+	}
+	return info, nil
+}
+
+// localFile is a stored file opened from the local filesystem
+type localFile struct {
+	*os.File
+	info Info
+}
+
+func (f *localFile) Info() Info { return f.info }
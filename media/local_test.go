@@ -0,0 +1,88 @@
+//go:build unit
+
+package media
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := NewLocalStore(dir)
+	require.NoError(t, err)
+
+	info, err := store.Save(ctx, "notes.txt", "text/plain", strings.NewReader("hello, media store"))
+	require.NoError(t, err)
+	assert.Equal(t, int64(18), info.Size)
+	assert.Equal(t, "notes.txt", info.Filename)
+
+	t.Run("open", func(t *testing.T) {
+		f, err := store.Open(ctx, info.ID)
+		require.NoError(t, err)
+		defer f.Close()
+
+		assert.Equal(t, "text/plain", f.Info().ContentType)
+		assert.Equal(t, info.Size, f.Info().Size)
+
+		_, err = f.Seek(7, io.SeekStart)
+		require.NoError(t, err)
+		data, err := io.ReadAll(f)
+		require.NoError(t, err)
+		assert.Equal(t, "media store", string(data))
+	})
+
+	t.Run("unknown IDs", func(t *testing.T) {
+		for _, id := range []string{"000000000000000000000001", "../config", ""} {
+			_, err := store.Open(ctx, id)
+			assert.ErrorIs(t, err, ErrNotFound, id)
+		}
+	})
+
+	t.Run("walk", func(t *testing.T) {
+		var ids []string
+		require.NoError(t, store.Walk(ctx, func(info Info) error {
+			ids = append(ids, info.ID)
+			return nil
+		}))
+		assert.Equal(t, []string{info.ID}, ids)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, store.Delete(ctx, info.ID))
+		_, err := store.Open(ctx, info.ID)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.NoError(t, store.Delete(ctx, info.ID), "deleting twice")
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+}
+
+func TestLocalStore_FailedSave(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewLocalStore(dir)
+	require.NoError(t, err)
+
+	_, err = store.Save(context.Background(), "broken.bin", "application/octet-stream", io.MultiReader(
+		strings.NewReader("partial"), errReader{},
+	))
+	require.Error(t, err)
+
+	matches, err := filepath.Glob(filepath.Join(dir, "*"))
+	require.NoError(t, err)
+	assert.Empty(t, matches, "a failed upload leaves nothing behind")
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) { return 0, io.ErrUnexpectedEOF }
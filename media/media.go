@@ -0,0 +1,125 @@
+package media
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Backends of NewStore
+const (
+	BackendGridFS = "gridfs"
+	BackendLocal  = "local"
+)
+
+var (
+	// ErrUnknownBackend is returned by NewStore for unsupported backends
+	ErrUnknownBackend = errors.New("unknown media backend")
+	// ErrNotFound is returned for files that aren't in the store
+	ErrNotFound = errors.New("media file not found")
+)
+
+// Info describes a stored file
+type Info struct {
+	ID          string    `json:"id"`
+	Filename    string    `json:"filename"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	UploadedAt  time.Time `json:"uploaded_at"`
+}
+
+// File is a stored file opened for reading. It seeks, so that range
+// requests can start anywhere in it.
+type File interface {
+	io.ReadSeekCloser
+	Info() Info
+}
+
+// Store keeps the files uploaded with posts. Files are never changed once
+// saved, and are addressed by the ID the store gives them. Implementations
+// must be safe for concurrent use.
+type Store interface {
+	// Save stores the contents of r under a new ID
+	Save(ctx context.Context, filename, contentType string, r io.Reader) (Info, error)
+	// Open opens a file for reading, returning ErrNotFound for unknown IDs
+	Open(ctx context.Context, id string) (File, error)
+	// Delete removes a file. Deleting a file that doesn't exist succeeds.
+	Delete(ctx context.Context, id string) error
+	// Walk calls fn for every stored file
+	Walk(ctx context.Context, fn func(Info) error) error
+}
+
+// NewStore returns the named store: GridFS in database, or the directory
+// dir of the local filesystem
+func NewStore(name string, database *mongo.Database, dir string) (Store, error) {
+	switch name {
+	case BackendGridFS, "":
+		return NewGridFSStore(database)
+	case BackendLocal:
+		return NewLocalStore(dir)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownBackend, name)
+	}
+}
+
+// DetectContentType sniffs the media type of the contents of r from its
+// first bytes, ignoring whatever the client claimed. The returned reader
+// still yields all of r.
+func DetectContentType(r io.Reader) (string, io.Reader, error) {
+	buffered := bufio.NewReaderSize(r, 512)
+	head, err := buffered.Peek(512)
+	if err != nil && err != io.EOF {
+		return "", nil, err
+	}
+
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return "", nil, err
+	}
+	return mediaType, buffered, nil
+}
+
+// Prune deletes the files no post references, except for those uploaded
+// after before, which may belong to a post that is still being saved. It
+// returns the number of files deleted.
+func Prune(ctx context.Context, store Store, referenced map[string]bool, before time.Time) (int, error) {
+	var orphans []string
+	err := store.Walk(ctx, func(info Info) error {
+		if !referenced[info.ID] && info.UploadedAt.Before(before) {
+			orphans = append(orphans, info.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for i, id := range orphans {
+		if err := store.Delete(ctx, id); err != nil {
+			return i, err
+		}
+	}
+	return len(orphans), nil
+}
+
+// FormatSize formats a size in bytes for people, like "1.5 MB"
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit && exp < 3; n /= unit {
+		div *= unit
+		exp++
+	}
+	return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(size)/float64(div)), ".0") + " " + "KMGT"[exp:exp+1] + "B"
+}
@@ -0,0 +1,78 @@
+//go:build unit
+
+package media
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectContentType(t *testing.T) {
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 600)
+	tests := map[string]string{
+		png:                            "image/png",
+		"%PDF-1.7\n":                   "application/pdf",
+		"<html><body>hi</body></html>": "text/html",
+		"plain words":                  "text/plain",
+		"":                             "text/plain",
+	}
+
+	for content, want := range tests {
+		contentType, r, err := DetectContentType(strings.NewReader(content))
+		require.NoError(t, err)
+		assert.Equal(t, want, contentType)
+
+		// Sniffing doesn't consume the contents
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, content, string(data))
+	}
+}
+
+func TestPrune(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	kept, err := store.Save(ctx, "kept.txt", "text/plain", strings.NewReader("kept"))
+	require.NoError(t, err)
+	orphan, err := store.Save(ctx, "orphan.txt", "text/plain", strings.NewReader("orphan"))
+	require.NoError(t, err)
+
+	// Files uploaded after the cutoff are left alone
+	count, err := Prune(ctx, store, map[string]bool{kept.ID: true}, orphan.UploadedAt.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	count, err = Prune(ctx, store, map[string]bool{kept.ID: true}, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	_, err = store.Open(ctx, orphan.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = store.Open(ctx, kept.ID)
+	assert.NoError(t, err)
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := map[int64]string{
+		0:        "0 B",
+		1023:     "1023 B",
+		1024:     "1 KB",
+		1536:     "1.5 KB",
+		10 << 20: "10 MB",
+		5 << 30:  "5 GB",
+		3 << 40:  "3 TB",
+		1 << 50:  "1024 TB",
+	}
+
+	for size, want := range tests {
+		assert.Equal(t, want, FormatSize(size), "%d bytes", size)
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gekich/news-app/i18n"
+)
+
+// LocaleParam is the query parameter switching the locale, like ?lang=uk.
+// The choice is kept in the LocaleCookie.
+const (
+	LocaleParam  = "lang"
+	LocaleCookie = "lang"
+)
+
+// localeCookieMaxAge keeps the chosen locale for a year
+const localeCookieMaxAge = 365 * 24 * 60 * 60
+
+// Locale picks the locale of each request and puts its translator in the
+// request context, see i18n.FromContext. The locale comes from the
+// LocaleParam, then the LocaleCookie, then the Accept-Language header, and
+// is the default locale of bundle when none of them names a supported one.
+func Locale(bundle *i18n.Bundle) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			locale, ok := bundle.Match(r.URL.Query().Get(LocaleParam))
+			if ok {
+				http.SetCookie(w, &http.Cookie{
+					Name:     LocaleCookie,
+					Value:    locale,
+					Path:     "/",
+					MaxAge:   localeCookieMaxAge,
+					HttpOnly: true,
+					SameSite: http.SameSiteLaxMode,
+				})
+			} else if cookie, err := r.Cookie(LocaleCookie); err == nil {
+				locale, ok = bundle.Match(cookie.Value)
+			}
+			if !ok {
+				locale = bundle.Negotiate(r.Header.Get("Accept-Language"))
+			}
+
+			// Shared caches mustn't serve a page in another user's language
+			w.Header().Add("Vary", "Accept-Language, Cookie")
+
+			ctx := i18n.WithTranslator(r.Context(), bundle.Translator(locale))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,102 @@
+//go:build unit
+
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gekich/news-app/i18n"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocale(t *testing.T) {
+	tests := []struct {
+		name           string
+		target         string
+		cookie         string
+		acceptLanguage string
+		expectedLocale string
+		expectedCookie string
+	}{
+		{
+			name:           "default locale",
+			target:         "/posts",
+			expectedLocale: "en",
+		},
+		{
+			name:           "Accept-Language",
+			target:         "/posts",
+			acceptLanguage: "uk-UA,uk;q=0.9,en;q=0.8",
+			expectedLocale: "uk",
+		},
+		{
+			name:           "cookie over Accept-Language",
+			target:         "/posts",
+			cookie:         "en",
+			acceptLanguage: "uk",
+			expectedLocale: "en",
+		},
+		{
+			name:           "query parameter over cookie",
+			target:         "/posts?lang=uk",
+			cookie:         "en",
+			expectedLocale: "uk",
+			expectedCookie: "uk",
+		},
+		{
+			name:           "query parameter by language",
+			target:         "/posts?lang=uk-UA",
+			expectedLocale: "uk",
+			expectedCookie: "uk",
+		},
+		{
+			name:           "unsupported query parameter",
+			target:         "/posts?lang=fr",
+			acceptLanguage: "uk",
+			expectedLocale: "uk",
+		},
+		{
+			name:           "unsupported cookie",
+			target:         "/posts",
+			cookie:         "fr",
+			acceptLanguage: "uk",
+			expectedLocale: "uk",
+		},
+	}
+
+	bundle, err := i18n.New("en")
+	require.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var locale string
+			handler := Locale(bundle)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				locale = i18n.FromContext(r.Context()).Locale()
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: LocaleCookie, Value: tt.cookie})
+			}
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedLocale, locale)
+			assert.Contains(t, rr.Header().Get("Vary"), "Accept-Language")
+
+			var cookie string
+			for _, c := range rr.Result().Cookies() {
+				if c.Name == LocaleCookie {
+					cookie = c.Value
+				}
+			}
+			assert.Equal(t, tt.expectedCookie, cookie)
+		})
+	}
+}
@@ -6,11 +6,18 @@ import (
 )
 
 // MethodOverride checks for _method form field or X-HTTP-Method-Override header
-// to support PUT/DELETE in browsers that don't support these methods in forms
+// to support PUT/DELETE in browsers that don't support these methods in forms.
+// Multipart bodies are left for the handler to read with its own size limits,
+// so multipart forms put _method in the query of their action instead.
 func MethodOverride(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == "POST" {
-			if err := r.ParseForm(); err == nil {
+			if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+				method := strings.ToUpper(r.URL.Query().Get("_method"))
+				if method == "PUT" || method == "DELETE" {
+					r.Method = method
+				}
+			} else if err := r.ParseForm(); err == nil {
 				method := r.PostForm.Get("_method")
 				if method != "" {
 					method = strings.ToUpper(method)
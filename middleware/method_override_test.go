@@ -3,6 +3,7 @@
 package middleware
 
 import (
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -99,3 +100,22 @@ func TestMethodOverride(t *testing.T) {
 		})
 	}
 }
+
+func TestMethodOverride_Multipart(t *testing.T) {
+	var body strings.Builder
+	form := multipart.NewWriter(&body)
+	form.WriteField("_method", "DELETE")
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/test?_method=put", strings.NewReader(body.String()))
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	handler := MethodOverride(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The method comes from the query, and the body is left unread
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Nil(t, r.MultipartForm)
+		assert.NoError(t, r.ParseMultipartForm(1<<10))
+		assert.Equal(t, "DELETE", r.PostFormValue("_method"))
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+}
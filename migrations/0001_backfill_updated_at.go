@@ -0,0 +1,26 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func init() {
+	register(Migration{
+		Version:     1,
+		Description: "backfill posts.updated_at from created_at",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("posts").UpdateMany(ctx,
+				bson.M{"updated_at": bson.M{"$exists": false}},
+				mongo.Pipeline{{{Key: "$set", Value: bson.M{"updated_at": "$created_at"}}}},
+			)
+			return err
+		},
+		// The backfilled values are indistinguishable from real ones, so there is nothing to undo
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			return nil
+		},
+	})
+}
@@ -0,0 +1,29 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func init() {
+	register(Migration{
+		Version:     2,
+		Description: "backfill posts.status as published",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("posts").UpdateMany(ctx,
+				bson.M{"status": bson.M{"$exists": false}},
+				bson.M{"$set": bson.M{"status": "published"}},
+			)
+			return err
+		},
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("posts").UpdateMany(ctx,
+				bson.M{},
+				bson.M{"$unset": bson.M{"status": ""}},
+			)
+			return err
+		},
+	})
+}
@@ -0,0 +1,92 @@
+package migrations
+
+import (
+	"context"
+
+	"github.com/gekich/news-app/slug"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	register(Migration{
+		Version:     3,
+		Description: "backfill posts.slug from titles",
+		Up:          backfillSlugs,
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("posts").UpdateMany(ctx,
+				bson.M{},
+				bson.M{"$unset": bson.M{"slug": "", "previous_slugs": ""}},
+			)
+			return err
+		},
+	})
+}
+
+// backfillSlugs gives every post without a slug one derived from its title.
+// Older posts are handled first so they keep the unsuffixed slug when titles
+// collide.
+func backfillSlugs(ctx context.Context, db *mongo.Database) error {
+	posts := db.Collection("posts")
+
+	taken := make(map[string]bool)
+	existing, err := posts.Distinct(ctx, "slug", bson.M{"slug": bson.M{"$type": "string"}})
+	if err != nil {
+		return err
+	}
+	for _, value := range existing {
+		taken[value.(string)] = true
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetProjection(bson.M{"title": 1})
+	cursor, err := posts.Find(ctx, bson.M{"slug": bson.M{"$exists": false}}, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var updates []mongo.WriteModel
+	flush := func() error {
+		if len(updates) == 0 {
+			return nil
+		}
+		_, err := posts.BulkWrite(ctx, updates)
+		updates = updates[:0]
+		return err
+	}
+
+	for cursor.Next(ctx) {
+		var post struct {
+			ID    primitive.ObjectID `bson:"_id"`
+			Title string             `bson:"title"`
+		}
+		if err := cursor.Decode(&post); err != nil {
+			return err
+		}
+
+		base := slug.Make(post.Title)
+		candidate := base
+		for n := 2; taken[candidate]; n++ {
+			candidate = slug.WithSuffix(base, n)
+		}
+		taken[candidate] = true
+
+		updates = append(updates, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": post.ID}).
+			SetUpdate(bson.M{"$set": bson.M{"slug": candidate}}))
+		if len(updates) == 1000 {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	return flush()
+}
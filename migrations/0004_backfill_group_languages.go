@@ -0,0 +1,81 @@
+package migrations
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func init() {
+	register(Migration{
+		Version:     4,
+		Description: "backfill posts.group_languages from translation groups",
+		Up:          backfillGroupLanguages,
+		Down: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("posts").UpdateMany(ctx,
+				bson.M{},
+				bson.M{"$unset": bson.M{"group_languages": ""}},
+			)
+			return err
+		},
+	})
+}
+
+// backfillGroupLanguages stores the languages of each translation group on
+// the posts of the group
+func backfillGroupLanguages(ctx context.Context, db *mongo.Database) error {
+	posts := db.Collection("posts")
+
+	cursor, err := posts.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"translation_group": bson.M{"$type": "string"}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":       "$translation_group",
+			"languages": bson.M{"$addToSet": "$language"},
+		}}},
+	})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var updates []mongo.WriteModel
+	flush := func() error {
+		if len(updates) == 0 {
+			return nil
+		}
+		_, err := posts.BulkWrite(ctx, updates)
+		updates = updates[:0]
+		return err
+	}
+
+	for cursor.Next(ctx) {
+		var group struct {
+			ID        string        `bson:"_id"`
+			Languages []interface{} `bson:"languages"`
+		}
+		if err := cursor.Decode(&group); err != nil {
+			return err
+		}
+
+		// Posts without a language add nothing to the list
+		languages := bson.A{}
+		for _, language := range group.Languages {
+			if _, ok := language.(string); ok {
+				languages = append(languages, language)
+			}
+		}
+		updates = append(updates, mongo.NewUpdateManyModel().
+			SetFilter(bson.M{"translation_group": group.ID}).
+			SetUpdate(bson.M{"$set": bson.M{"group_languages": languages}}))
+		if len(updates) == 500 {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	return flush()
+}
@@ -0,0 +1,139 @@
+package migrations
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	lockCollection = "migrations_lock"
+	lockID         = "migrations"
+	defaultLockTTL = 5 * time.Minute
+)
+
+// ErrLocked is returned when another runner currently holds the migration lock
+var ErrLocked = errors.New("migrations are locked by another runner")
+
+// ErrLockLost is returned when the migration lock stopped being held while
+// migrating, like when it couldn't be renewed before it expired
+var ErrLockLost = errors.New("the migration lock was lost")
+
+// lockDocument is stored in the lock collection while a runner is migrating
+type lockDocument struct {
+	ID        string    `bson:"_id"`
+	Owner     string    `bson:"owner"`
+	LockedAt  time.Time `bson:"locked_at"`
+	ExpiresAt time.Time `bson:"expires_at"`
+}
+
+// lock acquires the migration lock and returns a function that releases it.
+// A lock left behind by a crashed runner is taken over once it has expired.
+// While it is held, the lock is renewed every third of its TTL, so migrations
+// may take longer than that. The returned context is cancelled with
+// ErrLockLost when a renewal finds the lock is no longer held.
+func (m *Migrator) lock(ctx context.Context) (context.Context, func(), error) {
+	owner, err := lockOwner()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	doc := lockDocument{
+		ID:        lockID,
+		Owner:     owner,
+		LockedAt:  now,
+		ExpiresAt: now.Add(m.lockTTL),
+	}
+
+	collection := m.db.Collection(lockCollection)
+	_, err = collection.InsertOne(ctx, doc)
+	if mongo.IsDuplicateKeyError(err) {
+		result, updateErr := collection.ReplaceOne(ctx, bson.M{
+			"_id":        lockID,
+			"expires_at": bson.M{"$lt": now},
+		}, doc)
+		if updateErr != nil {
+			return nil, nil, updateErr
+		}
+		if result.ModifiedCount == 0 {
+			return nil, nil, ErrLocked
+		}
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	lockedCtx, cancel := context.WithCancelCause(ctx)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		m.renewLock(lockedCtx, collection, owner, doc.ExpiresAt, cancel)
+	}()
+
+	release := func() {
+		cancel(nil)
+		<-stopped
+
+		// Release with a fresh context so a cancelled request doesn't leave the lock behind
+		releaseCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		collection.DeleteOne(releaseCtx, bson.M{"_id": lockID, "owner": owner})
+	}
+
+	return lockedCtx, release, nil
+}
+
+// renewLock pushes the expiry of the lock forward until ctx is done. A lock
+// that is no longer held, or can't be renewed before it expires, cancels
+// ctx with ErrLockLost.
+func (m *Migrator) renewLock(ctx context.Context, collection *mongo.Collection, owner string, expiresAt time.Time, cancel context.CancelCauseFunc) {
+	ticker := time.NewTicker(m.lockTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		now := time.Now()
+		result, err := collection.UpdateOne(ctx,
+			bson.M{"_id": lockID, "owner": owner},
+			bson.M{"$set": bson.M{"expires_at": now.Add(m.lockTTL)}},
+		)
+		switch {
+		case err == nil && result.MatchedCount == 0:
+			cancel(ErrLockLost)
+			return
+		case err == nil:
+			expiresAt = now.Add(m.lockTTL)
+		case !now.Before(expiresAt):
+			// Another runner may have taken the lock over by now
+			cancel(ErrLockLost)
+			return
+		}
+	}
+}
+
+// lockOwner builds an identifier that is unique to this runner
+func lockOwner() (string, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", fmt.Errorf("failed to generate lock owner: %w", err)
+	}
+
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), hex.EncodeToString(suffix)), nil
+}
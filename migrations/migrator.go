@@ -0,0 +1,203 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const migrationsCollection = "migrations"
+
+// Migration describes a single versioned change to the database
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+	Down        func(ctx context.Context, db *mongo.Database) error
+}
+
+// Status reports whether a known migration has been applied
+type Status struct {
+	Version     int
+	Description string
+	Applied     bool
+	AppliedAt   time.Time
+}
+
+// record is the document stored in the migrations collection for every applied migration
+type record struct {
+	Version     int       `bson:"_id"`
+	Description string    `bson:"description"`
+	AppliedAt   time.Time `bson:"applied_at"`
+}
+
+// Migrator applies and rolls back migrations against a database
+type Migrator struct {
+	db         *mongo.Database
+	migrations []Migration
+	lockTTL    time.Duration
+}
+
+// NewMigrator creates a Migrator for the given migrations, which are sorted by version
+func NewMigrator(db *mongo.Database, migrations []Migration) (*Migrator, error) {
+	sorted := make([]Migration, len(migrations))
+	copy(sorted, migrations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+
+	for i, m := range sorted {
+		if m.Version <= 0 {
+			return nil, fmt.Errorf("migration %q has invalid version %d", m.Description, m.Version)
+		}
+		if m.Up == nil {
+			return nil, fmt.Errorf("migration %d has no Up function", m.Version)
+		}
+		if i > 0 && sorted[i-1].Version == m.Version {
+			return nil, fmt.Errorf("duplicate migration version %d", m.Version)
+		}
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: sorted,
+		lockTTL:    defaultLockTTL,
+	}, nil
+}
+
+// WithLockTTL sets how long the migration lock lasts without being renewed,
+// which is how long a crashed runner blocks the others
+func (m *Migrator) WithLockTTL(ttl time.Duration) *Migrator {
+	m.lockTTL = ttl
+	return m
+}
+
+// Up applies all pending migrations in version order and returns how many were applied
+func (m *Migrator) Up(ctx context.Context) (count int, err error) {
+	ctx, release, err := m.lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+	defer func() { err = lockError(ctx, err) }()
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		if err := migration.Up(ctx, m.db); err != nil {
+			return count, fmt.Errorf("migration %d (%s) failed: %w", migration.Version, migration.Description, err)
+		}
+
+		rec := record{
+			Version:     migration.Version,
+			Description: migration.Description,
+			AppliedAt:   time.Now(),
+		}
+		if _, err := m.db.Collection(migrationsCollection).InsertOne(ctx, rec); err != nil {
+			return count, fmt.Errorf("failed to record migration %d: %w", migration.Version, err)
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// Down rolls back the given number of most recently applied migrations and returns how many were rolled back
+func (m *Migrator) Down(ctx context.Context, steps int) (count int, err error) {
+	ctx, release, err := m.lock(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+	defer func() { err = lockError(ctx, err) }()
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := len(m.migrations) - 1; i >= 0 && count < steps; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+
+		if migration.Down == nil {
+			return count, fmt.Errorf("migration %d (%s) is irreversible", migration.Version, migration.Description)
+		}
+
+		if err := migration.Down(ctx, m.db); err != nil {
+			return count, fmt.Errorf("rollback of migration %d (%s) failed: %w", migration.Version, migration.Description, err)
+		}
+
+		if _, err := m.db.Collection(migrationsCollection).DeleteOne(ctx, bson.M{"_id": migration.Version}); err != nil {
+			return count, fmt.Errorf("failed to remove record of migration %d: %w", migration.Version, err)
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// lockError reports a failure caused by losing the lock of ctx as
+// ErrLockLost
+func lockError(ctx context.Context, err error) error {
+	if err != nil && errors.Is(context.Cause(ctx), ErrLockLost) {
+		return fmt.Errorf("%w: %v", ErrLockLost, err)
+	}
+	return err
+}
+
+// Status lists every known migration along with when it was applied
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, len(m.migrations))
+	for i, migration := range m.migrations {
+		rec, ok := applied[migration.Version]
+		statuses[i] = Status{
+			Version:     migration.Version,
+			Description: migration.Description,
+			Applied:     ok,
+			AppliedAt:   rec.AppliedAt,
+		}
+	}
+
+	return statuses, nil
+}
+
+// applied loads the records of all applied migrations keyed by version
+func (m *Migrator) applied(ctx context.Context) (map[int]record, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := m.db.Collection(migrationsCollection).Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var records []record
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]record, len(records))
+	for _, rec := range records {
+		applied[rec.Version] = rec
+	}
+
+	return applied, nil
+}
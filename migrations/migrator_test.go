@@ -0,0 +1,294 @@
+//go:build integration
+
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/ory/dockertest/v3/docker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	mongoClient *mongo.Client
+	mongoDB     *mongo.Database
+)
+
+func TestMain(m *testing.M) {
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		log.Fatalf("Could not connect to docker: %s", err)
+	}
+
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: "mongo",
+		Tag:        "4.4",
+		Env: []string{
+			"MONGO_INITDB_DATABASE=test_db",
+		},
+	}, func(config *docker.HostConfig) {
+		config.AutoRemove = true
+		config.RestartPolicy = docker.RestartPolicy{
+			Name: "no",
+		}
+	})
+	if err != nil {
+		log.Fatalf("Could not start resource: %s", err)
+	}
+
+	if err := pool.Retry(func() error {
+		var err error
+		mongoURI := fmt.Sprintf("mongodb://localhost:%s", resource.GetPort("27017/tcp"))
+
+		mongoClient, err = mongo.Connect(
+			context.Background(),
+			options.Client().ApplyURI(mongoURI),
+		)
+		if err != nil {
+			return err
+		}
+
+		return mongoClient.Ping(context.Background(), nil)
+	}); err != nil {
+		log.Fatalf("Could not connect to docker: %s", err)
+	}
+
+	mongoDB = mongoClient.Database("test_db")
+	code := m.Run()
+
+	if err := pool.Purge(resource); err != nil {
+		log.Fatalf("Could not purge resource: %s", err)
+	}
+
+	os.Exit(code)
+}
+
+func resetMigrations(t *testing.T) {
+	require.NoError(t, mongoDB.Collection(migrationsCollection).Drop(context.Background()))
+	require.NoError(t, mongoDB.Collection(lockCollection).Drop(context.Background()))
+}
+
+func testMigrations(calls *[]string) []Migration {
+	step := func(name string) func(context.Context, *mongo.Database) error {
+		return func(context.Context, *mongo.Database) error {
+			*calls = append(*calls, name)
+			return nil
+		}
+	}
+
+	return []Migration{
+		{Version: 2, Description: "second", Up: step("up 2"), Down: step("down 2")},
+		{Version: 1, Description: "first", Up: step("up 1"), Down: step("down 1")},
+	}
+}
+
+func TestMigrator_UpAndDown(t *testing.T) {
+	resetMigrations(t)
+	ctx := context.Background()
+
+	var calls []string
+	migrator, err := NewMigrator(mongoDB, testMigrations(&calls))
+	require.NoError(t, err)
+
+	count, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, []string{"up 1", "up 2"}, calls)
+
+	// Running again applies nothing
+	count, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	assert.True(t, statuses[0].Applied)
+	assert.True(t, statuses[1].Applied)
+
+	count, err = migrator.Down(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, []string{"up 1", "up 2", "down 2"}, calls)
+
+	statuses, err = migrator.Status(ctx)
+	require.NoError(t, err)
+	assert.True(t, statuses[0].Applied)
+	assert.False(t, statuses[1].Applied)
+}
+
+func TestMigrator_Lock(t *testing.T) {
+	resetMigrations(t)
+	ctx := context.Background()
+
+	migrator, err := NewMigrator(mongoDB, nil)
+	require.NoError(t, err)
+
+	_, release, err := migrator.lock(ctx)
+	require.NoError(t, err)
+
+	_, err = migrator.Up(ctx)
+	assert.ErrorIs(t, err, ErrLocked)
+
+	release()
+
+	_, err = migrator.Up(ctx)
+	assert.NoError(t, err)
+}
+
+func TestMigrator_ExpiredLockIsTakenOver(t *testing.T) {
+	resetMigrations(t)
+	ctx := context.Background()
+
+	_, err := mongoDB.Collection(lockCollection).InsertOne(ctx, lockDocument{
+		ID:        lockID,
+		Owner:     "crashed-runner",
+		LockedAt:  time.Now().Add(-time.Hour),
+		ExpiresAt: time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+
+	migrator, err := NewMigrator(mongoDB, nil)
+	require.NoError(t, err)
+
+	_, err = migrator.Up(ctx)
+	assert.NoError(t, err)
+}
+
+func TestMigrator_LockIsRenewed(t *testing.T) {
+	resetMigrations(t)
+	ctx := context.Background()
+
+	// The migration outlasts the TTL several times over
+	slow := Migration{Version: 1, Description: "slow", Up: func(ctx context.Context, db *mongo.Database) error {
+		time.Sleep(time.Second)
+		return nil
+	}}
+	migrator, err := NewMigrator(mongoDB, []Migration{slow})
+	require.NoError(t, err)
+	migrator.WithLockTTL(300 * time.Millisecond)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := migrator.Up(ctx)
+		done <- err
+	}()
+
+	time.Sleep(700 * time.Millisecond)
+	other, err := NewMigrator(mongoDB, nil)
+	require.NoError(t, err)
+	_, err = other.Up(ctx)
+	assert.ErrorIs(t, err, ErrLocked, "the lock is still held")
+
+	assert.NoError(t, <-done)
+}
+
+func TestMigrator_LockLost(t *testing.T) {
+	resetMigrations(t)
+	ctx := context.Background()
+
+	// Another runner takes the lock over in the middle of the migration
+	takeover := Migration{Version: 1, Description: "takeover", Up: func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection(lockCollection).UpdateOne(context.Background(),
+			bson.M{"_id": lockID}, bson.M{"$set": bson.M{"owner": "other-runner"}})
+		if err != nil {
+			return err
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+	migrator, err := NewMigrator(mongoDB, []Migration{takeover})
+	require.NoError(t, err)
+	migrator.WithLockTTL(300 * time.Millisecond)
+
+	count, err := migrator.Up(ctx)
+	assert.ErrorIs(t, err, ErrLockLost)
+	assert.Zero(t, count)
+
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	assert.False(t, statuses[0].Applied, "the migration isn't recorded")
+}
+
+func TestBackfillGroupLanguages(t *testing.T) {
+	resetMigrations(t)
+	ctx := context.Background()
+	posts := mongoDB.Collection("posts")
+	require.NoError(t, posts.Drop(ctx))
+
+	_, err := posts.InsertMany(ctx, []interface{}{
+		bson.M{"title": "Source", "translation_group": "g1"},
+		bson.M{"title": "Translation", "translation_group": "g1", "language": "uk"},
+		bson.M{"title": "Alone", "language": "en"},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, backfillGroupLanguages(ctx, mongoDB))
+
+	var source, alone bson.M
+	require.NoError(t, posts.FindOne(ctx, bson.M{"title": "Source"}).Decode(&source))
+	assert.Equal(t, bson.A{"uk"}, source["group_languages"])
+	require.NoError(t, posts.FindOne(ctx, bson.M{"title": "Alone"}).Decode(&alone))
+	assert.NotContains(t, alone, "group_languages")
+}
+
+func TestBackfillUpdatedAt(t *testing.T) {
+	resetMigrations(t)
+	ctx := context.Background()
+	posts := mongoDB.Collection("posts")
+	require.NoError(t, posts.Drop(ctx))
+
+	createdAt := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	_, err := posts.InsertOne(ctx, bson.M{"title": "Legacy", "created_at": createdAt})
+	require.NoError(t, err)
+
+	migrator, err := NewMigrator(mongoDB, All())
+	require.NoError(t, err)
+
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+
+	var doc struct {
+		UpdatedAt time.Time `bson:"updated_at"`
+	}
+	require.NoError(t, posts.FindOne(ctx, bson.M{"title": "Legacy"}).Decode(&doc))
+	assert.True(t, createdAt.Equal(doc.UpdatedAt))
+}
+
+func TestBackfillSlugs(t *testing.T) {
+	resetMigrations(t)
+	ctx := context.Background()
+	posts := mongoDB.Collection("posts")
+	require.NoError(t, posts.Drop(ctx))
+
+	createdAt := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+	_, err := posts.InsertMany(ctx, []interface{}{
+		bson.M{"title": "Breaking News", "created_at": createdAt.Add(time.Minute)},
+		bson.M{"title": "Breaking news!", "created_at": createdAt},
+	})
+	require.NoError(t, err)
+
+	migrator, err := NewMigrator(mongoDB, All())
+	require.NoError(t, err)
+
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+
+	var doc struct {
+		Slug string `bson:"slug"`
+	}
+	require.NoError(t, posts.FindOne(ctx, bson.M{"title": "Breaking news!"}).Decode(&doc))
+	assert.Equal(t, "breaking-news", doc.Slug)
+	require.NoError(t, posts.FindOne(ctx, bson.M{"title": "Breaking News"}).Decode(&doc))
+	assert.Equal(t, "breaking-news-2", doc.Slug)
+}
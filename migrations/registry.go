@@ -0,0 +1,19 @@
+package migrations
+
+import "sort"
+
+var registry []Migration
+
+// register adds a migration to the set returned by All. It is called from the
+// init function of each migration file.
+func register(m Migration) {
+	registry = append(registry, m)
+}
+
+// All returns every registered migration sorted by version
+func All() []Migration {
+	migrations := make([]Migration, len(registry))
+	copy(migrations, registry)
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations
+}
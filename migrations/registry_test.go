@@ -0,0 +1,64 @@
+//go:build unit
+
+package migrations
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func noop(context.Context, *mongo.Database) error { return nil }
+
+func TestAll_IsValid(t *testing.T) {
+	_, err := NewMigrator(nil, All())
+	require.NoError(t, err)
+
+	all := All()
+	for i := 1; i < len(all); i++ {
+		assert.Less(t, all[i-1].Version, all[i].Version)
+	}
+}
+
+func TestNewMigrator(t *testing.T) {
+	tests := []struct {
+		name       string
+		migrations []Migration
+		wantErr    bool
+	}{
+		{
+			name:       "valid migrations",
+			migrations: []Migration{{Version: 2, Up: noop}, {Version: 1, Up: noop}},
+		},
+		{
+			name:       "duplicate version",
+			migrations: []Migration{{Version: 1, Up: noop}, {Version: 1, Up: noop}},
+			wantErr:    true,
+		},
+		{
+			name:       "invalid version",
+			migrations: []Migration{{Version: 0, Up: noop}},
+			wantErr:    true,
+		},
+		{
+			name:       "missing up",
+			migrations: []Migration{{Version: 1}},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrator, err := NewMigrator(nil, tt.migrations)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 1, migrator.migrations[0].Version)
+		})
+	}
+}
@@ -1,15 +1,147 @@
 package models
 
 import (
+	"path"
+	"regexp"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Post statuses
+const (
+	StatusPublished = "published"
+	StatusDraft     = "draft"
+)
+
+// Post is a news post. Language is the locale it is written in, like "en";
+// posts without one are in the site's fallback language. Translations of a
+// story share a TranslationGroup, the ID of the post they were translated
+// from, which that post carries as well. Imported groups get a new ID of
+// their own.
 type Post struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Title     string             `bson:"title" json:"title"`
-	Content   string             `bson:"content" json:"content"`
-	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Title            string             `bson:"title" json:"title"`
+	Slug             string             `bson:"slug,omitempty" json:"slug,omitempty"`
+	PreviousSlugs    []string           `bson:"previous_slugs,omitempty" json:"previous_slugs,omitempty"`
+	Content          string             `bson:"content" json:"content"`
+	Author           string             `bson:"author,omitempty" json:"author,omitempty"`
+	Tags             []string           `bson:"tags,omitempty" json:"tags,omitempty"`
+	Status           string             `bson:"status" json:"status"`
+	SourceGUID       string             `bson:"source_guid,omitempty" json:"source_guid,omitempty"`
+	Language         string             `bson:"language,omitempty" json:"language,omitempty"`
+	TranslationGroup string             `bson:"translation_group,omitempty" json:"translation_group,omitempty"`
+	Attachments      []Attachment       `bson:"attachments,omitempty" json:"attachments,omitempty"`
+	FeaturedImage    string             `bson:"featured_image,omitempty" json:"featured_image,omitempty"`
+	Version          int64              `bson:"version" json:"version"`
+	CreatedAt        time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt        time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// Path returns the URL path of the post, preferring its slug over its ID
+func (p Post) Path() string {
+	if p.Slug != "" {
+		return "/posts/" + p.Slug
+	}
+	return "/posts/" + p.ID.Hex()
+}
+
+// Group returns the translation group of the post. A post that hasn't been
+// translated yet is a group of its own.
+func (p Post) Group() string {
+	if p.TranslationGroup != "" {
+		return p.TranslationGroup
+	}
+	return p.ID.Hex()
+}
+
+// Featured returns the image attachment shown with the post in lists and
+// on its page: the one FeaturedImage names, or else the first image. It
+// returns nil for posts without images.
+func (p Post) Featured() *Attachment {
+	var first *Attachment
+	for i := range p.Attachments {
+		attachment := &p.Attachments[i]
+		if !attachment.IsImage() {
+			continue
+		}
+		if attachment.ID == p.FeaturedImage {
+			return attachment
+		}
+		if first == nil {
+			first = attachment
+		}
+	}
+	return first
+}
+
+// plainExtension matches the file extensions attachment URLs end in
+var plainExtension = regexp.MustCompile(`^\.[a-z0-9]{1,10}$`)
+
+// Attachment is a file uploaded with a post. The file itself lives in the
+// media store under ID. Images have their size in pixels, and the resized
+// copies made of them in Variants, narrowest first, once they are ready.
+type Attachment struct {
+	ID          string         `bson:"id" json:"id"`
+	Filename    string         `bson:"filename" json:"filename"`
+	ContentType string         `bson:"content_type" json:"content_type"`
+	Size        int64          `bson:"size" json:"size"`
+	Width       int            `bson:"width,omitempty" json:"width,omitempty"`
+	Height      int            `bson:"height,omitempty" json:"height,omitempty"`
+	Variants    []ImageVariant `bson:"variants,omitempty" json:"variants,omitempty"`
+}
+
+// ImageVariant is a resized copy of an image attachment, stored in the media
+// store under ID
+type ImageVariant struct {
+	ID          string `bson:"id" json:"id"`
+	ContentType string `bson:"content_type" json:"content_type"`
+	Width       int    `bson:"width" json:"width"`
+	Height      int    `bson:"height" json:"height"`
+}
+
+// variantExtensions are the extensions of the URLs of image variants
+var variantExtensions = map[string]string{"image/jpeg": ".jpg", "image/png": ".png"}
+
+// URL returns the URL path the variant is served from
+func (v ImageVariant) URL() string {
+	return "/media/" + v.ID + variantExtensions[v.ContentType]
+}
+
+// URL returns the URL path the attachment is served from. It ends in the
+// extension of the uploaded file, so that copies on static hosts get the
+// right content type.
+func (a Attachment) URL() string {
+	ext := strings.ToLower(path.Ext(a.Filename))
+	if !plainExtension.MatchString(ext) {
+		ext = ""
+	}
+	return "/media/" + a.ID + ext
+}
+
+// IsImage reports whether the attachment can be shown inline as an image
+func (a Attachment) IsImage() bool {
+	return strings.HasPrefix(a.ContentType, "image/")
+}
+
+// Thumbnail returns the URL of the narrowest copy of the image at least
+// width pixels wide, or of the image itself when no copy is
+func (a Attachment) Thumbnail(width int) string {
+	for _, variant := range a.Variants {
+		if variant.Width >= width {
+			return variant.URL()
+		}
+	}
+	return a.URL()
+}
+
+// MediaIDs returns the IDs of the files of the attachment in the media store:
+// its own and those of its variants
+func (a Attachment) MediaIDs() []string {
+	ids := []string{a.ID}
+	for _, variant := range a.Variants {
+		ids = append(ids, variant.ID)
+	}
+	return ids
 }
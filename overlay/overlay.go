@@ -0,0 +1,76 @@
+package overlay
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"sort"
+)
+
+// FS layers a directory on disk over a base file system: files in the
+// directory take precedence, and directories list the entries of both. It
+// lets a deployment override single templates or assets embedded in the
+// binary without copying the rest.
+type FS struct {
+	upper fs.FS
+	base  fs.FS
+}
+
+// New returns base overlaid with the files in dir. With an empty dir it
+// returns base itself.
+func New(dir string, base fs.FS) fs.FS {
+	if dir == "" {
+		return base
+	}
+	return &FS{upper: os.DirFS(dir), base: base}
+}
+
+// Open opens the named file from the directory, or from the base when the
+// directory doesn't have it
+func (o *FS) Open(name string) (fs.File, error) {
+	f, err := o.upper.Open(name)
+	if err == nil || !errors.Is(err, fs.ErrNotExist) {
+		return f, err
+	}
+	return o.base.Open(name)
+}
+
+// Stat describes the named file as Open would find it
+func (o *FS) Stat(name string) (fs.FileInfo, error) {
+	info, err := fs.Stat(o.upper, name)
+	if err == nil || !errors.Is(err, fs.ErrNotExist) {
+		return info, err
+	}
+	return fs.Stat(o.base, name)
+}
+
+// ReadDir merges the entries of the named directory in both file systems,
+// preferring the directory's entry for names found in both
+func (o *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	upper, upperErr := fs.ReadDir(o.upper, name)
+	if upperErr != nil && !errors.Is(upperErr, fs.ErrNotExist) {
+		return nil, upperErr
+	}
+	base, baseErr := fs.ReadDir(o.base, name)
+	if baseErr != nil && !errors.Is(baseErr, fs.ErrNotExist) {
+		return nil, baseErr
+	}
+	if upperErr != nil && baseErr != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	entries := make(map[string]fs.DirEntry, len(upper)+len(base))
+	for _, entry := range base {
+		entries[entry.Name()] = entry
+	}
+	for _, entry := range upper {
+		entries[entry.Name()] = entry
+	}
+
+	merged := make([]fs.DirEntry, 0, len(entries))
+	for _, entry := range entries {
+		merged = append(merged, entry)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].Name() < merged[j].Name() })
+	return merged, nil
+}
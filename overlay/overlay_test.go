@@ -0,0 +1,64 @@
+//go:build unit
+
+package overlay
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	base := fstest.MapFS{
+		"layout.html":     {Data: []byte("base layout")},
+		"posts/show.html": {Data: []byte("base show")},
+		"posts/list.html": {Data: []byte("base list")},
+	}
+
+	assert.Equal(t, fs.FS(base), New("", base))
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "posts"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "posts", "show.html"), []byte("custom show"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "posts", "extra.html"), []byte("custom extra"), 0644))
+	files := New(dir, base)
+
+	for name, want := range map[string]string{
+		"layout.html":      "base layout",
+		"posts/show.html":  "custom show",
+		"posts/list.html":  "base list",
+		"posts/extra.html": "custom extra",
+	} {
+		data, err := fs.ReadFile(files, name)
+		require.NoError(t, err)
+		assert.Equal(t, want, string(data), name)
+	}
+
+	_, err := fs.ReadFile(files, "missing.html")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	matches, err := fs.Glob(files, "posts/*.html")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"posts/extra.html", "posts/list.html", "posts/show.html"}, matches)
+
+	info, err := fs.Stat(files, "posts/show.html")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len("custom show")), info.Size())
+
+	_, err = fs.ReadDir(files, "missing")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestNew_MissingDir(t *testing.T) {
+	base := fstest.MapFS{"layout.html": {Data: []byte("base layout")}}
+	files := New(filepath.Join(t.TempDir(), "missing"), base)
+
+	data, err := fs.ReadFile(files, "layout.html")
+	require.NoError(t, err)
+	assert.Equal(t, "base layout", string(data))
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/gekich/news-app/cache"
+	"github.com/gekich/news-app/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// CachedPostStore caches post lookups of a PostStore and invalidates the
+// cache on every write made through it. Writes made elsewhere, like by
+// another server or newsctl, show up once the entries expire.
+type CachedPostStore struct {
+	PostStore
+	cache *cache.Store
+}
+
+var _ PostStore = (*CachedPostStore)(nil)
+
+// NewCachedPostStore wraps store with the cache. The same cache can be
+// shared with rendered pages, so a write invalidates them too.
+func NewCachedPostStore(store PostStore, c *cache.Store) *CachedPostStore {
+	return &CachedPostStore{PostStore: store, cache: c}
+}
+
+// FindByID retrieves a post by its ID through the cache
+func (s *CachedPostStore) FindByID(ctx context.Context, id string) (models.Post, error) {
+	ctx = context.WithoutCancel(ctx)
+	return s.fetch("post:id:"+id, func() (models.Post, error) {
+		return s.PostStore.FindByID(ctx, id)
+	})
+}
+
+// FindBySlug retrieves a post by its current or a previous slug through the
+// cache
+func (s *CachedPostStore) FindBySlug(ctx context.Context, slug string) (models.Post, error) {
+	ctx = context.WithoutCancel(ctx)
+	return s.fetch("post:slug:"+slug, func() (models.Post, error) {
+		return s.PostStore.FindBySlug(ctx, slug)
+	})
+}
+
+// fetch caches a post as BSON. Lookup errors, including a missing post,
+// aren't cached. Lookups run without the caller's cancellation, since other
+// callers may be waiting on them.
+func (s *CachedPostStore) fetch(key string, find func() (models.Post, error)) (models.Post, error) {
+	var post models.Post
+
+	data, err := s.cache.Fetch(key, func() ([]byte, error) {
+		post, err := find()
+		if err != nil {
+			return nil, err
+		}
+		return bson.Marshal(post)
+	})
+	if err != nil {
+		return post, err
+	}
+
+	err = bson.Unmarshal(data, &post)
+	return post, err
+}
+
+// The writes below invalidate the cache once they return, whether or not
+// they succeeded
+
+func (s *CachedPostStore) Create(ctx context.Context, post models.Post) (string, error) {
+	defer s.cache.Invalidate()
+	return s.PostStore.Create(ctx, post)
+}
+
+func (s *CachedPostStore) Update(ctx context.Context, id string, post models.Post) error {
+	defer s.cache.Invalidate()
+	return s.PostStore.Update(ctx, id, post)
+}
+
+func (s *CachedPostStore) SetImageVariants(ctx context.Context, attachmentID string, variants []models.ImageVariant) error {
+	defer s.cache.Invalidate()
+	return s.PostStore.SetImageVariants(ctx, attachmentID, variants)
+}
+
+func (s *CachedPostStore) Delete(ctx context.Context, id string) error {
+	defer s.cache.Invalidate()
+	return s.PostStore.Delete(ctx, id)
+}
+
+func (s *CachedPostStore) DeleteVersion(ctx context.Context, id string, version int64) error {
+	defer s.cache.Invalidate()
+	return s.PostStore.DeleteVersion(ctx, id, version)
+}
+
+func (s *CachedPostStore) CreateMany(ctx context.Context, posts []models.Post) ([]string, error) {
+	defer s.cache.Invalidate()
+	return s.PostStore.CreateMany(ctx, posts)
+}
+
+func (s *CachedPostStore) ReplaceAll(ctx context.Context, posts []models.Post) error {
+	defer s.cache.Invalidate()
+	return s.PostStore.ReplaceAll(ctx, posts)
+}
+
+func (s *CachedPostStore) ReplaceAllBatched(ctx context.Context, fill func(insert func([]models.Post) error) error) error {
+	defer s.cache.Invalidate()
+	return s.PostStore.ReplaceAllBatched(ctx, fill)
+}
@@ -0,0 +1,88 @@
+//go:build unit
+
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gekich/news-app/cache"
+	"github.com/gekich/news-app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// lookupStore serves a single post and counts the lookups reaching it
+type lookupStore struct {
+	PostStore
+	post    models.Post
+	lookups int
+}
+
+func (s *lookupStore) FindByID(ctx context.Context, id string) (models.Post, error) {
+	s.lookups++
+	if id != s.post.ID.Hex() {
+		return models.Post{}, mongo.ErrNoDocuments
+	}
+	return s.post, nil
+}
+
+func (s *lookupStore) FindBySlug(ctx context.Context, slug string) (models.Post, error) {
+	s.lookups++
+	if slug != s.post.Slug {
+		return models.Post{}, mongo.ErrNoDocuments
+	}
+	return s.post, nil
+}
+
+func (s *lookupStore) Update(ctx context.Context, id string, post models.Post) error {
+	s.post = post
+	return nil
+}
+
+func TestCachedPostStore(t *testing.T) {
+	ctx := context.Background()
+	underlying := &lookupStore{post: models.Post{ID: primitive.NewObjectID(), Title: "Cached", Slug: "cached", Tags: []string{"news"}}}
+	store := NewCachedPostStore(underlying, cache.NewStore(cache.NewLRU(10, 0)))
+	id := underlying.post.ID.Hex()
+
+	for i := 0; i < 2; i++ {
+		post, err := store.FindByID(ctx, id)
+		require.NoError(t, err)
+		assert.Equal(t, underlying.post, post)
+
+		post, err = store.FindBySlug(ctx, "cached")
+		require.NoError(t, err)
+		assert.Equal(t, underlying.post, post)
+	}
+	assert.Equal(t, 2, underlying.lookups)
+
+	// Missing posts aren't cached
+	for i := 0; i < 2; i++ {
+		_, err := store.FindBySlug(ctx, "missing")
+		assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+	}
+	assert.Equal(t, 4, underlying.lookups)
+
+	updated := underlying.post
+	updated.Title = "Updated"
+	require.NoError(t, store.Update(ctx, id, updated))
+
+	post, err := store.FindByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "Updated", post.Title)
+	assert.Equal(t, 5, underlying.lookups)
+}
+
+func TestCachedPostStore_Uncached(t *testing.T) {
+	underlying := &lookupStore{post: models.Post{ID: primitive.NewObjectID()}}
+	store := NewCachedPostStore(underlying, nil)
+
+	for i := 0; i < 2; i++ {
+		_, err := store.FindByID(context.Background(), underlying.post.ID.Hex())
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, underlying.lookups)
+}
@@ -0,0 +1,281 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+
+	"github.com/gekich/news-app/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ErrInvalidCursor is returned for cursors that weren't produced by FindPage
+// for the requested sort
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// ErrInvalidSort is returned for unknown sorts, and for SortRelevance
+// without a search
+var ErrInvalidSort = errors.New("invalid sort")
+
+// Sorts of FindPage
+const (
+	SortNewest    = "newest"
+	SortOldest    = "oldest"
+	SortUpdated   = "updated"
+	SortTitle     = "title"
+	SortRelevance = "relevance"
+)
+
+// sortOrder orders posts by its fields and then _id, all in one direction
+type sortOrder struct {
+	fields    []string
+	direction int
+}
+
+var sortOrders = map[string]sortOrder{
+	SortNewest:  {fields: []string{"created_at"}, direction: -1},
+	SortOldest:  {fields: []string{"created_at"}, direction: 1},
+	SortUpdated: {fields: []string{"updated_at"}, direction: -1},
+	// Titles are compared case-insensitively through a lowercased copy
+	SortTitle: {fields: []string{"title_key"}, direction: 1},
+	// Better matches first, newest first among equal matches
+	SortRelevance: {fields: []string{"score", "created_at"}, direction: -1},
+}
+
+// ValidSort reports whether sort is one of the sorts of FindPage
+func ValidSort(sort string) bool {
+	_, ok := sortOrders[sort]
+	return ok
+}
+
+// Cursor is a position in a sorted list of posts: the sort, the values of
+// its fields for the post it points at, and the post's _id. It is handed to
+// clients as an opaque string.
+type Cursor struct {
+	Sort string             `bson:"s"`
+	Keys bson.A             `bson:"k"`
+	ID   primitive.ObjectID `bson:"i"`
+}
+
+// String encodes the cursor for use in URLs
+func (c Cursor) String() string {
+	data, err := bson.Marshal(c)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// ParseCursor decodes a cursor returned by Cursor.String
+func ParseCursor(s string) (Cursor, error) {
+	var c Cursor
+
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(data) == 0 {
+		return c, ErrInvalidCursor
+	}
+	if err := bson.Unmarshal(data, &c); err != nil {
+		return c, ErrInvalidCursor
+	}
+
+	order, ok := sortOrders[c.Sort]
+	if !ok || len(c.Keys) != len(order.fields) || c.ID.IsZero() {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// PageRequest selects a page of posts relative to a cursor. At most one of
+// After and Before is set; with neither, the first page is returned.
+type PageRequest struct {
+	Filter PostFilter
+	// Sort is one of the Sort constants, SortNewest when empty
+	Sort string
+	// After returns the posts following the cursor
+	After string
+	// Before returns the posts preceding the cursor
+	Before string
+	Limit  int64
+	// SkipCount leaves Total unset, saving a CountDocuments on large collections
+	SkipCount bool
+}
+
+// PostPage is a page of posts with the cursors of its neighbours
+type PostPage struct {
+	Posts []models.Post
+	// NextCursor continues with the following posts, PrevCursor with the
+	// preceding ones. They are empty at the ends of the list.
+	NextCursor string
+	PrevCursor string
+	// Total is the number of posts matching the filter, or -1 when the count
+	// was skipped
+	Total int64
+}
+
+// pageDocument is a post along with the computed fields it was sorted by
+type pageDocument struct {
+	models.Post `bson:",inline"`
+	Score       int    `bson:"score,omitempty"`
+	TitleKey    string `bson:"title_key,omitempty"`
+}
+
+// cursor returns the cursor pointing at the document in the given sort
+func (d pageDocument) cursor(sort string) Cursor {
+	c := Cursor{Sort: sort, ID: d.ID}
+	for _, field := range sortOrders[sort].fields {
+		switch field {
+		case "created_at":
+			c.Keys = append(c.Keys, d.CreatedAt)
+		case "updated_at":
+			c.Keys = append(c.Keys, d.UpdatedAt)
+		case "title_key":
+			c.Keys = append(c.Keys, d.TitleKey)
+		case "score":
+			c.Keys = append(c.Keys, d.Score)
+		}
+	}
+	return c
+}
+
+// FindPage returns a page of posts in the requested sort using keyset
+// pagination over the sort fields and _id. Unlike FindAll's skip/limit, the
+// cost doesn't grow with the page number and pages don't shift when posts
+// are added.
+func (r *PostRepository) FindPage(ctx context.Context, req PageRequest) (PostPage, error) {
+	page := PostPage{Total: -1}
+
+	if req.Sort == "" {
+		req.Sort = SortNewest
+	}
+	order, ok := sortOrders[req.Sort]
+	if !ok || (req.Sort == SortRelevance && req.Filter.Search == "") {
+		return page, ErrInvalidSort
+	}
+	if req.After != "" && req.Before != "" {
+		return page, ErrInvalidCursor
+	}
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: req.Filter.bson()}}}
+	switch req.Sort {
+	case SortTitle:
+		pipeline = append(pipeline, bson.D{{Key: "$addFields", Value: bson.M{"title_key": bson.M{"$toLower": "$title"}}}})
+	case SortRelevance:
+		pipeline = append(pipeline, bson.D{{Key: "$addFields", Value: bson.M{"score": relevance(req.Filter.Search)}}})
+	}
+
+	// Paging backward walks the list in reverse and flips the page afterwards
+	backward := req.Before != ""
+	direction := order.direction
+	if backward {
+		direction = -direction
+	}
+
+	if ref := req.After + req.Before; ref != "" {
+		cursor, err := ParseCursor(ref)
+		if err != nil {
+			return page, err
+		}
+		if cursor.Sort != req.Sort {
+			return page, ErrInvalidCursor
+		}
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: keyset(order.fields, cursor, direction)}})
+	}
+
+	sortDoc := bson.D{}
+	for _, field := range order.fields {
+		sortDoc = append(sortDoc, bson.E{Key: field, Value: direction})
+	}
+	sortDoc = append(sortDoc, bson.E{Key: "_id", Value: direction})
+	pipeline = append(pipeline, bson.D{{Key: "$sort", Value: sortDoc}})
+
+	if req.Limit > 0 {
+		// One extra post tells whether there is another page
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: req.Limit + 1}})
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return page, err
+	}
+	var documents []pageDocument
+	if err := cursor.All(ctx, &documents); err != nil {
+		return page, err
+	}
+
+	more := req.Limit > 0 && int64(len(documents)) > req.Limit
+	if more {
+		documents = documents[:req.Limit]
+	}
+	if backward {
+		for i, j := 0, len(documents)-1; i < j; i, j = i+1, j-1 {
+			documents[i], documents[j] = documents[j], documents[i]
+		}
+	}
+
+	if len(documents) > 0 {
+		first, last := documents[0], documents[len(documents)-1]
+		// Paging forward came from the preceding posts and paging backward
+		// from the following ones, so that direction always has a page
+		if (more && !backward) || req.Before != "" {
+			page.NextCursor = last.cursor(req.Sort).String()
+		}
+		if (more && backward) || req.After != "" {
+			page.PrevCursor = first.cursor(req.Sort).String()
+		}
+	}
+
+	page.Posts = make([]models.Post, len(documents))
+	for i, document := range documents {
+		page.Posts[i] = document.Post
+	}
+
+	if !req.SkipCount {
+		if page.Total, err = r.collection.CountDocuments(ctx, req.Filter.bson()); err != nil {
+			return page, err
+		}
+	}
+
+	return page, nil
+}
+
+// keyset matches the posts past the cursor when walking the sort fields and
+// _id in the given direction
+func keyset(fields []string, c Cursor, direction int) bson.M {
+	op := "$gt"
+	if direction < 0 {
+		op = "$lt"
+	}
+
+	// (a, b, _id) > (x, y, id) expands to a > x, or a = x and b > y, or
+	// a = x and b = y and _id > id
+	var or []bson.M
+	equal := bson.M{}
+	for i, field := range fields {
+		branch := bson.M{field: bson.M{op: c.Keys[i]}}
+		for k, v := range equal {
+			branch[k] = v
+		}
+		or = append(or, branch)
+		equal[field] = c.Keys[i]
+	}
+	last := bson.M{"_id": bson.M{op: c.ID}}
+	for k, v := range equal {
+		last[k] = v
+	}
+	or = append(or, last)
+
+	return bson.M{"$or": or}
+}
+
+// relevance scores how well a post matches the search: a match in the title
+// counts twice as much as one in the content
+func relevance(search string) bson.M {
+	matches := func(field string) bson.M {
+		return bson.M{"$regexMatch": bson.M{"input": field, "regex": searchPattern(search), "options": "i"}}
+	}
+	return bson.M{"$add": bson.A{
+		bson.M{"$cond": bson.A{matches("$title"), 2, 0}},
+		bson.M{"$cond": bson.A{matches("$content"), 1, 0}},
+	}}
+}
@@ -0,0 +1,45 @@
+//go:build unit
+
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2025, 3, 14, 15, 9, 26, 535000000, time.UTC)
+	cursor := Cursor{
+		Sort: SortRelevance,
+		Keys: bson.A{3, createdAt},
+		ID:   primitive.NewObjectID(),
+	}
+
+	parsed, err := ParseCursor(cursor.String())
+	if err != nil {
+		t.Fatalf("ParseCursor(%q) failed: %v", cursor.String(), err)
+	}
+	if parsed.Sort != SortRelevance || parsed.ID != cursor.ID {
+		t.Errorf("Expected %+v, got %+v", cursor, parsed)
+	}
+	if score, ok := parsed.Keys[0].(int32); !ok || score != 3 {
+		t.Errorf("Expected score 3, got %#v", parsed.Keys[0])
+	}
+	if date, ok := parsed.Keys[1].(primitive.DateTime); !ok || !date.Time().Equal(createdAt) {
+		t.Errorf("Expected created_at %v, got %#v", createdAt, parsed.Keys[1])
+	}
+}
+
+func TestParseCursorInvalid(t *testing.T) {
+	wrongKeys := Cursor{Sort: SortRelevance, Keys: bson.A{3}, ID: primitive.NewObjectID()}
+	unknownSort := Cursor{Sort: "popular", Keys: bson.A{3}, ID: primitive.NewObjectID()}
+
+	for _, value := range []string{"", "not-a-cursor", "MTIz", wrongKeys.String(), unknownSort.String()} {
+		if _, err := ParseCursor(value); err != ErrInvalidCursor {
+			t.Errorf("ParseCursor(%q) = %v, expected ErrInvalidCursor", value, err)
+		}
+	}
+}
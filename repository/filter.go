@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// PostFilter narrows down the posts returned by a query. Zero values are ignored.
+type PostFilter struct {
+	Search string
+	// From and To bound created_at, inclusive of From and exclusive of To
+	From   time.Time
+	To     time.Time
+	Status string
+	// Tag and Author match exactly
+	Tag    string
+	Author string
+	// Language keeps the posts in that language. FallbackLanguage is the
+	// language of posts without one, and with Language set also keeps the
+	// posts in it whose story has no translation into Language.
+	Language         string
+	FallbackLanguage string
+	// Skip and Limit select a window of the sorted results; zero Limit means no limit
+	Skip  int64
+	Limit int64
+}
+
+// bson converts the filter into a MongoDB query document
+func (f PostFilter) bson() bson.M {
+	filter := bson.M{}
+
+	if f.Search != "" {
+		// Search in both title and content fields
+		pattern := searchPattern(f.Search)
+		filter["$or"] = []bson.M{
+			{"title": bson.M{"$regex": pattern, "$options": "i"}},
+			{"content": bson.M{"$regex": pattern, "$options": "i"}},
+		}
+	}
+
+	createdAt := bson.M{}
+	if !f.From.IsZero() {
+		createdAt["$gte"] = f.From
+	}
+	if !f.To.IsZero() {
+		createdAt["$lt"] = f.To
+	}
+	if len(createdAt) > 0 {
+		filter["created_at"] = createdAt
+	}
+
+	if f.Status != "" {
+		filter["status"] = f.Status
+	}
+	if f.Tag != "" {
+		filter["tags"] = f.Tag
+	}
+	if f.Author != "" {
+		filter["author"] = f.Author
+	}
+
+	if f.Language != "" {
+		language := bson.M{"language": f.language(f.Language)}
+		if f.FallbackLanguage != "" && f.FallbackLanguage != f.Language {
+			// group_languages lists the languages of a post's translation
+			// group, and is missing on posts without translations
+			language = bson.M{"$or": []bson.M{
+				language,
+				{"language": f.language(f.FallbackLanguage), groupLanguagesField: bson.M{"$ne": f.Language}},
+			}}
+		}
+		// Searches take the top-level $or
+		filter["$and"] = []bson.M{language}
+	}
+
+	return filter
+}
+
+// language matches the posts in the given language, counting posts without
+// one as in the fallback language
+func (f PostFilter) language(language string) interface{} {
+	if language == f.FallbackLanguage {
+		return bson.M{"$in": bson.A{language, nil}}
+	}
+	return language
+}
+
+// searchPattern is the regular expression matching the search text literally
+func searchPattern(search string) string {
+	return regexp.QuoteMeta(search)
+}
@@ -0,0 +1,50 @@
+//go:build unit
+
+package repository
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestPostFilter_Language(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter PostFilter
+		want   bson.M
+	}{
+		{
+			name:   "no language",
+			filter: PostFilter{FallbackLanguage: "en"},
+			want:   bson.M{},
+		},
+		{
+			name:   "language only",
+			filter: PostFilter{Language: "uk"},
+			want:   bson.M{"$and": []bson.M{{"language": "uk"}}},
+		},
+		{
+			name:   "fallback language",
+			filter: PostFilter{Language: "en", FallbackLanguage: "en"},
+			want:   bson.M{"$and": []bson.M{{"language": bson.M{"$in": bson.A{"en", nil}}}}},
+		},
+		{
+			name:   "other language",
+			filter: PostFilter{Language: "uk", FallbackLanguage: "en"},
+			want: bson.M{"$and": []bson.M{{"$or": []bson.M{
+				{"language": "uk"},
+				{"language": bson.M{"$in": bson.A{"en", nil}}, "group_languages": bson.M{"$ne": "uk"}},
+			}}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.bson(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
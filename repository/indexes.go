@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// postIndexes declares the indexes the posts collection is expected to have
+func postIndexes() []mongo.IndexModel {
+	return []mongo.IndexModel{
+		{
+			// Backs the newest-first listing in FindAll and FindPage, and the oldest-first one in reverse
+			Keys:    bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+			Options: options.Index().SetName("created_at_desc"),
+		},
+		{
+			Keys:    bson.D{{Key: "updated_at", Value: -1}},
+			Options: options.Index().SetName("updated_at_desc"),
+		},
+		{
+			// Back the tag and author filters of the post list
+			Keys:    bson.D{{Key: "tags", Value: 1}},
+			Options: options.Index().SetName("tags"),
+		},
+		{
+			Keys:    bson.D{{Key: "author", Value: 1}},
+			Options: options.Index().SetName("author"),
+		},
+		{
+			// Back the language of the post list and the translations of a post
+			Keys:    bson.D{{Key: "language", Value: 1}},
+			Options: options.Index().SetName("language"),
+		},
+		{
+			Keys:    bson.D{{Key: "translation_group", Value: 1}, {Key: "language", Value: 1}},
+			Options: options.Index().SetName("translation_group_language"),
+		},
+		{
+			// Prevents importing the same external post twice
+			Keys: bson.D{{Key: "source_guid", Value: 1}},
+			Options: options.Index().
+				SetName("source_guid_unique").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"source_guid": bson.M{"$type": "string"}}),
+		},
+		{
+			// Resolves /posts/{slug} and guards against two posts sharing a slug
+			Keys: bson.D{{Key: "slug", Value: 1}},
+			Options: options.Index().
+				SetName("slug_unique").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"slug": bson.M{"$type": "string"}}),
+		},
+		{
+			// Finds the post of an attachment when its resized copies are ready
+			Keys:    bson.D{{Key: "attachments.id", Value: 1}},
+			Options: options.Index().SetName("attachments_id"),
+		},
+		{
+			// Resolves old slugs to redirect them to the current one
+			Keys:    bson.D{{Key: "previous_slugs", Value: 1}},
+			Options: options.Index().SetName("previous_slugs"),
+		},
+	}
+}
+
+// EnsureIndexes creates any declared indexes that are missing from the posts collection.
+// Creating an index that already exists with the same definition is a no-op.
+func (r *PostRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, postIndexes())
+	return err
+}
@@ -2,9 +2,12 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/gekich/news-app/models"
+	"github.com/gekich/news-app/slug"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -13,6 +16,20 @@ import (
 
 const postCollection = "posts"
 
+// ErrVersionConflict is returned by Update when the post was changed by
+// someone else since the version being saved was read
+var ErrVersionConflict = errors.New("post was modified since it was loaded")
+
+// parseID parses a post ID. Strings that aren't ObjectIDs can't be the ID of
+// any post, so they are reported as mongo.ErrNoDocuments like unknown IDs.
+func parseID(id string) (primitive.ObjectID, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return objectID, mongo.ErrNoDocuments
+	}
+	return objectID, nil
+}
+
 // PostRepository handles database operations for posts
 type PostRepository struct {
 	collection *mongo.Collection
@@ -28,7 +45,7 @@ func NewPostRepository(db *mongo.Database) *PostRepository {
 // FindAll retrieves all posts with optional pagination and search
 func (r *PostRepository) FindAll(ctx context.Context, page, limit int64, search string) ([]models.Post, int64, error) {
 	opts := options.Find()
-	opts.SetSort(bson.D{{"created_at", -1}})
+	opts.SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
 
 	if limit > 0 {
 		opts.SetSkip((page - 1) * limit)
@@ -36,16 +53,7 @@ func (r *PostRepository) FindAll(ctx context.Context, page, limit int64, search
 	}
 
 	// Create filter based on search parameter
-	filter := bson.M{}
-	if search != "" {
-		// Search in both title and content fields
-		filter = bson.M{
-			"$or": []bson.M{
-				{"title": bson.M{"$regex": search, "$options": "i"}},
-				{"content": bson.M{"$regex": search, "$options": "i"}},
-			},
-		}
-	}
+	filter := PostFilter{Search: search}.bson()
 
 	cursor, err := r.collection.Find(ctx, filter, opts)
 	if err != nil {
@@ -71,11 +79,67 @@ func (r *PostRepository) FindAll(ctx context.Context, page, limit int64, search
 	return posts, totalPages, nil
 }
 
+// Stream calls fn for every post matching filter, newest first, without loading
+// the whole result set into memory. Iteration stops at the first error fn returns.
+func (r *PostRepository) Stream(ctx context.Context, filter PostFilter, fn func(models.Post) error) error {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+	if filter.Skip > 0 {
+		opts.SetSkip(filter.Skip)
+	}
+	if filter.Limit > 0 {
+		opts.SetLimit(filter.Limit)
+	}
+
+	cursor, err := r.collection.Find(ctx, filter.bson(), opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var post models.Post
+		if err := cursor.Decode(&post); err != nil {
+			return err
+		}
+		if err := fn(post); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
+
+// Count returns the number of posts matching the filter, ignoring Skip and Limit
+func (r *PostRepository) Count(ctx context.Context, filter PostFilter) (int64, error) {
+	return r.collection.CountDocuments(ctx, filter.bson())
+}
+
+// ExistingSourceGUIDs returns which of the given source GUIDs already belong to a post
+func (r *PostRepository) ExistingSourceGUIDs(ctx context.Context, guids []string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	if len(guids) == 0 {
+		return existing, nil
+	}
+
+	values, err := r.collection.Distinct(ctx, "source_guid", bson.M{"source_guid": bson.M{"$in": guids}})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, value := range values {
+		if guid, ok := value.(string); ok {
+			existing[guid] = true
+		}
+	}
+
+	return existing, nil
+}
+
 // FindByID retrieves a post by its ID
 func (r *PostRepository) FindByID(ctx context.Context, id string) (models.Post, error) {
 	var post models.Post
 
-	objectID, err := primitive.ObjectIDFromHex(id)
+	objectID, err := parseID(id)
 	if err != nil {
 		return post, err
 	}
@@ -89,63 +153,316 @@ func (r *PostRepository) Create(ctx context.Context, post models.Post) (string,
 	now := time.Now()
 	post.CreatedAt = now
 	post.UpdatedAt = now
+	post.Version = 1
+	if post.Status == "" {
+		post.Status = models.StatusPublished
+	}
 
-	result, err := r.collection.InsertOne(ctx, post)
+	var result *mongo.InsertOneResult
+	requested := post.Slug
+	err := retrySlugConflict(func() error {
+		posts := []models.Post{post}
+		posts[0].Slug = requested
+		if err := assignSlugs(ctx, r.collection, posts, primitive.NilObjectID); err != nil {
+			return err
+		}
+
+		var err error
+		result, err = r.collection.InsertOne(ctx, posts[0])
+		return err
+	})
 	if err != nil {
 		return "", err
 	}
 
 	id := result.InsertedID.(primitive.ObjectID).Hex()
-	return id, nil
+
+	// The post the translation was made from joins the group on its first
+	// translation
+	if post.TranslationGroup != "" {
+		if source, err := primitive.ObjectIDFromHex(post.TranslationGroup); err == nil {
+			_, err := r.collection.UpdateOne(ctx,
+				bson.M{"_id": source, "translation_group": bson.M{"$exists": false}},
+				bson.M{"$set": bson.M{"translation_group": post.TranslationGroup}},
+			)
+			if err != nil {
+				return id, err
+			}
+		}
+	}
+
+	return id, syncGroupLanguages(ctx, r.collection, post.TranslationGroup)
 }
 
-// Update modifies an existing post
+// FindTranslations returns the posts of a translation group, see
+// models.Post.Group, ordered by language
+func (r *PostRepository) FindTranslations(ctx context.Context, group string) ([]models.Post, error) {
+	filter := bson.M{"translation_group": group}
+	if source, err := primitive.ObjectIDFromHex(group); err == nil {
+		filter = bson.M{"$or": []bson.M{filter, {"_id": source}}}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "language", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var posts []models.Post
+	if err := cursor.All(ctx, &posts); err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// Update modifies an existing post. post.Version must be the version the
+// changes were made against; when the stored post has moved on since, nothing
+// is written and ErrVersionConflict is returned. A successful update bumps the
+// version. When the title changes enough to change its slug, the post gets a
+// new slug and the old one is kept in its history.
 func (r *PostRepository) Update(ctx context.Context, id string, post models.Post) error {
-	objectID, err := primitive.ObjectIDFromHex(id)
+	objectID, err := parseID(id)
 	if err != nil {
 		return err
 	}
 
+	var current models.Post
+	opts := options.FindOne().SetProjection(bson.M{"title": 1, "slug": 1, "previous_slugs": 1, "version": 1, "translation_group": 1})
+	if err := r.collection.FindOne(ctx, bson.M{"_id": objectID}, opts).Decode(&current); err != nil {
+		return err
+	}
+	if current.Version != post.Version {
+		return ErrVersionConflict
+	}
+
 	post.UpdatedAt = time.Now()
 
-	update := bson.M{
-		"$set": bson.M{
-			"title":      post.Title,
-			"content":    post.Content,
-			"updated_at": post.UpdatedAt,
-		},
+	fields := bson.M{
+		"title":      post.Title,
+		"content":    post.Content,
+		"updated_at": post.UpdatedAt,
+	}
+	if post.Status != "" {
+		fields["status"] = post.Status
+	}
+	if post.Language != "" {
+		fields["language"] = post.Language
+	}
+	// The post is saved with the attachments it is given, so removing the
+	// last one stores an empty list
+	fields["attachments"] = post.Attachments
+	if post.Attachments == nil {
+		fields["attachments"] = []models.Attachment{}
 	}
+	fields["featured_image"] = post.FeaturedImage
 
-	_, err = r.collection.UpdateOne(ctx, bson.M{"_id": objectID}, update)
-	return err
+	err = retrySlugConflict(func() error {
+		if current.Slug == "" || slug.Make(post.Title) != slug.Make(current.Title) {
+			renamed := []models.Post{{Title: post.Title}}
+			if err := assignSlugs(ctx, r.collection, renamed, objectID); err != nil {
+				return err
+			}
+			if renamed[0].Slug != current.Slug {
+				fields["slug"] = renamed[0].Slug
+				fields["previous_slugs"] = slugHistory(current.PreviousSlugs, current.Slug, renamed[0].Slug)
+			}
+		}
+
+		// The version in the filter makes the check and the write atomic, so
+		// of two concurrent saves of the same version only one matches
+		filter := bson.M{"_id": objectID, "version": versionValue(post.Version)}
+		result, err := r.collection.UpdateOne(ctx, filter, bson.M{
+			"$set": fields,
+			"$inc": bson.M{"version": 1},
+		})
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return ErrVersionConflict
+		}
+		return nil
+	})
+	if err != nil || post.Language == "" {
+		return err
+	}
+	return syncGroupLanguages(ctx, r.collection, current.TranslationGroup)
+}
+
+// SetImageVariants records the resized copies of an image attachment on the
+// post that has it, returning mongo.ErrNoDocuments when no post has it
+// anymore. The version is left alone, so that an edit started before the
+// copies were ready still saves, but updated_at moves on so that pages
+// showing the image are rendered again.
+func (r *PostRepository) SetImageVariants(ctx context.Context, attachmentID string, variants []models.ImageVariant) error {
+	result, err := r.collection.UpdateOne(ctx, bson.M{"attachments.id": attachmentID}, bson.M{
+		"$set": bson.M{"attachments.$.variants": variants, "updated_at": time.Now()},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return nil
+}
+
+// versionValue matches a stored version. Posts written before versioning have
+// no version field, which reads as version 0.
+func versionValue(version int64) interface{} {
+	if version == 0 {
+		return bson.M{"$in": bson.A{0, nil}}
+	}
+	return version
 }
 
 // Delete removes a post from the repository by its ID
 func (r *PostRepository) Delete(ctx context.Context, id string) error {
-	objectID, err := primitive.ObjectIDFromHex(id)
+	objectID, err := parseID(id)
 	if err != nil {
 		return err
 	}
 
-	_, err = r.collection.DeleteOne(ctx, bson.M{"_id": objectID})
+	err = r.deleteOne(ctx, bson.M{"_id": objectID})
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil
+	}
 	return err
 }
 
-// CreateMany inserts multiple posts into the repository
-func (r *PostRepository) CreateMany(ctx context.Context, posts []models.Post) error {
+// deleteOne deletes the post matching filter, if any, and updates the
+// languages of its translation group. It returns mongo.ErrNoDocuments when
+// no post matches.
+func (r *PostRepository) deleteOne(ctx context.Context, filter bson.M) error {
+	var deleted models.Post
+	opts := options.FindOneAndDelete().SetProjection(bson.M{"translation_group": 1})
+	if err := r.collection.FindOneAndDelete(ctx, filter, opts).Decode(&deleted); err != nil {
+		return err
+	}
+	return syncGroupLanguages(ctx, r.collection, deleted.TranslationGroup)
+}
+
+// DeleteVersion removes a post only while it is still at the given version,
+// returning ErrVersionConflict when it has been changed since and
+// mongo.ErrNoDocuments when it doesn't exist
+func (r *PostRepository) DeleteVersion(ctx context.Context, id string, version int64) error {
+	objectID, err := parseID(id)
+	if err != nil {
+		return err
+	}
+
+	err = r.deleteOne(ctx, bson.M{"_id": objectID, "version": versionValue(version)})
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+
+	if err := r.collection.FindOne(ctx, bson.M{"_id": objectID}).Err(); err != nil {
+		return err
+	}
+	return ErrVersionConflict
+}
+
+// CreateMany inserts multiple posts into the repository and returns their IDs in order.
+// Timestamps that are already set on a post are preserved.
+func (r *PostRepository) CreateMany(ctx context.Context, posts []models.Post) ([]string, error) {
 	if len(posts) == 0 {
-		return nil
+		return nil, nil
+	}
+
+	if err := assignSlugs(ctx, r.collection, posts, primitive.NilObjectID); err != nil {
+		return nil, err
+	}
+
+	result, err := r.collection.InsertMany(ctx, prepareDocuments(posts, time.Now()))
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(result.InsertedIDs))
+	for i, id := range result.InsertedIDs {
+		ids[i] = id.(primitive.ObjectID).Hex()
+	}
+
+	return ids, syncGroupLanguages(ctx, r.collection, translationGroups(posts)...)
+}
+
+// ReplaceAll atomically replaces every post in the repository with the given posts
+func (r *PostRepository) ReplaceAll(ctx context.Context, posts []models.Post) error {
+	return r.ReplaceAllBatched(ctx, func(insert func([]models.Post) error) error {
+		return insert(posts)
+	})
+}
+
+// ReplaceAllBatched atomically replaces every post in the repository with the posts
+// that fill passes to insert, one batch at a time. The new posts are written to a
+// staging collection which is then renamed over the posts collection, so readers
+// never observe a partially written or empty list.
+func (r *PostRepository) ReplaceAllBatched(ctx context.Context, fill func(insert func([]models.Post) error) error) error {
+	database := r.collection.Database()
+	stagingName := fmt.Sprintf("%s_staging_%s", postCollection, primitive.NewObjectID().Hex())
+	staging := database.Collection(stagingName)
+
+	if err := database.CreateCollection(ctx, stagingName); err != nil {
+		return err
 	}
 
 	now := time.Now()
+	insert := func(posts []models.Post) error {
+		if len(posts) == 0 {
+			return nil
+		}
+		if err := assignSlugs(ctx, staging, posts, primitive.NilObjectID); err != nil {
+			return err
+		}
+		if _, err := staging.InsertMany(ctx, prepareDocuments(posts, now)); err != nil {
+			return err
+		}
+		return syncGroupLanguages(ctx, staging, translationGroups(posts)...)
+	}
+
+	err := func() error {
+		if err := fill(insert); err != nil {
+			return err
+		}
+
+		if _, err := staging.Indexes().CreateMany(ctx, postIndexes()); err != nil {
+			return err
+		}
+
+		return database.Client().Database("admin").RunCommand(ctx, bson.D{
+			{Key: "renameCollection", Value: database.Name() + "." + stagingName},
+			{Key: "to", Value: database.Name() + "." + postCollection},
+			{Key: "dropTarget", Value: true},
+		}).Err()
+	}()
+	if err != nil {
+		staging.Drop(ctx)
+		return err
+	}
+
+	return nil
+}
+
+// prepareDocuments fills in missing timestamps, status and version and converts posts into insertable documents
+func prepareDocuments(posts []models.Post, now time.Time) []interface{} {
 	documents := make([]interface{}, len(posts))
 
 	for i := range posts {
-		posts[i].CreatedAt = now
-		posts[i].UpdatedAt = now
+		if posts[i].CreatedAt.IsZero() {
+			posts[i].CreatedAt = now
+		}
+		if posts[i].UpdatedAt.IsZero() {
+			posts[i].UpdatedAt = posts[i].CreatedAt
+		}
+		if posts[i].Status == "" {
+			posts[i].Status = models.StatusPublished
+		}
+		if posts[i].Version == 0 {
+			posts[i].Version = 1
+		}
 		documents[i] = posts[i]
 	}
 
-	_, err := r.collection.InsertMany(ctx, documents)
-	return err
+	return documents
 }
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/gekich/news-app/models"
+	"github.com/gekich/news-app/seeder"
 	"github.com/ory/dockertest/v3"
 	"github.com/ory/dockertest/v3/docker"
 	"github.com/stretchr/testify/assert"
@@ -122,6 +123,13 @@ func TestPostRepository_FindByID(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, post.Title, foundPost.Title)
 	assert.Equal(t, post.Content, foundPost.Content)
+
+	// IDs that aren't ObjectIDs are unknown posts rather than failures
+	_, err = repository.FindByID(context.Background(), "not-an-id")
+	assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+	assert.ErrorIs(t, repository.Update(context.Background(), "not-an-id", post), mongo.ErrNoDocuments)
+	assert.ErrorIs(t, repository.Delete(context.Background(), "not-an-id"), mongo.ErrNoDocuments)
+	assert.ErrorIs(t, repository.DeleteVersion(context.Background(), "not-an-id", 1), mongo.ErrNoDocuments)
 }
 
 func TestPostRepository_Update(t *testing.T) {
@@ -155,6 +163,44 @@ func TestPostRepository_Update(t *testing.T) {
 	assert.False(t, retrievedPost.UpdatedAt.IsZero())
 }
 
+func TestPostRepository_UpdateVersionConflict(t *testing.T) {
+	ctx := context.Background()
+	_, err := repository.collection.DeleteMany(ctx, bson.M{})
+	require.NoError(t, err)
+
+	id, err := repository.Create(ctx, models.Post{Title: "Original Title", Content: "Original Content"})
+	require.NoError(t, err)
+
+	first, err := repository.FindByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), first.Version)
+	second := first
+
+	first.Content = "First editor"
+	require.NoError(t, repository.Update(ctx, id, first))
+
+	second.Content = "Second editor"
+	err = repository.Update(ctx, id, second)
+	assert.ErrorIs(t, err, ErrVersionConflict)
+
+	stored, err := repository.FindByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "First editor", stored.Content)
+	assert.Equal(t, int64(2), stored.Version)
+
+	// Saving against the current version overwrites
+	second.Version = stored.Version
+	require.NoError(t, repository.Update(ctx, id, second))
+
+	stored, err = repository.FindByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "Second editor", stored.Content)
+	assert.Equal(t, int64(3), stored.Version)
+
+	err = repository.Update(ctx, primitive.NewObjectID().Hex(), second)
+	assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+}
+
 func TestPostRepository_Delete(t *testing.T) {
 	_, err := repository.collection.DeleteMany(context.Background(), bson.M{})
 	require.NoError(t, err)
@@ -178,6 +224,30 @@ func TestPostRepository_Delete(t *testing.T) {
 	assert.Equal(t, int64(0), count)
 }
 
+func TestPostRepository_DeleteVersion(t *testing.T) {
+	ctx := context.Background()
+	_, err := repository.collection.DeleteMany(ctx, bson.M{})
+	require.NoError(t, err)
+
+	id, err := repository.Create(ctx, models.Post{Title: "Versioned Post", Content: "Content"})
+	require.NoError(t, err)
+
+	post, err := repository.FindByID(ctx, id)
+	require.NoError(t, err)
+	require.NoError(t, repository.Update(ctx, id, post))
+
+	// The post was saved since version 1 was loaded
+	err = repository.DeleteVersion(ctx, id, 1)
+	assert.ErrorIs(t, err, ErrVersionConflict)
+
+	require.NoError(t, repository.DeleteVersion(ctx, id, 2))
+	_, err = repository.FindByID(ctx, id)
+	assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+
+	err = repository.DeleteVersion(ctx, id, 2)
+	assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+}
+
 func TestPostRepository_FindAll(t *testing.T) {
 	_, err := repository.collection.DeleteMany(context.Background(), bson.M{})
 	require.NoError(t, err)
@@ -232,6 +302,31 @@ func TestPostRepository_FindAll(t *testing.T) {
 	assert.Equal(t, "Post 3", foundPosts[0].Title)
 }
 
+func TestPostRepository_FindAll_Fixtures(t *testing.T) {
+	_, err := repository.collection.DeleteMany(context.Background(), bson.M{})
+	require.NoError(t, err)
+
+	fixture, err := seeder.LoadFixtureFile("../testdata/fixtures/posts.yaml")
+	require.NoError(t, err)
+
+	ids, err := seeder.LoadFixture(context.Background(), repository, fixture)
+	require.NoError(t, err)
+	require.Len(t, ids, 3)
+
+	foundPosts, totalPages, err := repository.FindAll(context.Background(), 1, 2, "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), totalPages)
+	require.Len(t, foundPosts, 2)
+	assert.Equal(t, ids["quantum"], foundPosts[0].ID.Hex())
+	assert.Equal(t, ids["festival"], foundPosts[1].ID.Hex())
+	assert.Equal(t, []string{"technology", "science"}, foundPosts[0].Tags)
+	assert.Equal(t, "Alice Morgan", foundPosts[0].Author)
+
+	post, err := repository.FindByID(context.Background(), ids["exoplanet"])
+	require.NoError(t, err)
+	assert.Equal(t, "Water Vapor Found on Nearby Exoplanet", post.Title)
+}
+
 func TestPostRepository_CreateMany(t *testing.T) {
 	_, err := repository.collection.DeleteMany(context.Background(), bson.M{})
 	require.NoError(t, err)
@@ -247,8 +342,9 @@ func TestPostRepository_CreateMany(t *testing.T) {
 		},
 	}
 
-	err = repository.CreateMany(context.Background(), posts)
+	ids, err := repository.CreateMany(context.Background(), posts)
 	require.NoError(t, err)
+	assert.Len(t, ids, 2)
 
 	count, err := repository.collection.CountDocuments(context.Background(), bson.M{})
 	require.NoError(t, err)
@@ -265,3 +361,381 @@ func TestPostRepository_CreateMany(t *testing.T) {
 		assert.False(t, post.UpdatedAt.IsZero())
 	}
 }
+
+func TestPostRepository_ExistingSourceGUIDs(t *testing.T) {
+	_, err := repository.collection.DeleteMany(context.Background(), bson.M{})
+	require.NoError(t, err)
+
+	_, err = repository.CreateMany(context.Background(), []models.Post{
+		{Title: "Imported Post", Content: "Imported Content", SourceGUID: "https://example.com/?p=1"},
+		{Title: "Local Post", Content: "Local Content"},
+	})
+	require.NoError(t, err)
+
+	existing, err := repository.ExistingSourceGUIDs(context.Background(), []string{"https://example.com/?p=1", "https://example.com/?p=2"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"https://example.com/?p=1": true}, existing)
+}
+
+func TestPostRepository_Slugs(t *testing.T) {
+	ctx := context.Background()
+	_, err := repository.collection.DeleteMany(ctx, bson.M{})
+	require.NoError(t, err)
+
+	firstID, err := repository.Create(ctx, models.Post{Title: "Café Opens Downtown", Content: "Content"})
+	require.NoError(t, err)
+	secondID, err := repository.Create(ctx, models.Post{Title: "Cafe opens downtown!", Content: "Content"})
+	require.NoError(t, err)
+
+	first, err := repository.FindByID(ctx, firstID)
+	require.NoError(t, err)
+	second, err := repository.FindByID(ctx, secondID)
+	require.NoError(t, err)
+	assert.Equal(t, "cafe-opens-downtown", first.Slug)
+	assert.Equal(t, "cafe-opens-downtown-2", second.Slug)
+
+	// Editing the content keeps the slug
+	first.Content = "Updated Content"
+	require.NoError(t, repository.Update(ctx, firstID, first))
+	first, err = repository.FindByID(ctx, firstID)
+	require.NoError(t, err)
+	assert.Equal(t, "cafe-opens-downtown", first.Slug)
+	assert.Empty(t, first.PreviousSlugs)
+
+	// Renaming moves the old slug into the history
+	first.Title = "Café Closes Downtown"
+	require.NoError(t, repository.Update(ctx, firstID, first))
+	first, err = repository.FindByID(ctx, firstID)
+	require.NoError(t, err)
+	assert.Equal(t, "cafe-closes-downtown", first.Slug)
+	assert.Equal(t, []string{"cafe-opens-downtown"}, first.PreviousSlugs)
+
+	found, err := repository.FindBySlug(ctx, "cafe-closes-downtown")
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, found.ID)
+
+	found, err = repository.FindBySlug(ctx, "cafe-opens-downtown")
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, found.ID)
+
+	_, err = repository.FindBySlug(ctx, "no-such-post")
+	assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+
+	// A slug in another post's history isn't reused
+	thirdID, err := repository.Create(ctx, models.Post{Title: "Cafe Opens Downtown", Content: "Content"})
+	require.NoError(t, err)
+	third, err := repository.FindByID(ctx, thirdID)
+	require.NoError(t, err)
+	assert.Equal(t, "cafe-opens-downtown-3", third.Slug)
+}
+
+func TestPostRepository_SetImageVariants(t *testing.T) {
+	ctx := context.Background()
+	_, err := repository.collection.DeleteMany(ctx, bson.M{})
+	require.NoError(t, err)
+
+	photo := models.Attachment{ID: primitive.NewObjectID().Hex(), Filename: "photo.jpg", ContentType: "image/jpeg", Width: 1600, Height: 1200}
+	notes := models.Attachment{ID: primitive.NewObjectID().Hex(), Filename: "notes.pdf", ContentType: "application/pdf"}
+	id, err := repository.Create(ctx, models.Post{Title: "Photo Post", Content: "Content", Attachments: []models.Attachment{notes, photo}})
+	require.NoError(t, err)
+	before, err := repository.FindByID(ctx, id)
+	require.NoError(t, err)
+
+	variants := []models.ImageVariant{{ID: primitive.NewObjectID().Hex(), ContentType: "image/jpeg", Width: 320, Height: 240}}
+	require.NoError(t, repository.SetImageVariants(ctx, photo.ID, variants))
+
+	stored, err := repository.FindByID(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, variants, stored.Attachments[1].Variants)
+	assert.Empty(t, stored.Attachments[0].Variants)
+	// An edit of the post started before the copies were ready still saves
+	assert.Equal(t, before.Version, stored.Version)
+	assert.True(t, stored.UpdatedAt.After(before.UpdatedAt))
+
+	err = repository.SetImageVariants(ctx, primitive.NewObjectID().Hex(), variants)
+	assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+}
+
+func TestPostRepository_EnsureIndexes(t *testing.T) {
+	err := repository.EnsureIndexes(context.Background())
+	require.NoError(t, err)
+
+	// Applying the same definitions twice is a no-op
+	err = repository.EnsureIndexes(context.Background())
+	require.NoError(t, err)
+
+	cursor, err := repository.collection.Indexes().List(context.Background())
+	require.NoError(t, err)
+
+	var indexes []bson.M
+	require.NoError(t, cursor.All(context.Background(), &indexes))
+
+	names := make([]string, 0, len(indexes))
+	for _, index := range indexes {
+		names = append(names, index["name"].(string))
+	}
+	assert.Contains(t, names, "created_at_desc")
+}
+
+func TestPostRepository_ReplaceAll(t *testing.T) {
+	_, err := repository.collection.DeleteMany(context.Background(), bson.M{})
+	require.NoError(t, err)
+
+	_, err = repository.CreateMany(context.Background(), []models.Post{
+		{Title: "Old Post 1", Content: "Old Content 1"},
+		{Title: "Old Post 2", Content: "Old Content 2"},
+	})
+	require.NoError(t, err)
+
+	createdAt := time.Now().Add(-48 * time.Hour).Truncate(time.Millisecond)
+	err = repository.ReplaceAll(context.Background(), []models.Post{
+		{Title: "New Post", Content: "New Content", CreatedAt: createdAt},
+	})
+	require.NoError(t, err)
+
+	var foundPosts []models.Post
+	cursor, err := repository.collection.Find(context.Background(), bson.M{})
+	require.NoError(t, err)
+	require.NoError(t, cursor.All(context.Background(), &foundPosts))
+
+	require.Len(t, foundPosts, 1)
+	assert.Equal(t, "New Post", foundPosts[0].Title)
+	assert.True(t, createdAt.Equal(foundPosts[0].CreatedAt))
+	assert.True(t, createdAt.Equal(foundPosts[0].UpdatedAt))
+
+	// The staging collection must not be left behind
+	names, err := mongoDB.ListCollectionNames(context.Background(), bson.M{"name": bson.M{"$regex": "^posts_staging_"}})
+	require.NoError(t, err)
+	assert.Empty(t, names)
+}
+
+func TestPostRepository_Stream(t *testing.T) {
+	_, err := repository.collection.DeleteMany(context.Background(), bson.M{})
+	require.NoError(t, err)
+
+	fixture, err := seeder.LoadFixtureFile("../testdata/fixtures/posts.yaml")
+	require.NoError(t, err)
+	ids, err := seeder.LoadFixture(context.Background(), repository, fixture)
+	require.NoError(t, err)
+
+	_, err = repository.CreateMany(context.Background(), []models.Post{
+		{Title: "Draft post", Content: "Draft content", Status: models.StatusDraft},
+	})
+	require.NoError(t, err)
+
+	var streamed []string
+	err = repository.Stream(context.Background(), PostFilter{Status: models.StatusPublished}, func(post models.Post) error {
+		streamed = append(streamed, post.ID.Hex())
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{ids["quantum"], ids["festival"], ids["exoplanet"]}, streamed)
+
+	streamed = nil
+	err = repository.Stream(context.Background(), PostFilter{
+		From: time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC),
+	}, func(post models.Post) error {
+		streamed = append(streamed, post.ID.Hex())
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{ids["festival"]}, streamed)
+
+	streamed = nil
+	err = repository.Stream(context.Background(), PostFilter{Status: models.StatusPublished, Skip: 1, Limit: 1}, func(post models.Post) error {
+		streamed = append(streamed, post.ID.Hex())
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{ids["festival"]}, streamed)
+
+	count, err := repository.Count(context.Background(), PostFilter{Status: models.StatusPublished, Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+}
+
+func TestPostRepository_FindPage(t *testing.T) {
+	ctx := context.Background()
+	_, err := repository.collection.DeleteMany(ctx, bson.M{})
+	require.NoError(t, err)
+
+	// Posts 3 and 4 share a timestamp, so _id breaks the tie
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var posts []models.Post
+	for i, offset := range []int{1, 2, 3, 3, 5} {
+		posts = append(posts, models.Post{
+			Title:     fmt.Sprintf("Post %d", i+1),
+			Content:   "Content",
+			CreatedAt: base.Add(time.Duration(offset) * time.Hour),
+		})
+	}
+	_, err = repository.CreateMany(ctx, posts)
+	require.NoError(t, err)
+
+	titles := func(page PostPage) []string {
+		var titles []string
+		for _, post := range page.Posts {
+			titles = append(titles, post.Title)
+		}
+		return titles
+	}
+
+	first, err := repository.FindPage(ctx, PageRequest{Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Post 5", "Post 4"}, titles(first))
+	assert.Empty(t, first.PrevCursor)
+	assert.NotEmpty(t, first.NextCursor)
+	assert.Equal(t, int64(5), first.Total)
+
+	second, err := repository.FindPage(ctx, PageRequest{After: first.NextCursor, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Post 3", "Post 2"}, titles(second))
+	assert.NotEmpty(t, second.PrevCursor)
+	assert.NotEmpty(t, second.NextCursor)
+
+	last, err := repository.FindPage(ctx, PageRequest{After: second.NextCursor, Limit: 2, SkipCount: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Post 1"}, titles(last))
+	assert.Empty(t, last.NextCursor)
+	assert.Equal(t, int64(-1), last.Total)
+
+	back, err := repository.FindPage(ctx, PageRequest{Before: second.PrevCursor, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Post 5", "Post 4"}, titles(back))
+	assert.Empty(t, back.PrevCursor)
+	assert.Equal(t, first.NextCursor, back.NextCursor)
+
+	filtered, err := repository.FindPage(ctx, PageRequest{Filter: PostFilter{Search: "Post 2"}, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Post 2"}, titles(filtered))
+	assert.Equal(t, int64(1), filtered.Total)
+
+	_, err = repository.FindPage(ctx, PageRequest{After: "not-a-cursor", Limit: 2})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestPostRepository_FindPageSorts(t *testing.T) {
+	ctx := context.Background()
+	_, err := repository.collection.DeleteMany(ctx, bson.M{})
+	require.NoError(t, err)
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, err = repository.CreateMany(ctx, []models.Post{
+		{Title: "banana bread", Content: "Baking", Author: "Ann", Tags: []string{"food"}, CreatedAt: base, UpdatedAt: base.AddDate(0, 1, 0)},
+		{Title: "Apple pie", Content: "Baking with apples", Author: "Bob", Tags: []string{"food"}, CreatedAt: base.AddDate(0, 0, 1)},
+		{Title: "Cherry season", Content: "Apple and cherry harvest", Author: "Ann", Tags: []string{"farming"}, CreatedAt: base.AddDate(0, 0, 2)},
+	})
+	require.NoError(t, err)
+
+	// all walks every page of one post to exercise the cursors of the sort
+	all := func(req PageRequest) []string {
+		req.Limit = 1
+		var titles []string
+		for {
+			page, err := repository.FindPage(ctx, req)
+			require.NoError(t, err)
+			for _, post := range page.Posts {
+				titles = append(titles, post.Title)
+			}
+			if page.NextCursor == "" {
+				return titles
+			}
+			req.After = page.NextCursor
+		}
+	}
+
+	assert.Equal(t, []string{"banana bread", "Apple pie", "Cherry season"}, all(PageRequest{Sort: SortOldest}))
+	assert.Equal(t, []string{"banana bread", "Cherry season", "Apple pie"}, all(PageRequest{Sort: SortUpdated}))
+	assert.Equal(t, []string{"Apple pie", "banana bread", "Cherry season"}, all(PageRequest{Sort: SortTitle}))
+	assert.Equal(t, []string{"Apple pie", "Cherry season"}, all(PageRequest{Sort: SortRelevance, Filter: PostFilter{Search: "apple"}}))
+	assert.Equal(t, []string{"Apple pie", "banana bread"}, all(PageRequest{Filter: PostFilter{Tag: "food"}}))
+	assert.Equal(t, []string{"Cherry season", "banana bread"}, all(PageRequest{Filter: PostFilter{Author: "Ann"}}))
+
+	// Paging back from the end of the title sort
+	last, err := repository.FindPage(ctx, PageRequest{Sort: SortTitle, Limit: 2})
+	require.NoError(t, err)
+	last, err = repository.FindPage(ctx, PageRequest{Sort: SortTitle, After: last.NextCursor, Limit: 2})
+	require.NoError(t, err)
+	back, err := repository.FindPage(ctx, PageRequest{Sort: SortTitle, Before: last.PrevCursor, Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, back.Posts, 2)
+	assert.Equal(t, "Apple pie", back.Posts[0].Title)
+
+	_, err = repository.FindPage(ctx, PageRequest{Sort: SortRelevance})
+	assert.ErrorIs(t, err, ErrInvalidSort)
+	_, err = repository.FindPage(ctx, PageRequest{Sort: "popular"})
+	assert.ErrorIs(t, err, ErrInvalidSort)
+	_, err = repository.FindPage(ctx, PageRequest{Sort: SortOldest, After: last.PrevCursor})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestPostRepository_Translations(t *testing.T) {
+	ctx := context.Background()
+	_, err := repository.collection.DeleteMany(ctx, bson.M{})
+	require.NoError(t, err)
+
+	sourceID, err := repository.Create(ctx, models.Post{Title: "Election results", Content: "The count is in"})
+	require.NoError(t, err)
+	translationID, err := repository.Create(ctx, models.Post{
+		Title:            "Результати виборів",
+		Content:          "Голоси підраховано",
+		Language:         "uk",
+		TranslationGroup: sourceID,
+	})
+	require.NoError(t, err)
+	untranslatedID, err := repository.Create(ctx, models.Post{Title: "Weather report", Content: "Sunny all week", Language: "en"})
+	require.NoError(t, err)
+
+	// The source post joins the group of its first translation
+	source, err := repository.FindByID(ctx, sourceID)
+	require.NoError(t, err)
+	assert.Equal(t, sourceID, source.TranslationGroup)
+
+	translations, err := repository.FindTranslations(ctx, sourceID)
+	require.NoError(t, err)
+	require.Len(t, translations, 2)
+	assert.Equal(t, sourceID, translations[0].ID.Hex())
+	assert.Equal(t, translationID, translations[1].ID.Hex())
+
+	listed := func(filter PostFilter) []string {
+		var ids []string
+		err := repository.Stream(ctx, filter, func(post models.Post) error {
+			ids = append(ids, post.ID.Hex())
+			return nil
+		})
+		require.NoError(t, err)
+		return ids
+	}
+
+	// Stories without a Ukrainian translation fall back to English, and the
+	// source post without a language counts as English
+	assert.ElementsMatch(t, []string{translationID, untranslatedID}, listed(PostFilter{Language: "uk", FallbackLanguage: "en"}))
+	assert.ElementsMatch(t, []string{sourceID, untranslatedID}, listed(PostFilter{Language: "en", FallbackLanguage: "en"}))
+	assert.ElementsMatch(t, []string{translationID}, listed(PostFilter{Language: "uk"}))
+	assert.ElementsMatch(t, []string{untranslatedID}, listed(PostFilter{Language: "uk", FallbackLanguage: "en", Search: "weather"}))
+
+	count, err := repository.Count(ctx, PostFilter{Language: "uk", FallbackLanguage: "en"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	page, err := repository.FindPage(ctx, PageRequest{Filter: PostFilter{Language: "uk", FallbackLanguage: "en"}})
+	require.NoError(t, err)
+	assert.Len(t, page.Posts, 2)
+	assert.Equal(t, int64(2), page.Total)
+
+	// Moving the translation to another language brings the source back
+	translation, err := repository.FindByID(ctx, translationID)
+	require.NoError(t, err)
+	translation.Language = "de"
+	require.NoError(t, repository.Update(ctx, translationID, translation))
+	assert.ElementsMatch(t, []string{sourceID, untranslatedID}, listed(PostFilter{Language: "uk", FallbackLanguage: "en"}))
+
+	// and so does deleting it
+	translation.Language = "uk"
+	translation.Version++
+	require.NoError(t, repository.Update(ctx, translationID, translation))
+	assert.ElementsMatch(t, []string{translationID, untranslatedID}, listed(PostFilter{Language: "uk", FallbackLanguage: "en"}))
+	require.NoError(t, repository.Delete(ctx, translationID))
+	assert.ElementsMatch(t, []string{sourceID, untranslatedID}, listed(PostFilter{Language: "uk", FallbackLanguage: "en"}))
+}
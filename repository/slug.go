@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/gekich/news-app/models"
+	"github.com/gekich/news-app/slug"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxSlugAttempts bounds the retries when a concurrent write takes the slug
+// that was just allocated
+const maxSlugAttempts = 3
+
+// FindBySlug retrieves a post by its current slug or, failing that, by one
+// of its previous slugs
+func (r *PostRepository) FindBySlug(ctx context.Context, s string) (models.Post, error) {
+	var post models.Post
+
+	err := r.collection.FindOne(ctx, bson.M{"slug": s}).Decode(&post)
+	if err != mongo.ErrNoDocuments {
+		return post, err
+	}
+
+	// The most recently renamed post wins if an old slug was reused
+	opts := options.FindOne().SetSort(bson.D{{Key: "updated_at", Value: -1}})
+	err = r.collection.FindOne(ctx, bson.M{"previous_slugs": s}, opts).Decode(&post)
+	return post, err
+}
+
+// assignSlugs gives every post without a slug one derived from its title,
+// unique within the collection and the batch. Posts that already carry a
+// slug, e.g. from an import, keep it unless it's taken.
+func assignSlugs(ctx context.Context, collection *mongo.Collection, posts []models.Post, exclude primitive.ObjectID) error {
+	bases := make([]string, len(posts))
+	patterns := make([]interface{}, 0, len(posts))
+	for i, post := range posts {
+		base := post.Slug
+		if base == "" {
+			base = slug.Make(post.Title)
+		} else {
+			base = slug.Make(base)
+		}
+		bases[i] = base
+		patterns = append(patterns, primitive.Regex{Pattern: "^" + regexp.QuoteMeta(base) + "(-[0-9]+)?$"})
+	}
+	if len(patterns) == 0 {
+		return nil
+	}
+
+	taken, err := takenSlugs(ctx, collection, patterns, exclude)
+	if err != nil {
+		return err
+	}
+
+	for i := range posts {
+		candidate := bases[i]
+		for n := 2; taken[candidate]; n++ {
+			candidate = slug.WithSuffix(bases[i], n)
+		}
+		taken[candidate] = true
+		posts[i].Slug = candidate
+	}
+
+	return nil
+}
+
+// takenSlugs returns the current and previous slugs matching any of the
+// patterns, ignoring the excluded post
+func takenSlugs(ctx context.Context, collection *mongo.Collection, patterns []interface{}, exclude primitive.ObjectID) (map[string]bool, error) {
+	filter := bson.M{"$or": []bson.M{
+		{"slug": bson.M{"$in": patterns}},
+		{"previous_slugs": bson.M{"$in": patterns}},
+	}}
+	if !exclude.IsZero() {
+		filter["_id"] = bson.M{"$ne": exclude}
+	}
+
+	opts := options.Find().SetProjection(bson.M{"slug": 1, "previous_slugs": 1})
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	taken := make(map[string]bool)
+	for cursor.Next(ctx) {
+		var post models.Post
+		if err := cursor.Decode(&post); err != nil {
+			return nil, err
+		}
+		taken[post.Slug] = true
+		for _, previous := range post.PreviousSlugs {
+			taken[previous] = true
+		}
+	}
+
+	return taken, cursor.Err()
+}
+
+// retrySlugConflict runs write again when it fails because a concurrent
+// write took the slug it allocated
+func retrySlugConflict(write func() error) error {
+	var err error
+	for attempt := 0; attempt < maxSlugAttempts; attempt++ {
+		if err = write(); !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// slugHistory adds the replaced slug to a post's previous slugs. The new
+// slug is dropped from the history in case the post got an old title back.
+func slugHistory(previous []string, replaced, current string) []string {
+	history := make([]string, 0, len(previous)+1)
+	for _, s := range previous {
+		if s != current && s != replaced {
+			history = append(history, s)
+		}
+	}
+	if replaced != "" && replaced != current {
+		history = append(history, replaced)
+	}
+	return history
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/gekich/news-app/models"
+)
+
+// PostStore is the set of post persistence operations used by handlers and seeders.
+// PostRepository is the MongoDB implementation.
+type PostStore interface {
+	FindAll(ctx context.Context, page, limit int64, search string) ([]models.Post, int64, error)
+	FindPage(ctx context.Context, req PageRequest) (PostPage, error)
+	FindByID(ctx context.Context, id string) (models.Post, error)
+	FindBySlug(ctx context.Context, slug string) (models.Post, error)
+	FindTranslations(ctx context.Context, group string) ([]models.Post, error)
+	Stream(ctx context.Context, filter PostFilter, fn func(models.Post) error) error
+	Count(ctx context.Context, filter PostFilter) (int64, error)
+	Create(ctx context.Context, post models.Post) (string, error)
+	Update(ctx context.Context, id string, post models.Post) error
+	SetImageVariants(ctx context.Context, attachmentID string, variants []models.ImageVariant) error
+	Delete(ctx context.Context, id string) error
+	DeleteVersion(ctx context.Context, id string, version int64) error
+	CreateMany(ctx context.Context, posts []models.Post) ([]string, error)
+	ExistingSourceGUIDs(ctx context.Context, guids []string) (map[string]bool, error)
+	ReplaceAll(ctx context.Context, posts []models.Post) error
+	ReplaceAllBatched(ctx context.Context, fill func(insert func([]models.Post) error) error) error
+}
+
+var _ PostStore = (*PostRepository)(nil)
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/gekich/news-app/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// groupLanguagesField holds the languages of a post's translation group on
+// every post of the group, so lists can tell whether a story has a
+// translation into their language without looking up the group
+const groupLanguagesField = "group_languages"
+
+// syncGroupLanguages stores the languages of each of the translation groups
+// on their posts. It is called after every write that adds a post to a
+// group, removes one or changes its language.
+func syncGroupLanguages(ctx context.Context, collection *mongo.Collection, groups ...string) error {
+	for _, group := range groups {
+		if group == "" {
+			continue
+		}
+
+		members := bson.M{"translation_group": group}
+		values, err := collection.Distinct(ctx, "language", bson.M{
+			"translation_group": group,
+			"language":          bson.M{"$type": "string"},
+		})
+		if err != nil {
+			return err
+		}
+
+		languages := bson.A{}
+		for _, value := range values {
+			languages = append(languages, value)
+		}
+		if _, err := collection.UpdateMany(ctx, members, bson.M{"$set": bson.M{groupLanguagesField: languages}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// translationGroups returns the distinct translation groups of posts
+func translationGroups(posts []models.Post) []string {
+	seen := make(map[string]bool)
+	var groups []string
+	for _, post := range posts {
+		if post.TranslationGroup != "" && !seen[post.TranslationGroup] {
+			seen[post.TranslationGroup] = true
+			groups = append(groups, post.TranslationGroup)
+		}
+	}
+	return groups
+}
@@ -3,6 +3,7 @@ package router
 import (
 	"net/http"
 
+	"github.com/gekich/news-app/i18n"
 	custom "github.com/gekich/news-app/middleware"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -17,14 +18,26 @@ type PostHandler interface {
 	Create(w http.ResponseWriter, r *http.Request)
 	Show(w http.ResponseWriter, r *http.Request)
 	Edit(w http.ResponseWriter, r *http.Request)
+	Translate(w http.ResponseWriter, r *http.Request)
 	Update(w http.ResponseWriter, r *http.Request)
 	Delete(w http.ResponseWriter, r *http.Request)
 	Seed(w http.ResponseWriter, r *http.Request)
+	ImportForm(w http.ResponseWriter, r *http.Request)
+	Import(w http.ResponseWriter, r *http.Request)
+	Export(w http.ResponseWriter, r *http.Request)
+	Sitemap(w http.ResponseWriter, r *http.Request)
+	SitemapPage(w http.ResponseWriter, r *http.Request)
+	Robots(w http.ResponseWriter, r *http.Request)
+	Media(w http.ResponseWriter, r *http.Request)
+	NotFound(w http.ResponseWriter, r *http.Request)
+	MethodNotAllowed(w http.ResponseWriter, r *http.Request)
 }
 
 // SetupRouter configures and returns the application router.
-// It now takes a staticDir parameter to specify the directory for static files.
-func SetupRouter(postHandler PostHandler, staticDir string) http.Handler {
+// Static files are served by assets under /static/, and pages are
+// translated into the locales of bundle. metrics serves /admin/metrics
+// unless it is nil.
+func SetupRouter(postHandler PostHandler, assets http.Handler, bundle *i18n.Bundle, metrics http.Handler) http.Handler {
 	r := chi.NewRouter()
 
 	// Middleware
@@ -32,25 +45,50 @@ func SetupRouter(postHandler PostHandler, staticDir string) http.Handler {
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.URLFormat)
 	r.Use(custom.MethodOverride)
+	r.Use(custom.Locale(bundle))
+
+	// Unknown paths and methods get the error pages of the handlers
+	r.NotFound(postHandler.NotFound)
+	r.MethodNotAllowed(postHandler.MethodNotAllowed)
 
 	// Serve static files
-	r.Handle("/static/*", http.StripPrefix("/static/", http.FileServer(http.Dir(staticDir))))
+	r.Handle("/static/*", http.StripPrefix("/static", assets))
+
+	// Files attached to posts, by ID with or without the extension of the
+	// uploaded file, which URLFormat strips
+	r.Get("/media/{id}", postHandler.Media)
 
 	// Routes
 	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, "/posts", http.StatusSeeOther)
 	})
 
+	// URLFormat strips the extension, so these serve /sitemap.xml, /sitemap-{page}.xml and /robots.txt
+	r.Get("/sitemap", postHandler.Sitemap)
+	r.Get("/sitemap-{page}", postHandler.SitemapPage)
+	r.Get("/robots", postHandler.Robots)
+
 	r.Route("/posts", func(r chi.Router) {
 		r.Get("/", postHandler.Index)
 		r.Get("/new", postHandler.New)
 		r.Post("/", postHandler.Create)
 		r.Get("/{id}", postHandler.Show)
 		r.Get("/{id}/edit", postHandler.Edit)
+		r.Get("/{id}/translate", postHandler.Translate)
 		r.Put("/{id}", postHandler.Update)
 		r.Delete("/{id}", postHandler.Delete)
 		r.Post("/seed", postHandler.Seed)
 	})
 
+	r.Route("/admin", func(r chi.Router) {
+		r.Get("/import", postHandler.ImportForm)
+		r.Post("/import", postHandler.Import)
+		r.Get("/export", postHandler.Export)
+		// Runtime and cache metrics as JSON
+		if metrics != nil {
+			r.Handle("/metrics", metrics)
+		}
+	})
+
 	return r
 }
@@ -3,12 +3,16 @@
 package router
 
 import (
+	"expvar"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/gekich/news-app/i18n"
+	"github.com/gekich/news-app/static"
 )
 
 // mockPostHandler is a mock implementation of the PostHandler interface for testing.
@@ -19,9 +23,33 @@ func (m *mockPostHandler) New(w http.ResponseWriter, r *http.Request)    { w.Wri
 func (m *mockPostHandler) Create(w http.ResponseWriter, r *http.Request) { w.Write([]byte("Create")) }
 func (m *mockPostHandler) Show(w http.ResponseWriter, r *http.Request)   { w.Write([]byte("Show")) }
 func (m *mockPostHandler) Edit(w http.ResponseWriter, r *http.Request)   { w.Write([]byte("Edit")) }
+func (m *mockPostHandler) Translate(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("Translate"))
+}
 func (m *mockPostHandler) Update(w http.ResponseWriter, r *http.Request) { w.Write([]byte("Update")) }
 func (m *mockPostHandler) Delete(w http.ResponseWriter, r *http.Request) { w.Write([]byte("Delete")) }
 func (m *mockPostHandler) Seed(w http.ResponseWriter, r *http.Request)   { w.Write([]byte("Seed")) }
+func (m *mockPostHandler) ImportForm(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("ImportForm"))
+}
+func (m *mockPostHandler) Import(w http.ResponseWriter, r *http.Request) { w.Write([]byte("Import")) }
+func (m *mockPostHandler) Export(w http.ResponseWriter, r *http.Request) { w.Write([]byte("Export")) }
+func (m *mockPostHandler) Sitemap(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("Sitemap"))
+}
+func (m *mockPostHandler) SitemapPage(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("SitemapPage"))
+}
+func (m *mockPostHandler) Robots(w http.ResponseWriter, r *http.Request) { w.Write([]byte("Robots")) }
+func (m *mockPostHandler) Media(w http.ResponseWriter, r *http.Request)  { w.Write([]byte("Media")) }
+func (m *mockPostHandler) NotFound(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNotFound)
+	w.Write([]byte("NotFound"))
+}
+func (m *mockPostHandler) MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusMethodNotAllowed)
+	w.Write([]byte("MethodNotAllowed"))
+}
 
 // TestSetupRouter verifies that all routes are correctly configured.
 func TestSetupRouter(t *testing.T) {
@@ -33,18 +61,31 @@ func TestSetupRouter(t *testing.T) {
 	}{
 		{"GET", "/", http.StatusSeeOther, ""},
 		{"GET", "/posts", http.StatusOK, "Index"},
+		{"GET", "/posts.json", http.StatusOK, "Index"},
 		{"GET", "/posts/new", http.StatusOK, "New"},
 		{"POST", "/posts", http.StatusOK, "Create"},
 		{"GET", "/posts/123", http.StatusOK, "Show"},
 		{"GET", "/posts/123/edit", http.StatusOK, "Edit"},
+		{"GET", "/posts/123/translate", http.StatusOK, "Translate"},
 		{"PUT", "/posts/123", http.StatusOK, "Update"},
 		{"DELETE", "/posts/123", http.StatusOK, "Delete"},
 		{"POST", "/posts/seed", http.StatusOK, "Seed"},
-		{"GET", "/non-existent-path", http.StatusNotFound, "404 page not found"},
+		{"GET", "/admin/import", http.StatusOK, "ImportForm"},
+		{"POST", "/admin/import", http.StatusOK, "Import"},
+		{"GET", "/admin/export", http.StatusOK, "Export"},
+		{"GET", "/admin/metrics", http.StatusOK, "memstats"},
+		{"GET", "/sitemap.xml", http.StatusOK, "Sitemap"},
+		{"GET", "/sitemap-2.xml", http.StatusOK, "SitemapPage"},
+		{"GET", "/robots.txt", http.StatusOK, "Robots"},
+		{"GET", "/media/65f1a2b3c4d5e6f708192a3b", http.StatusOK, "Media"},
+		{"GET", "/media/65f1a2b3c4d5e6f708192a3b.png", http.StatusOK, "Media"},
+		{"GET", "/non-existent-path", http.StatusNotFound, "NotFound"},
+		{"GET", "/posts/123/missing", http.StatusNotFound, "NotFound"},
+		{"PATCH", "/posts/123", http.StatusMethodNotAllowed, "MethodNotAllowed"},
 	}
 
 	// The static directory can be a dummy value since we are not testing static files here.
-	router := SetupRouter(&mockPostHandler{}, ".")
+	router := SetupRouter(&mockPostHandler{}, http.NotFoundHandler(), i18n.Default(), expvar.Handler())
 
 	for _, tc := range tests {
 		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
@@ -74,6 +115,23 @@ func TestSetupRouter(t *testing.T) {
 	}
 }
 
+// TestSetupRouter_MetricsOff verifies that the metrics aren't served without
+// a handler for them.
+func TestSetupRouter_MetricsOff(t *testing.T) {
+	router := SetupRouter(&mockPostHandler{}, http.NotFoundHandler(), i18n.Default(), nil)
+
+	req, _ := http.NewRequest("GET", "/admin/metrics", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d, got %d", http.StatusNotFound, rr.Code)
+	}
+	if strings.Contains(rr.Body.String(), "memstats") {
+		t.Errorf("Expected no metrics, got %q", rr.Body.String())
+	}
+}
+
 // TestStaticFileServer tests the static file serving functionality.
 func TestStaticFileServer(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "static_test")
@@ -88,7 +146,11 @@ func TestStaticFileServer(t *testing.T) {
 		t.Fatalf("Failed to write dummy static file: %v", err)
 	}
 
-	router := SetupRouter(&mockPostHandler{}, tmpDir)
+	assets, err := static.NewAssets(os.DirFS(tmpDir))
+	if err != nil {
+		t.Fatalf("Failed to load static files: %v", err)
+	}
+	router := SetupRouter(&mockPostHandler{}, assets.Handler(), i18n.Default(), nil)
 
 	// Test case for an existing file
 	t.Run("existing file", func(t *testing.T) {
@@ -104,6 +166,28 @@ func TestStaticFileServer(t *testing.T) {
 		}
 	})
 
+	// Test case for the fingerprinted name of a file
+	t.Run("fingerprinted file", func(t *testing.T) {
+		path := assets.Path("style.css")
+		if path == "/static/style.css" {
+			t.Fatalf("Expected a fingerprinted path, got %q", path)
+		}
+
+		req, _ := http.NewRequest("GET", path, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if status := rr.Code; status != http.StatusOK {
+			t.Errorf("Static file handler returned wrong status code: got %v want %v", status, http.StatusOK)
+		}
+		if body := rr.Body.String(); body != fileContent {
+			t.Errorf("Static file handler returned unexpected body: got %q want %q", body, fileContent)
+		}
+		if cacheControl := rr.Header().Get("Cache-Control"); !strings.Contains(cacheControl, "immutable") {
+			t.Errorf("Expected fingerprinted files to be cached for good, got %q", cacheControl)
+		}
+	})
+
 	// Test case for a non-existent file
 	t.Run("non-existent file", func(t *testing.T) {
 		req, _ := http.NewRequest("GET", "/static/nonexistent.js", nil)
@@ -0,0 +1,170 @@
+package seeder
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gekich/news-app/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	fakeAuthors = []string{
+		"Alice Morgan", "Ben Okafor", "Chloé Durand", "Daniel Kim", "Elena Petrova",
+		"Farid Haddad", "Grace Liu", "Hiro Tanaka", "Isabel Cruz", "Jonas Berg",
+	}
+
+	fakeTags = []string{
+		"politics", "economy", "technology", "science", "health", "sports",
+		"culture", "environment", "education", "world", "local", "opinion",
+	}
+
+	fakeSubjects = []string{
+		"City Council", "Researchers", "Local Startup", "National Team", "Health Officials",
+		"Museum", "Central Bank", "School Board", "Climate Panel", "Tech Giant",
+		"Farmers", "Volunteers", "Space Agency", "Hospital", "Transit Authority",
+	}
+
+	fakeVerbs = []string{
+		"Announces", "Unveils", "Rejects", "Expands", "Delays", "Launches",
+		"Questions", "Celebrates", "Approves", "Investigates", "Warns About", "Backs",
+	}
+
+	fakeObjects = []string{
+		"New Budget Plan", "Record Growth", "Controversial Proposal", "Summer Program",
+		"Data Privacy Rules", "Renewable Energy Project", "Housing Reform", "Vaccine Study",
+		"Stadium Renovation", "Digital Archive", "Water Restrictions", "Trade Agreement",
+	}
+
+	fakeWords = strings.Fields(`the a new report shows that officials local residents plan city
+		government economy market growth data study team project community public year week
+		announced said according expected increase decrease policy research results support
+		funding season school students hospital patients climate energy water transport
+		analysts experts early later following during despite after before across region
+		national international record significant major minor several many most few other`)
+)
+
+// FakeGenerator produces varied, realistic-looking posts with Markdown bodies,
+// authors, tags and timestamps spread over a configurable period
+type FakeGenerator struct {
+	Now     time.Time
+	Span    time.Duration
+	Authors []string
+	Tags    []string
+}
+
+// NewFakeGenerator creates a FakeGenerator spreading posts over the year before now
+func NewFakeGenerator(now time.Time) *FakeGenerator {
+	return &FakeGenerator{
+		Now:     now,
+		Span:    365 * 24 * time.Hour,
+		Authors: fakeAuthors,
+		Tags:    fakeTags,
+	}
+}
+
+// Next generates a single post
+func (g *FakeGenerator) Next(rng *rand.Rand) models.Post {
+	// Squaring the fraction makes recent posts more frequent than old ones
+	fraction := rng.Float64()
+	createdAt := g.Now.Add(-time.Duration(fraction * fraction * float64(g.Span))).Truncate(time.Millisecond)
+
+	updatedAt := createdAt
+	if rng.Intn(10) < 3 {
+		updatedAt = createdAt.Add(time.Duration(rng.Int63n(int64(g.Now.Sub(createdAt)) + 1))).Truncate(time.Millisecond)
+	}
+
+	return models.Post{
+		ID:        primitive.NewObjectID(),
+		Title:     g.title(rng),
+		Content:   g.content(rng),
+		Author:    g.Authors[rng.Intn(len(g.Authors))],
+		Tags:      g.tags(rng),
+		Status:    g.status(rng),
+		CreatedAt: createdAt,
+		UpdatedAt: updatedAt,
+	}
+}
+
+// title builds a headline such as "City Council Approves New Budget Plan"
+func (g *FakeGenerator) title(rng *rand.Rand) string {
+	return fmt.Sprintf("%s %s %s",
+		fakeSubjects[rng.Intn(len(fakeSubjects))],
+		fakeVerbs[rng.Intn(len(fakeVerbs))],
+		fakeObjects[rng.Intn(len(fakeObjects))])
+}
+
+// status makes roughly one post in ten a draft
+func (g *FakeGenerator) status(rng *rand.Rand) string {
+	if rng.Intn(10) == 0 {
+		return models.StatusDraft
+	}
+	return models.StatusPublished
+}
+
+// tags picks between one and four distinct tags
+func (g *FakeGenerator) tags(rng *rand.Rand) []string {
+	count := 1 + rng.Intn(min(4, len(g.Tags)))
+	tags := make([]string, 0, count)
+	for _, i := range rng.Perm(len(g.Tags))[:count] {
+		tags = append(tags, g.Tags[i])
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// content builds a Markdown body of one to six paragraphs, occasionally with
+// a subheading or a bullet list
+func (g *FakeGenerator) content(rng *rand.Rand) string {
+	var b strings.Builder
+	paragraphs := 1 + rng.Intn(6)
+
+	for i := 0; i < paragraphs; i++ {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+
+		switch {
+		case i > 0 && rng.Intn(5) == 0:
+			b.WriteString("## ")
+			b.WriteString(strings.TrimSuffix(sentence(rng, 3, 6), "."))
+			b.WriteString("\n\n")
+		case i > 0 && rng.Intn(6) == 0:
+			items := 2 + rng.Intn(3)
+			for j := 0; j < items; j++ {
+				b.WriteString("- ")
+				b.WriteString(sentence(rng, 4, 10))
+				b.WriteString("\n")
+			}
+			b.WriteString("\n")
+		}
+
+		sentences := 2 + rng.Intn(6)
+		for j := 0; j < sentences; j++ {
+			if j > 0 {
+				b.WriteString(" ")
+			}
+			s := sentence(rng, 6, 18)
+			if rng.Intn(12) == 0 {
+				s = "**" + strings.TrimSuffix(s, ".") + "**."
+			}
+			b.WriteString(s)
+		}
+	}
+
+	return b.String()
+}
+
+// sentence builds a capitalized sentence of between minWords and maxWords words
+func sentence(rng *rand.Rand, minWords, maxWords int) string {
+	count := minWords + rng.Intn(maxWords-minWords+1)
+	words := make([]string, count)
+	for i := range words {
+		words[i] = fakeWords[rng.Intn(len(fakeWords))]
+	}
+	words[0] = strings.ToUpper(words[0][:1]) + words[0][1:]
+	return strings.Join(words, " ") + "."
+}
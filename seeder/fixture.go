@@ -0,0 +1,174 @@
+package seeder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gekich/news-app/models"
+	"gopkg.in/yaml.v3"
+)
+
+// Fixture is a declarative dataset of users, tags and posts. Posts refer to
+// users and tags by key, and are themselves keyed so callers can look up the
+// IDs they were created with.
+type Fixture struct {
+	Users []FixtureUser `json:"users" yaml:"users"`
+	Tags  []FixtureTag  `json:"tags" yaml:"tags"`
+	Posts []FixturePost `json:"posts" yaml:"posts"`
+}
+
+// FixtureUser is an author that posts can reference
+type FixtureUser struct {
+	Key  string `json:"key" yaml:"key"`
+	Name string `json:"name" yaml:"name"`
+}
+
+// FixtureTag is a tag that posts can reference. Name defaults to Key.
+type FixtureTag struct {
+	Key  string `json:"key" yaml:"key"`
+	Name string `json:"name" yaml:"name"`
+}
+
+// FixturePost describes a post. Author is a user key and Tags are tag keys.
+type FixturePost struct {
+	Key       string    `json:"key" yaml:"key"`
+	Title     string    `json:"title" yaml:"title"`
+	Content   string    `json:"content" yaml:"content"`
+	Author    string    `json:"author" yaml:"author"`
+	Tags      []string  `json:"tags" yaml:"tags"`
+	CreatedAt time.Time `json:"created_at" yaml:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" yaml:"updated_at"`
+}
+
+// ParseFixture decodes a fixture in the given format, "yaml" or "json"
+func ParseFixture(r io.Reader, format string) (Fixture, error) {
+	var fixture Fixture
+
+	switch format {
+	case "yaml", "yml":
+		decoder := yaml.NewDecoder(r)
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&fixture); err != nil && err != io.EOF {
+			return fixture, fmt.Errorf("invalid yaml fixture: %w", err)
+		}
+	case "json":
+		decoder := json.NewDecoder(r)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&fixture); err != nil {
+			return fixture, fmt.Errorf("invalid json fixture: %w", err)
+		}
+	default:
+		return fixture, fmt.Errorf("unsupported fixture format %q", format)
+	}
+
+	return fixture, nil
+}
+
+// LoadFixtureFile reads a fixture file, choosing the format from its extension
+func LoadFixtureFile(path string) (Fixture, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Fixture{}, err
+	}
+	defer file.Close()
+
+	format := strings.TrimPrefix(filepath.Ext(path), ".")
+	fixture, err := ParseFixture(file, format)
+	if err != nil {
+		return fixture, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return fixture, nil
+}
+
+// Resolve turns the fixture into posts with author names and tag names filled
+// in from their references. The returned keys are in the same order as the posts.
+func (f Fixture) Resolve() ([]models.Post, []string, error) {
+	users := make(map[string]string, len(f.Users))
+	for _, user := range f.Users {
+		if _, exists := users[user.Key]; exists || user.Key == "" {
+			return nil, nil, fmt.Errorf("duplicate or empty user key %q", user.Key)
+		}
+		users[user.Key] = user.Name
+	}
+
+	tags := make(map[string]string, len(f.Tags))
+	for _, tag := range f.Tags {
+		if _, exists := tags[tag.Key]; exists || tag.Key == "" {
+			return nil, nil, fmt.Errorf("duplicate or empty tag key %q", tag.Key)
+		}
+		name := tag.Name
+		if name == "" {
+			name = tag.Key
+		}
+		tags[tag.Key] = name
+	}
+
+	posts := make([]models.Post, len(f.Posts))
+	keys := make([]string, len(f.Posts))
+	seen := make(map[string]bool, len(f.Posts))
+
+	for i, fp := range f.Posts {
+		if fp.Key == "" || seen[fp.Key] {
+			return nil, nil, fmt.Errorf("duplicate or empty post key %q", fp.Key)
+		}
+		seen[fp.Key] = true
+
+		post := models.Post{
+			Title:     fp.Title,
+			Content:   fp.Content,
+			CreatedAt: fp.CreatedAt,
+			UpdatedAt: fp.UpdatedAt,
+		}
+
+		if fp.Author != "" {
+			name, ok := users[fp.Author]
+			if !ok {
+				return nil, nil, fmt.Errorf("post %q references unknown user %q", fp.Key, fp.Author)
+			}
+			post.Author = name
+		}
+
+		for _, key := range fp.Tags {
+			name, ok := tags[key]
+			if !ok {
+				return nil, nil, fmt.Errorf("post %q references unknown tag %q", fp.Key, key)
+			}
+			post.Tags = append(post.Tags, name)
+		}
+
+		posts[i] = post
+		keys[i] = fp.Key
+	}
+
+	return posts, keys, nil
+}
+
+// LoadFixture inserts the fixture's posts into store and returns the created IDs keyed by post key
+func LoadFixture(ctx context.Context, store Store, fixture Fixture) (map[string]string, error) {
+	posts, keys, err := fixture.Resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := store.CreateMany(ctx, posts)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) != len(keys) {
+		return nil, fmt.Errorf("store created %d posts, expected %d", len(ids), len(keys))
+	}
+
+	created := make(map[string]string, len(keys))
+	for i, key := range keys {
+		created[key] = ids[i]
+	}
+
+	return created, nil
+}
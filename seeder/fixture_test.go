@@ -0,0 +1,111 @@
+//go:build unit
+
+package seeder
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const fixturePath = "../testdata/fixtures/posts.yaml"
+
+func TestLoadFixtureFile(t *testing.T) {
+	fixture, err := LoadFixtureFile(fixturePath)
+	require.NoError(t, err)
+
+	posts, keys, err := fixture.Resolve()
+	require.NoError(t, err)
+	require.Len(t, posts, 3)
+	assert.Equal(t, []string{"quantum", "festival", "exoplanet"}, keys)
+
+	assert.Equal(t, "Alice Morgan", posts[0].Author)
+	assert.Equal(t, []string{"technology", "science"}, posts[0].Tags)
+	assert.Equal(t, time.Date(2025, 1, 3, 9, 0, 0, 0, time.UTC), posts[0].CreatedAt.UTC())
+	assert.Equal(t, []string{"local"}, posts[1].Tags, "tag name defaults to its key")
+}
+
+func TestLoadFixtureFile_JSON(t *testing.T) {
+	fixture, err := LoadFixtureFile("../testdata/fixtures/posts.json")
+	require.NoError(t, err)
+
+	posts, _, err := fixture.Resolve()
+	require.NoError(t, err)
+	require.Len(t, posts, 1)
+	assert.Equal(t, "Alice Morgan", posts[0].Author)
+}
+
+func TestParseFixture_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		format  string
+		wantErr string
+	}{
+		{
+			name:    "unknown user",
+			input:   "posts:\n  - key: a\n    title: A\n    author: nobody\n",
+			format:  "yaml",
+			wantErr: "unknown user",
+		},
+		{
+			name:    "unknown tag",
+			input:   "posts:\n  - key: a\n    title: A\n    tags: [missing]\n",
+			format:  "yaml",
+			wantErr: "unknown tag",
+		},
+		{
+			name:    "duplicate post key",
+			input:   "posts:\n  - key: a\n  - key: a\n",
+			format:  "yaml",
+			wantErr: "duplicate",
+		},
+		{
+			name:    "unknown field",
+			input:   "posts:\n  - key: a\n    headline: A\n",
+			format:  "yaml",
+			wantErr: "invalid yaml fixture",
+		},
+		{
+			name:    "unsupported format",
+			input:   "",
+			format:  "toml",
+			wantErr: "unsupported fixture format",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fixture, err := ParseFixture(strings.NewReader(tt.input), tt.format)
+			if err == nil {
+				_, _, err = fixture.Resolve()
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestLoadFixture(t *testing.T) {
+	fixture, err := LoadFixtureFile(fixturePath)
+	require.NoError(t, err)
+
+	store := &memoryStore{}
+	ids, err := LoadFixture(context.Background(), store, fixture)
+	require.NoError(t, err)
+
+	assert.Len(t, store.posts, 3)
+	assert.Equal(t, map[string]string{"quantum": "1", "festival": "2", "exoplanet": "3"}, ids)
+}
+
+func TestRun_ResetFromFixtureFile(t *testing.T) {
+	store := &memoryStore{}
+	written, err := Run(context.Background(), store, Options{Mode: ModeReset, FixturePath: fixturePath})
+	require.NoError(t, err)
+	assert.Equal(t, 3, written)
+	assert.Equal(t, "Quantum Computing Breakthrough Announced", store.posts[0].Title)
+}
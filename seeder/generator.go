@@ -0,0 +1,79 @@
+package seeder
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/gekich/news-app/models"
+)
+
+// DefaultBatchSize is the number of posts generated and written at a time
+const DefaultBatchSize = 1000
+
+// Generator produces posts for seeding. Implementations draw all randomness
+// from rng, so a fixed seed yields the same titles, contents and other
+// fields. Each post still gets a new ID, and timestamps are relative to the
+// time the generator was created.
+type Generator interface {
+	Next(rng *rand.Rand) models.Post
+}
+
+// generators maps generator names to their constructors
+var generators = map[string]func(now time.Time) Generator{
+	"fake":   func(now time.Time) Generator { return NewFakeGenerator(now) },
+	"sample": func(now time.Time) Generator { return NewSampleGenerator(now) },
+}
+
+// NewGenerator returns the named generator with timestamps relative to now
+func NewGenerator(name string, now time.Time) (Generator, error) {
+	newGenerator, ok := generators[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown generator %q", name)
+	}
+	return newGenerator(now), nil
+}
+
+// HasGenerator reports whether a generator with the given name exists
+func HasGenerator(name string) bool {
+	_, ok := generators[name]
+	return ok
+}
+
+// GeneratorNames lists the available generators
+func GeneratorNames() []string {
+	names := make([]string, 0, len(generators))
+	for name := range generators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Generate draws count posts from gen and hands them to emit in batches of at most
+// batchSize, so that large datasets never have to be held in memory at once.
+// The batch slice is reused, so emit must not retain it.
+func Generate(ctx context.Context, gen Generator, rng *rand.Rand, count, batchSize int, emit func([]models.Post) error) error {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	batch := make([]models.Post, 0, min(batchSize, count))
+	for i := 0; i < count; i++ {
+		batch = append(batch, gen.Next(rng))
+
+		if len(batch) == batchSize || i == count-1 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := emit(batch); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+
+	return nil
+}
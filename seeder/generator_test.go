@@ -0,0 +1,118 @@
+//go:build unit
+
+package seeder
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gekich/news-app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewGenerator(t *testing.T) {
+	for _, name := range GeneratorNames() {
+		gen, err := NewGenerator(name, time.Now())
+		require.NoError(t, err)
+		assert.NotNil(t, gen)
+		assert.True(t, HasGenerator(name))
+	}
+
+	_, err := NewGenerator("lorem", time.Now())
+	assert.Error(t, err)
+	assert.False(t, HasGenerator("lorem"))
+}
+
+func TestFakeGenerator(t *testing.T) {
+	now := time.Now()
+	gen := NewFakeGenerator(now)
+	rng := rand.New(rand.NewSource(1))
+
+	titles := make(map[string]bool)
+	days := make(map[string]bool)
+	edited := 0
+
+	for i := 0; i < 500; i++ {
+		post := gen.Next(rng)
+
+		assert.NotEmpty(t, post.Title)
+		assert.NotEmpty(t, post.Content)
+		assert.NotEmpty(t, post.Author)
+		assert.NotEmpty(t, post.Tags)
+		assert.False(t, post.CreatedAt.After(now))
+		assert.True(t, post.CreatedAt.After(now.Add(-gen.Span)))
+		assert.False(t, post.UpdatedAt.Before(post.CreatedAt))
+
+		titles[post.Title] = true
+		days[post.CreatedAt.Format("2006-01-02")] = true
+		if post.UpdatedAt.After(post.CreatedAt) {
+			edited++
+		}
+	}
+
+	assert.Greater(t, len(titles), 100, "titles should vary")
+	assert.Greater(t, len(days), 30, "timestamps should be spread out")
+	assert.Greater(t, edited, 0, "some posts should have been edited")
+}
+
+func TestFakeGenerator_Deterministic(t *testing.T) {
+	now := time.Now()
+	first := NewFakeGenerator(now)
+	second := NewFakeGenerator(now)
+	rng1 := rand.New(rand.NewSource(99))
+	rng2 := rand.New(rand.NewSource(99))
+
+	for i := 0; i < 50; i++ {
+		a, b := first.Next(rng1), second.Next(rng2)
+		assert.Equal(t, a.Title, b.Title)
+		assert.Equal(t, a.Content, b.Content)
+		assert.Equal(t, a.Tags, b.Tags)
+		assert.Equal(t, a.CreatedAt, b.CreatedAt)
+	}
+}
+
+func TestFakeGenerator_Markdown(t *testing.T) {
+	gen := NewFakeGenerator(time.Now())
+	rng := rand.New(rand.NewSource(3))
+
+	var headings, lists int
+	for i := 0; i < 200; i++ {
+		content := gen.Next(rng).Content
+		if strings.Contains(content, "\n## ") {
+			headings++
+		}
+		if strings.Contains(content, "\n- ") {
+			lists++
+		}
+	}
+
+	assert.Greater(t, headings, 0)
+	assert.Greater(t, lists, 0)
+}
+
+func TestGenerate_Batches(t *testing.T) {
+	gen := NewFakeGenerator(time.Now())
+	rng := rand.New(rand.NewSource(1))
+
+	var sizes []int
+	err := Generate(context.Background(), gen, rng, 2500, 1000, func(batch []models.Post) error {
+		sizes = append(sizes, len(batch))
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int{1000, 1000, 500}, sizes)
+}
+
+func TestGenerate_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := Generate(ctx, NewFakeGenerator(time.Now()), rand.New(rand.NewSource(1)), 10, 5, func([]models.Post) error {
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+}
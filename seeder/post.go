@@ -2,16 +2,16 @@ package seeder
 
 import (
 	"fmt"
+	"math/rand"
 	"time"
 
 	"github.com/gekich/news-app/models"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-// GenerateSamplePosts creates a specified number of sample posts
-func GenerateSamplePosts(count int) []models.Post {
-	posts := make([]models.Post, count)
-	titles := []string{
+// sampleTitles and sampleContents are the canonical sample posts, paired by index
+var (
+	sampleTitles = []string{
 		"Breaking News: Major Tech Breakthrough Announced",
 		"Local Community Celebrates Annual Festival",
 		"New Study Reveals Surprising Health Benefits",
@@ -24,7 +24,7 @@ func GenerateSamplePosts(count int) []models.Post {
 		"Political Leaders Announce Historic Agreement",
 	}
 
-	contents := []string{
+	sampleContents = []string{
 		"Researchers have announced a breakthrough in quantum computing technology that could revolutionize data processing. The new approach, developed by a team of international scientists, demonstrates quantum advantage in solving complex problems that would take traditional computers thousands of years to complete.",
 		"The annual harvest festival brought together thousands of community members this weekend, featuring local cuisine, artisan crafts, and live performances. Now in its 25th year, the celebration has become a cornerstone of cultural heritage in the region.",
 		"A comprehensive 10-year study published in the Journal of Medical Science indicates that moderate daily exercise can significantly reduce the risk of cognitive decline in older adults. The findings suggest even light activity provides measurable benefits.",
@@ -36,31 +36,61 @@ func GenerateSamplePosts(count int) []models.Post {
 		"Astronomers using the newly deployed space telescope have discovered evidence of water vapor in the atmosphere of an exoplanet located just 40 light years from Earth, raising new possibilities in the search for habitable worlds.",
 		"Representatives from previously conflicting nations signed a historic peace accord today, ending decades of tension. The agreement includes provisions for economic cooperation, cultural exchange programs, and joint environmental protection efforts.",
 	}
+)
+
+// GenerateSamplePosts creates a specified number of sample posts
+func GenerateSamplePosts(count int) []models.Post {
+	return GenerateSamplePostsWithRand(count, rand.New(rand.NewSource(time.Now().UnixNano())))
+}
+
+// GenerateSamplePostsWithRand creates a specified number of sample posts, drawing
+// their ages from rng. Their timestamps are relative to now and their IDs new.
+func GenerateSamplePostsWithRand(count int, rng *rand.Rand) []models.Post {
+	gen := NewSampleGenerator(time.Now())
+	posts := make([]models.Post, count)
+	for i := range posts {
+		posts[i] = gen.Next(rng)
+	}
+	return posts
+}
+
+// SampleGenerator cycles through the canonical sample posts, numbering repeats as parts of a series
+type SampleGenerator struct {
+	now   time.Time
+	index int
+}
 
-	for i := 0; i < count; i++ {
-		titleIndex := i % len(titles)
-		contentIndex := i % len(contents)
+// NewSampleGenerator creates a SampleGenerator with timestamps in the month before now
+func NewSampleGenerator(now time.Time) *SampleGenerator {
+	return &SampleGenerator{now: now}
+}
 
-		// Add some randomness to make each post unique
-		title := titles[titleIndex]
-		if i >= len(titles) {
-			title = fmt.Sprintf("%s - Part %d", title, (i/len(titles))+1)
-		}
+// Next generates a single post
+func (g *SampleGenerator) Next(rng *rand.Rand) models.Post {
+	i := g.index
+	g.index++
 
-		content := contents[contentIndex]
-		if i >= len(contents) {
-			content = fmt.Sprintf("%s\n\nThis is additional information for part %d of this series.", content, (i/len(contents))+1)
-		}
+	titleIndex := i % len(sampleTitles)
+	contentIndex := i % len(sampleContents)
 
-		// Create post with random timestamp within the last month
-		posts[i] = models.Post{
-			ID:        primitive.NewObjectID(),
-			Title:     title,
-			Content:   content,
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-		}
+	// Add some randomness to make each post unique
+	title := sampleTitles[titleIndex]
+	if i >= len(sampleTitles) {
+		title = fmt.Sprintf("%s - Part %d", title, (i/len(sampleTitles))+1)
 	}
 
-	return posts
+	content := sampleContents[contentIndex]
+	if i >= len(sampleContents) {
+		content = fmt.Sprintf("%s\n\nThis is additional information for part %d of this series.", content, (i/len(sampleContents))+1)
+	}
+
+	// Create post with random timestamp within the last month
+	createdAt := g.now.Add(-time.Duration(rng.Int63n(int64(30 * 24 * time.Hour))))
+	return models.Post{
+		ID:        primitive.NewObjectID(),
+		Title:     title,
+		Content:   content,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
 }
@@ -0,0 +1,170 @@
+package seeder
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/gekich/news-app/media"
+	"github.com/gekich/news-app/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Mode controls how seeded posts are combined with existing posts
+type Mode string
+
+const (
+	// ModeAppend adds generated posts next to the existing ones
+	ModeAppend Mode = "append"
+	// ModeReplace replaces all existing posts with generated ones
+	ModeReplace Mode = "replace"
+	// ModeReset replaces all existing posts with the fixture set or a fixture file
+	ModeReset Mode = "reset"
+)
+
+// ParseMode converts a string into a Mode
+func ParseMode(s string) (Mode, error) {
+	switch Mode(s) {
+	case ModeAppend, ModeReplace, ModeReset:
+		return Mode(s), nil
+	default:
+		return "", fmt.Errorf("unknown seed mode %q", s)
+	}
+}
+
+// Options configures a seeding run
+type Options struct {
+	Mode  Mode
+	Count int
+	// Seed seeds the generator, which then repeats the content and the ages
+	// of the posts of earlier runs with the seed. Zero picks a random seed.
+	Seed int64
+	// Generator names the generator to use, "fake" when empty
+	Generator string
+	// BatchSize is the number of posts written at a time, DefaultBatchSize when zero
+	BatchSize int
+	// FixturePath is the fixture file loaded by ModeReset, the built-in Fixtures when empty
+	FixturePath string
+	// Media, when set, has the files of replaced posts deleted by ModeReplace
+	// and ModeReset
+	Media media.Store
+}
+
+// Store is the subset of repository.PostStore used for seeding
+type Store interface {
+	CreateMany(ctx context.Context, posts []models.Post) ([]string, error)
+	ReplaceAllBatched(ctx context.Context, fill func(insert func([]models.Post) error) error) error
+}
+
+// Run seeds the store according to opts and returns the number of posts written
+func Run(ctx context.Context, store Store, opts Options) (int, error) {
+	if opts.Mode == ModeReset {
+		posts := Fixtures()
+		if opts.FixturePath != "" {
+			fixture, err := LoadFixtureFile(opts.FixturePath)
+			if err != nil {
+				return 0, err
+			}
+			if posts, _, err = fixture.Resolve(); err != nil {
+				return 0, err
+			}
+		}
+
+		err := replaceAll(ctx, store, opts.Media, func(insert func([]models.Post) error) error {
+			return insert(posts)
+		})
+		return len(posts), err
+	}
+
+	if opts.Mode != ModeAppend && opts.Mode != ModeReplace {
+		return 0, fmt.Errorf("unknown seed mode %q", opts.Mode)
+	}
+	if opts.Count < 0 {
+		return 0, fmt.Errorf("seed count must not be negative")
+	}
+
+	name := opts.Generator
+	if name == "" {
+		name = "fake"
+	}
+	gen, err := NewGenerator(name, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	seed := opts.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	written := 0
+	generate := func(insert func([]models.Post) error) error {
+		return Generate(ctx, gen, rng, opts.Count, opts.BatchSize, func(batch []models.Post) error {
+			if err := insert(batch); err != nil {
+				return err
+			}
+			written += len(batch)
+			return nil
+		})
+	}
+
+	if opts.Mode == ModeAppend {
+		err = generate(func(batch []models.Post) error {
+			_, err := store.CreateMany(ctx, batch)
+			return err
+		})
+		return written, err
+	}
+
+	err = replaceAll(ctx, store, opts.Media, generate)
+	return written, err
+}
+
+// replaceAll replaces the posts of store like Store.ReplaceAllBatched. With
+// files, it then deletes the files uploaded before the replacement that none
+// of the new posts refers to, which belonged to the replaced posts.
+func replaceAll(ctx context.Context, store Store, files media.Store, fill func(insert func([]models.Post) error) error) error {
+	started := time.Now()
+	referenced := make(map[string]bool)
+	err := store.ReplaceAllBatched(ctx, func(insert func([]models.Post) error) error {
+		return fill(func(batch []models.Post) error {
+			for _, post := range batch {
+				for _, attachment := range post.Attachments {
+					for _, id := range attachment.MediaIDs() {
+						referenced[id] = true
+					}
+				}
+			}
+			return insert(batch)
+		})
+	})
+	if err != nil || files == nil {
+		return err
+	}
+
+	if _, err := media.Prune(ctx, files, referenced, started); err != nil {
+		return fmt.Errorf("failed to delete the files of replaced posts: %w", err)
+	}
+	return nil
+}
+
+// Fixtures returns the canonical sample posts, one hour apart with the first being the newest
+func Fixtures() []models.Post {
+	now := time.Now().Truncate(time.Second)
+	posts := make([]models.Post, len(sampleTitles))
+
+	for i := range sampleTitles {
+		createdAt := now.Add(-time.Duration(i) * time.Hour)
+		posts[i] = models.Post{
+			ID:        primitive.NewObjectID(),
+			Title:     sampleTitles[i],
+			Content:   sampleContents[i],
+			CreatedAt: createdAt,
+			UpdatedAt: createdAt,
+		}
+	}
+
+	return posts
+}
@@ -0,0 +1,133 @@
+//go:build unit
+
+package seeder
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gekich/news-app/media"
+	"github.com/gekich/news-app/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore struct {
+	posts []models.Post
+}
+
+func (s *memoryStore) CreateMany(ctx context.Context, posts []models.Post) ([]string, error) {
+	ids := make([]string, len(posts))
+	for i := range posts {
+		ids[i] = strconv.Itoa(len(s.posts) + i + 1)
+	}
+	s.posts = append(s.posts, posts...)
+	return ids, nil
+}
+
+func (s *memoryStore) ReplaceAllBatched(ctx context.Context, fill func(insert func([]models.Post) error) error) error {
+	var posts []models.Post
+	err := fill(func(batch []models.Post) error {
+		posts = append(posts, batch...)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.posts = posts
+	return nil
+}
+
+func TestParseMode(t *testing.T) {
+	for _, mode := range []string{"append", "replace", "reset"} {
+		parsed, err := ParseMode(mode)
+		require.NoError(t, err)
+		assert.Equal(t, Mode(mode), parsed)
+	}
+
+	_, err := ParseMode("truncate")
+	assert.Error(t, err)
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     Options
+		existing int
+		expected int
+		wantErr  bool
+	}{
+		{name: "append", opts: Options{Mode: ModeAppend, Count: 5}, existing: 2, expected: 7},
+		{name: "replace", opts: Options{Mode: ModeReplace, Count: 5}, existing: 2, expected: 5},
+		{name: "reset ignores count", opts: Options{Mode: ModeReset, Count: 5}, existing: 2, expected: len(sampleTitles)},
+		{name: "negative count", opts: Options{Mode: ModeAppend, Count: -1}, existing: 2, expected: 2, wantErr: true},
+		{name: "unknown mode", opts: Options{Mode: "drop"}, existing: 2, expected: 2, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &memoryStore{posts: make([]models.Post, tt.existing)}
+
+			_, err := Run(context.Background(), store, tt.opts)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Len(t, store.posts, tt.expected)
+		})
+	}
+}
+
+func TestRun_Media(t *testing.T) {
+	ctx := context.Background()
+	files, err := media.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	info, err := files.Save(ctx, "photo.png", "image/png", strings.NewReader("photo"))
+	require.NoError(t, err)
+	store := &memoryStore{posts: []models.Post{{Title: "Old", Attachments: []models.Attachment{{ID: info.ID}}}}}
+
+	_, err = Run(ctx, store, Options{Mode: ModeAppend, Count: 1, Media: files})
+	require.NoError(t, err)
+	_, err = files.Open(ctx, info.ID)
+	assert.NoError(t, err, "appending keeps the files")
+
+	_, err = Run(ctx, store, Options{Mode: ModeReset, Media: files})
+	require.NoError(t, err)
+	_, err = files.Open(ctx, info.ID)
+	assert.ErrorIs(t, err, media.ErrNotFound, "the files of replaced posts are deleted")
+}
+
+func TestRun_DeterministicSeed(t *testing.T) {
+	first := &memoryStore{}
+	second := &memoryStore{}
+	opts := Options{Mode: ModeReplace, Count: 20, Seed: 7}
+
+	_, err := Run(context.Background(), first, opts)
+	require.NoError(t, err)
+	_, err = Run(context.Background(), second, opts)
+	require.NoError(t, err)
+
+	require.Len(t, second.posts, len(first.posts))
+	for i := range first.posts {
+		assert.Equal(t, first.posts[i].Title, second.posts[i].Title)
+		// Timestamps are relative to now, so compare their order rather than absolute values
+		if i > 0 {
+			assert.Equal(t,
+				first.posts[i].CreatedAt.Before(first.posts[i-1].CreatedAt),
+				second.posts[i].CreatedAt.Before(second.posts[i-1].CreatedAt))
+		}
+	}
+}
+
+func TestFixtures(t *testing.T) {
+	fixtures := Fixtures()
+	require.Len(t, fixtures, len(sampleTitles))
+
+	for i := 1; i < len(fixtures); i++ {
+		assert.True(t, fixtures[i].CreatedAt.Before(fixtures[i-1].CreatedAt))
+	}
+}
@@ -0,0 +1,434 @@
+package sitegen
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gekich/news-app/feed"
+	"github.com/gekich/news-app/i18n"
+	"github.com/gekich/news-app/media"
+	"github.com/gekich/news-app/models"
+	"github.com/gekich/news-app/repository"
+	"github.com/gekich/news-app/sitemap"
+	"github.com/gekich/news-app/static"
+	"github.com/gekich/news-app/templates"
+	"github.com/gekich/news-app/views"
+)
+
+// DefaultPageSize is the number of posts per index page when none is given
+const DefaultPageSize = 12
+
+// sitemapMaxURLs is the number of entries per sitemap before /sitemap.xml
+// becomes a sitemap index
+var sitemapMaxURLs int64 = sitemap.MaxURLs
+
+// listExcerpt is the number of characters of content kept for index pages,
+// one more than the post_list template shows so it still adds the ellipsis
+const listExcerpt = 201
+
+// Source is the subset of repository.PostStore used to build the site
+type Source interface {
+	Stream(ctx context.Context, filter repository.PostFilter, fn func(models.Post) error) error
+}
+
+// Options configures a build
+type Options struct {
+	// OutputDir receives the generated site
+	OutputDir string
+	// Assets are copied to OutputDir/static when set
+	Assets *static.Assets
+	// Channel describes the site for the feeds and the sitemap
+	Channel feed.Channel
+	// PageSize is the number of posts per index page, DefaultPageSize when zero
+	PageSize int
+	// FeedSize is the number of posts in the feeds, feed.DefaultSize when zero
+	FeedSize int
+	// Force rebuilds every page, e.g. after the templates changed
+	Force bool
+	// Locale the pages are translated into, the default locale of the
+	// templates when empty. Changing it needs Force, like template changes.
+	// The index and the feeds list the posts in that language.
+	Locale string
+	// FallbackLanguage is the language of posts without one, which the
+	// lists fall back to for stories that aren't translated into theirs.
+	// It is the locale when empty.
+	FallbackLanguage string
+	// Media holds the attachments of posts, which are copied to
+	// OutputDir/media when set
+	Media media.Store
+}
+
+// Result summarizes a build
+type Result struct {
+	Posts        int
+	PostsWritten int
+	PostsRemoved int
+	Pages        int
+	PagesWritten int
+	StaticFiles  int
+	MediaFiles   int
+}
+
+// Build renders every published post and the paginated post index through
+// the post_list and show templates into opts.OutputDir, along with RSS and
+// Atom feeds and a sitemap. Pages are only rewritten when their posts'
+// UpdatedAt or translations changed since the previous build. Sites with
+// posts in several languages get feeds for each language as well, at
+// /feed.{language}.xml and /atom.{language}.xml.
+func Build(ctx context.Context, src Source, tmpl *templates.Registry, opts Options) (Result, error) {
+	var result Result
+
+	if opts.PageSize <= 0 {
+		opts.PageSize = DefaultPageSize
+	}
+	if opts.FeedSize <= 0 {
+		opts.FeedSize = feed.DefaultSize
+	}
+	opts.Channel.BaseURL = strings.TrimRight(opts.Channel.BaseURL, "/")
+	opts.Channel.PostPath = PostPath
+	language := opts.Locale
+	if language == "" {
+		language = i18n.DefaultLocale
+	}
+	if opts.FallbackLanguage == "" {
+		opts.FallbackLanguage = language
+	}
+
+	for _, page := range []templates.Page{templates.PostList, templates.PostShow} {
+		if !tmpl.Has(page) {
+			return result, fmt.Errorf("%w: page %s", templates.ErrMissingTemplate, page)
+		}
+	}
+
+	if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
+		return result, err
+	}
+
+	previous, err := loadManifest(opts.OutputDir)
+	if err != nil {
+		return result, fmt.Errorf("failed to read build manifest: %w", err)
+	}
+	current := newManifest()
+
+	translations, err := loadTranslations(ctx, src, opts.FallbackLanguage)
+	if err != nil {
+		return result, err
+	}
+	// A site in a single language only has the main feeds
+	if languages := translations.sorted(); len(languages) > 1 {
+		current.Feeds = languages
+	}
+	feedLanguages := current.Feeds
+	if !slices.Contains(feedLanguages, language) {
+		feedLanguages = append([]string{language}, feedLanguages...)
+	}
+
+	var listed, indexed []models.Post
+	var attachments []models.Attachment
+	recent := make(map[string][]models.Post)
+	digest := sha256.New()
+
+	err = src.Stream(ctx, repository.PostFilter{Status: models.StatusPublished}, func(post models.Post) error {
+		id := post.ID.Hex()
+		path := PostPath(post)
+		linked := translations.digest(post)
+		current.Posts[id] = builtPost{Path: path, UpdatedAt: post.UpdatedAt, Translations: linked}
+		fmt.Fprintf(digest, "%s %s %d %s %s\n", id, path, post.UpdatedAt.UnixNano(), translations.language(post), linked)
+
+		built, ok := previous.Posts[id]
+		if opts.Force || !ok || built.Path != path || !built.UpdatedAt.Equal(post.UpdatedAt) ||
+			built.Translations != linked || !exists(opts.OutputDir, path) {
+			if err := render(tmpl, templates.PostShow, opts, path, translations.page(post, opts.Channel.BaseURL)); err != nil {
+				return fmt.Errorf("failed to render post %s: %w", id, err)
+			}
+			result.PostsWritten++
+		}
+
+		for _, feedLanguage := range feedLanguages {
+			if len(recent[feedLanguage]) < opts.FeedSize && translations.listed(post, feedLanguage) {
+				recent[feedLanguage] = append(recent[feedLanguage], post)
+			}
+		}
+
+		attachments = append(attachments, post.Attachments...)
+
+		if utf8.RuneCountInString(post.Content) > listExcerpt {
+			post.Content = string([]rune(post.Content)[:listExcerpt])
+		}
+		listed = append(listed, post)
+		if translations.listed(post, language) {
+			indexed = append(indexed, post)
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+	result.Posts = len(listed)
+
+	// Remove the pages of posts that were deleted or unpublished, and the
+	// old pages of posts whose slug changed
+	for id, built := range previous.Posts {
+		post, ok := current.Posts[id]
+		if ok && post.Path == built.Path {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Dir(outputPath(opts.OutputDir, built.Path))); err != nil {
+			return result, err
+		}
+		if !ok {
+			result.PostsRemoved++
+		}
+	}
+
+	if err := buildIndex(tmpl, indexed, previous, current, opts, &result); err != nil {
+		return result, err
+	}
+
+	current.Digest = hex.EncodeToString(digest.Sum(nil))
+	if opts.Force || current.Digest != previous.Digest || !exists(opts.OutputDir, "/sitemap.xml") {
+		if err := writeFeeds(opts, "", recent[language]); err != nil {
+			return result, err
+		}
+		for _, feedLanguage := range current.Feeds {
+			if err := writeFeeds(opts, "."+feedLanguage, recent[feedLanguage]); err != nil {
+				return result, err
+			}
+		}
+		// Remove the feeds of languages the site no longer has posts in
+		for _, feedLanguage := range previous.Feeds {
+			if !slices.Contains(current.Feeds, feedLanguage) {
+				for _, name := range []string{"/feed." + feedLanguage + ".xml", "/atom." + feedLanguage + ".xml"} {
+					if err := os.Remove(outputPath(opts.OutputDir, name)); err != nil && !os.IsNotExist(err) {
+						return result, err
+					}
+				}
+			}
+		}
+		if current.Sitemaps, err = writeSitemap(opts, listed, previous.Sitemaps); err != nil {
+			return result, err
+		}
+	} else {
+		current.Sitemaps = previous.Sitemaps
+	}
+
+	if opts.Assets != nil {
+		if result.StaticFiles, err = copyStatic(opts.Assets, filepath.Join(opts.OutputDir, "static")); err != nil {
+			return result, fmt.Errorf("failed to copy static files: %w", err)
+		}
+	}
+	if opts.Media != nil {
+		if result.MediaFiles, err = copyMedia(ctx, opts.Media, attachments, filepath.Join(opts.OutputDir, "media")); err != nil {
+			return result, fmt.Errorf("failed to copy attachments: %w", err)
+		}
+	}
+
+	return result, current.save(opts.OutputDir)
+}
+
+// buildIndex renders the pages of the post index that changed. The first
+// page doubles as the home page.
+func buildIndex(tmpl *templates.Registry, posts []models.Post, previous, current *manifest, opts Options, result *Result) error {
+	totalPages := (len(posts) + opts.PageSize - 1) / opts.PageSize
+	if totalPages == 0 {
+		totalPages = 1
+	}
+	result.Pages = totalPages
+
+	for page := 1; page <= totalPages; page++ {
+		start := (page - 1) * opts.PageSize
+		end := min(start+opts.PageSize, len(posts))
+		pagePosts := posts[start:end]
+
+		path := IndexPath(page)
+		digest := pageDigest(pagePosts, totalPages)
+		current.Pages[path] = digest
+		if !opts.Force && previous.Pages[path] == digest && exists(opts.OutputDir, path) {
+			continue
+		}
+
+		data := &views.ListPage{
+			Posts:       pagePosts,
+			CurrentPage: page,
+			TotalPages:  totalPages,
+		}
+		if err := render(tmpl, templates.PostList, opts, path, data); err != nil {
+			return fmt.Errorf("failed to render page %d: %w", page, err)
+		}
+		if page == 1 {
+			if err := render(tmpl, templates.PostList, opts, "/", data); err != nil {
+				return fmt.Errorf("failed to render home page: %w", err)
+			}
+		}
+		result.PagesWritten++
+	}
+
+	// Remove pages past the new end of the index
+	for path := range previous.Pages {
+		if _, ok := current.Pages[path]; !ok {
+			if err := os.RemoveAll(filepath.Dir(outputPath(opts.OutputDir, path))); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// pageDigest identifies the content of an index page
+func pageDigest(posts []models.Post, totalPages int) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "pages %d\n", totalPages)
+	for _, post := range posts {
+		fmt.Fprintf(h, "%s %s %d\n", post.ID.Hex(), post.Slug, post.UpdatedAt.UnixNano())
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeFeeds writes the RSS and Atom feeds of the most recent posts, with
+// suffix, like ".uk", added to their names
+func writeFeeds(opts Options, suffix string, posts []models.Post) error {
+	var rss bytes.Buffer
+	if err := feed.WriteRSS(&rss, opts.Channel, posts); err != nil {
+		return err
+	}
+	if err := writeFile(outputPath(opts.OutputDir, "/feed"+suffix+".xml"), rss.Bytes()); err != nil {
+		return err
+	}
+
+	atomPath := "/atom" + suffix + ".xml"
+	var atom bytes.Buffer
+	if err := feed.WriteAtom(&atom, opts.Channel, opts.Channel.BaseURL+atomPath, posts); err != nil {
+		return err
+	}
+	return writeFile(outputPath(opts.OutputDir, atomPath), atom.Bytes())
+}
+
+// writeSitemap lists the index and every post. Like the sitemap the server
+// serves, one with too many entries is split into /sitemap-{n}.xml files
+// that /sitemap.xml refers to as a sitemap index. It returns the number of
+// those files, 0 when the sitemap fits into /sitemap.xml, and removes the
+// ones left over from a previous build of previousPages.
+func writeSitemap(opts Options, posts []models.Post, previousPages int) (int, error) {
+	home := sitemap.URL{Loc: opts.Channel.BaseURL + IndexPath(1)}
+	if len(posts) > 0 {
+		home.LastMod = posts[0].UpdatedAt
+	}
+	entries := []sitemap.URL{home}
+	for _, post := range posts {
+		entries = append(entries, sitemap.URL{Loc: opts.Channel.PostURL(post), LastMod: post.UpdatedAt})
+	}
+
+	pages := int(sitemap.Pages(int64(len(entries)), sitemapMaxURLs))
+	if pages == 1 {
+		if err := writeSitemapFile(opts, "/sitemap.xml", entries); err != nil {
+			return 0, err
+		}
+		pages = 0
+	} else {
+		for page := 1; page <= pages; page++ {
+			start := (page - 1) * int(sitemapMaxURLs)
+			end := min(start+int(sitemapMaxURLs), len(entries))
+			if err := writeSitemapFile(opts, sitemap.PageURL("", int64(page)), entries[start:end]); err != nil {
+				return 0, err
+			}
+		}
+
+		var buf bytes.Buffer
+		if err := sitemap.WriteIndex(&buf, opts.Channel.BaseURL, int64(pages)); err != nil {
+			return 0, err
+		}
+		if err := writeFile(outputPath(opts.OutputDir, "/sitemap.xml"), buf.Bytes()); err != nil {
+			return 0, err
+		}
+	}
+
+	for page := pages + 1; page <= previousPages; page++ {
+		if err := os.Remove(outputPath(opts.OutputDir, sitemap.PageURL("", int64(page)))); err != nil && !os.IsNotExist(err) {
+			return 0, err
+		}
+	}
+	return pages, nil
+}
+
+// writeSitemapFile writes a sitemap of entries to the file serving path
+func writeSitemapFile(opts Options, path string, entries []sitemap.URL) error {
+	var buf bytes.Buffer
+	w := sitemap.NewWriter(&buf)
+	for _, entry := range entries {
+		if err := w.Add(entry); err != nil {
+			return err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return writeFile(outputPath(opts.OutputDir, path), buf.Bytes())
+}
+
+// render executes a page, rewrites its links for static hosting and writes
+// it to the file serving path
+func render(tmpl *templates.Registry, page templates.Page, opts Options, path string, data views.View) error {
+	*data.LayoutData() = views.Layout{SiteTitle: opts.Channel.Title, Locale: opts.Locale}
+
+	var buf bytes.Buffer
+	if err := tmpl.In(opts.Locale).Render(&buf, page, data); err != nil {
+		return err
+	}
+
+	static, err := rewriteLinks(buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	return writeFile(outputPath(opts.OutputDir, path), static)
+}
+
+// outputPath maps a site path to a file in the output directory. Paths
+// ending in a slash are served from their index.html.
+func outputPath(dir, path string) string {
+	if strings.HasSuffix(path, "/") {
+		path += "index.html"
+	}
+	return filepath.Join(dir, filepath.FromSlash(path))
+}
+
+// exists reports whether the file serving path has been written
+func exists(dir, path string) bool {
+	_, err := os.Stat(outputPath(dir, path))
+	return err == nil
+}
+
+// writeFile replaces a file atomically so a half-built site is never served
+func writeFile(name string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
@@ -0,0 +1,359 @@
+//go:build unit
+
+package sitegen
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gekich/news-app/feed"
+	"github.com/gekich/news-app/media"
+	"github.com/gekich/news-app/models"
+	"github.com/gekich/news-app/repository"
+	"github.com/gekich/news-app/static"
+	"github.com/gekich/news-app/templates"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memorySource struct {
+	posts []models.Post
+}
+
+func (s *memorySource) Stream(ctx context.Context, filter repository.PostFilter, fn func(models.Post) error) error {
+	for _, post := range s.posts {
+		if filter.Status != "" && post.Status != filter.Status {
+			continue
+		}
+		if err := fn(post); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newSource(count int) *memorySource {
+	created := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	src := &memorySource{}
+	for i := count; i > 0; i-- {
+		src.posts = append(src.posts, models.Post{
+			ID:        primitive.NewObjectID(),
+			Title:     fmt.Sprintf("Post %d", i),
+			Content:   fmt.Sprintf("Content of post %d", i),
+			Status:    models.StatusPublished,
+			CreatedAt: created.Add(time.Duration(i) * time.Hour),
+			UpdatedAt: created.Add(time.Duration(i) * time.Hour),
+		})
+	}
+	src.posts = append(src.posts, models.Post{
+		ID:        primitive.NewObjectID(),
+		Title:     "Unpublished draft",
+		Content:   "Not part of the static site",
+		Status:    models.StatusDraft,
+		CreatedAt: created,
+		UpdatedAt: created,
+	})
+	return src
+}
+
+func build(t *testing.T, src Source, dir string) Result {
+	t.Helper()
+	return buildIn(t, src, dir, "")
+}
+
+// buildIn builds the site translated into locale
+func buildIn(t *testing.T, src Source, dir, locale string) Result {
+	t.Helper()
+	assets, err := static.NewAssets(static.Files(""))
+	require.NoError(t, err)
+
+	registry, err := templates.Load("", assets)
+	require.NoError(t, err)
+
+	result, err := Build(context.Background(), src, registry, Options{
+		OutputDir: dir,
+		Assets:    assets,
+		Channel:   feed.Channel{Title: "News App", BaseURL: "https://news.example.com/"},
+		PageSize:  2,
+		Locale:    locale,
+	})
+	require.NoError(t, err)
+	return result
+}
+
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(name)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestBuild(t *testing.T) {
+	dir := t.TempDir()
+	src := newSource(5)
+
+	result := build(t, src, dir)
+	assert.Equal(t, 5, result.Posts)
+	assert.Equal(t, 5, result.PostsWritten)
+	assert.Equal(t, 3, result.Pages)
+	assert.Greater(t, result.StaticFiles, 0)
+
+	first := src.posts[0]
+	post := readFile(t, filepath.Join(dir, "posts", first.ID.Hex(), "index.html"))
+	assert.Contains(t, post, first.Title)
+	assert.Contains(t, post, `href="/posts/"`)
+	assert.NotContains(t, post, "hx-")
+	assert.NotContains(t, post, "<form")
+	assert.NotContains(t, post, "/edit")
+	assert.NotContains(t, post, "htmx.org")
+
+	index := readFile(t, filepath.Join(dir, "posts", "index.html"))
+	assert.Contains(t, index, fmt.Sprintf(`href="/posts/%s/"`, first.ID.Hex()))
+	assert.Contains(t, index, `href="/posts/page/2/"`)
+	assert.NotContains(t, index, "hx-")
+	assert.NotContains(t, index, "Unpublished draft")
+	assert.Equal(t, index, readFile(t, filepath.Join(dir, "index.html")))
+	assert.FileExists(t, filepath.Join(dir, "posts", "page", "3", "index.html"))
+	assert.NoFileExists(t, filepath.Join(dir, "posts", src.posts[5].ID.Hex(), "index.html"))
+
+	sitemap := readFile(t, filepath.Join(dir, "sitemap.xml"))
+	assert.Contains(t, sitemap, fmt.Sprintf("<loc>https://news.example.com/posts/%s/</loc>", first.ID.Hex()))
+	assert.Contains(t, readFile(t, filepath.Join(dir, "feed.xml")), "<title>Post 5</title>")
+	assert.Contains(t, readFile(t, filepath.Join(dir, "atom.xml")), `rel="self"`)
+	assert.FileExists(t, filepath.Join(dir, "static", "css", "main.css"))
+	assert.Regexp(t, `<link href="/static/css/main\.[0-9a-f]{8}\.css"`, index)
+	fingerprinted := regexp.MustCompile(`/static/(css/main\.[0-9a-f]{8}\.css)`).FindStringSubmatch(index)
+	require.NotNil(t, fingerprinted)
+	assert.FileExists(t, filepath.Join(dir, "static", filepath.FromSlash(fingerprinted[1])))
+}
+
+func TestBuild_Excerpt(t *testing.T) {
+	dir := t.TempDir()
+	src := newSource(1)
+	src.posts[0].Content = strings.Repeat("Новини ", 50)
+
+	build(t, src, dir)
+	index := readFile(t, filepath.Join(dir, "posts", "index.html"))
+	assert.True(t, utf8.ValidString(index), "excerpts are cut between characters")
+	assert.Contains(t, index, strings.Repeat("Новини ", 28)+"Нови...")
+}
+
+func TestBuild_Locale(t *testing.T) {
+	dir := t.TempDir()
+	src := newSource(1)
+	buildIn(t, src, dir, "uk")
+
+	post := readFile(t, filepath.Join(dir, "posts", src.posts[0].ID.Hex(), "index.html"))
+	assert.Contains(t, post, `<html lang="uk">`)
+	assert.Contains(t, post, "Створено:")
+	assert.Contains(t, post, "До списку дописів")
+	// The static site has no server to switch the locale
+	assert.NotContains(t, post, "?lang=")
+}
+
+func TestBuild_Incremental(t *testing.T) {
+	dir := t.TempDir()
+	src := newSource(5)
+	build(t, src, dir)
+
+	result := build(t, src, dir)
+	assert.Equal(t, 0, result.PostsWritten)
+	assert.Equal(t, 0, result.PagesWritten)
+	assert.Equal(t, 0, result.StaticFiles)
+
+	// Updating the last post only touches its own page and the last index page
+	src.posts[4].Title = "Edited post"
+	src.posts[4].UpdatedAt = src.posts[4].UpdatedAt.Add(time.Minute)
+	result = build(t, src, dir)
+	assert.Equal(t, 1, result.PostsWritten)
+	assert.Equal(t, 1, result.PagesWritten)
+	assert.Contains(t, readFile(t, filepath.Join(dir, "posts", "page", "3", "index.html")), "Edited post")
+
+	// Deleting posts removes their pages and the pages past the end of the index
+	removed := src.posts[3]
+	src.posts = append(src.posts[:3], src.posts[5:]...)
+	result = build(t, src, dir)
+	assert.Equal(t, 2, result.PostsRemoved)
+	assert.Equal(t, 2, result.Pages)
+	assert.NoDirExists(t, filepath.Join(dir, "posts", removed.ID.Hex()))
+	assert.NoDirExists(t, filepath.Join(dir, "posts", "page", "3"))
+}
+
+func TestBuild_SitemapIndex(t *testing.T) {
+	defer func(max int64) { sitemapMaxURLs = max }(sitemapMaxURLs)
+	sitemapMaxURLs = 4
+
+	dir := t.TempDir()
+	src := newSource(5)
+	build(t, src, dir)
+
+	// The index and five posts take two sitemaps
+	index := readFile(t, filepath.Join(dir, "sitemap.xml"))
+	assert.Contains(t, index, "<sitemapindex")
+	assert.Contains(t, index, "<loc>https://news.example.com/sitemap-1.xml</loc>")
+	assert.Contains(t, index, "<loc>https://news.example.com/sitemap-2.xml</loc>")
+	first := readFile(t, filepath.Join(dir, "sitemap-1.xml"))
+	assert.Contains(t, first, "<loc>https://news.example.com/posts/</loc>")
+	assert.Equal(t, 4, strings.Count(first, "<url>"))
+	assert.Equal(t, 2, strings.Count(readFile(t, filepath.Join(dir, "sitemap-2.xml")), "<url>"))
+
+	// Once the entries fit into one sitemap again, the parts are removed
+	src.posts = src.posts[3:]
+	build(t, src, dir)
+	sitemap := readFile(t, filepath.Join(dir, "sitemap.xml"))
+	assert.Contains(t, sitemap, "<urlset")
+	assert.Equal(t, 3, strings.Count(sitemap, "<url>"))
+	assert.NoFileExists(t, filepath.Join(dir, "sitemap-1.xml"))
+	assert.NoFileExists(t, filepath.Join(dir, "sitemap-2.xml"))
+}
+
+// translate adds a published Ukrainian translation of post to the source
+func (s *memorySource) translate(post *models.Post, title string) models.Post {
+	post.TranslationGroup = post.ID.Hex()
+	post.UpdatedAt = post.UpdatedAt.Add(time.Minute)
+	translation := models.Post{
+		ID:               primitive.NewObjectID(),
+		Title:            title,
+		Content:          "Переклад: " + post.Content,
+		Status:           models.StatusPublished,
+		Language:         "uk",
+		TranslationGroup: post.TranslationGroup,
+		CreatedAt:        post.CreatedAt,
+		UpdatedAt:        post.UpdatedAt,
+	}
+	s.posts = append(s.posts, translation)
+	return translation
+}
+
+func TestBuild_Translations(t *testing.T) {
+	dir := t.TempDir()
+	src := newSource(3)
+	source := &src.posts[0]
+	translation := src.translate(source, "Допис 3")
+
+	result := build(t, src, dir)
+	assert.Equal(t, 4, result.Posts)
+
+	page := readFile(t, filepath.Join(dir, "posts", source.ID.Hex(), "index.html"))
+	assert.Contains(t, page, fmt.Sprintf(`<link rel="alternate" hreflang="uk" href="https://news.example.com/posts/%s/"`, translation.ID.Hex()))
+	assert.Contains(t, page, fmt.Sprintf(`<link rel="alternate" hreflang="x-default" href="https://news.example.com/posts/%s/"`, source.ID.Hex()))
+	// The static site has no server to add translations
+	assert.NotContains(t, page, "/translate")
+
+	// The index lists each story once, in the site's language
+	var index string
+	for _, name := range []string{"index.html", filepath.Join("page", "2", "index.html")} {
+		index += readFile(t, filepath.Join(dir, "posts", name))
+	}
+	assert.Contains(t, index, source.Title)
+	assert.NotContains(t, index, translation.Title)
+
+	feed := readFile(t, filepath.Join(dir, "feed.uk.xml"))
+	assert.Contains(t, feed, "<title>Допис 3</title>")
+	assert.Contains(t, feed, "<title>Post 2</title>")
+	assert.NotContains(t, feed, "<title>Post 3</title>")
+	assert.FileExists(t, filepath.Join(dir, "atom.uk.xml"))
+	assert.NotContains(t, readFile(t, filepath.Join(dir, "feed.xml")), "Допис 3")
+	sitemap := readFile(t, filepath.Join(dir, "sitemap.xml"))
+	assert.Contains(t, sitemap, translation.ID.Hex())
+
+	// Translating another post rebuilds its page without changing it, to
+	// link the translation
+	second := &src.posts[1]
+	updated := second.UpdatedAt
+	src.translate(second, "Допис 2")
+	second.UpdatedAt = updated
+	result = build(t, src, dir)
+	assert.Equal(t, 2, result.PostsWritten)
+	assert.Contains(t, readFile(t, filepath.Join(dir, "posts", second.ID.Hex(), "index.html")), `hreflang="uk"`)
+
+	// Removing the last translation removes the language feeds
+	src.posts = src.posts[:len(newSource(3).posts)]
+	src.posts[0].TranslationGroup, src.posts[1].TranslationGroup = "", ""
+	build(t, src, dir)
+	assert.NoFileExists(t, filepath.Join(dir, "feed.uk.xml"))
+	assert.NoFileExists(t, filepath.Join(dir, "atom.uk.xml"))
+}
+
+func TestBuild_Media(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := media.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	attach := func(post *models.Post, filename string) models.Attachment {
+		info, err := store.Save(ctx, filename, "image/png", strings.NewReader("\x89PNG\r\n\x1a\n"))
+		require.NoError(t, err)
+		attachment := models.Attachment{ID: info.ID, Filename: filename, ContentType: info.ContentType, Size: info.Size}
+		post.Attachments = append(post.Attachments, attachment)
+		return attachment
+	}
+
+	src := newSource(2)
+	photo := attach(&src.posts[0], "photo.png")
+	draft := attach(&src.posts[2], "draft.png")
+	// Resized copies of images are copied along with them
+	info, err := store.Save(ctx, "photo-320w.png", "image/png", strings.NewReader("\x89PNG\r\n\x1a\n"))
+	require.NoError(t, err)
+	thumbnail := models.ImageVariant{ID: info.ID, ContentType: "image/png", Width: 320, Height: 240}
+	src.posts[0].Attachments[0].Variants = []models.ImageVariant{thumbnail}
+
+	assets, err := static.NewAssets(static.Files(""))
+	require.NoError(t, err)
+	registry, err := templates.Load("", assets)
+	require.NoError(t, err)
+	opts := Options{
+		OutputDir: dir,
+		Channel:   feed.Channel{Title: "News App", BaseURL: "https://news.example.com/"},
+		Media:     store,
+	}
+
+	result, err := Build(ctx, src, registry, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.MediaFiles)
+	assert.FileExists(t, filepath.Join(dir, filepath.FromSlash(photo.URL())))
+	assert.FileExists(t, filepath.Join(dir, filepath.FromSlash(thumbnail.URL())))
+	assert.NoFileExists(t, filepath.Join(dir, filepath.FromSlash(draft.URL())), "attachments of drafts are not published")
+	page := readFile(t, filepath.Join(dir, "posts", src.posts[0].ID.Hex(), "index.html"))
+	assert.Contains(t, page, photo.URL())
+	assert.Contains(t, page, `srcset="`+thumbnail.URL()+` 320w"`)
+
+	// Copied files are kept, and those of removed attachments deleted
+	src.posts[0].Attachments = nil
+	poster := attach(&src.posts[1], "poster.png")
+	result, err = Build(ctx, src, registry, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.MediaFiles)
+	assert.FileExists(t, filepath.Join(dir, filepath.FromSlash(poster.URL())))
+	assert.NoFileExists(t, filepath.Join(dir, filepath.FromSlash(photo.URL())))
+	assert.NoFileExists(t, filepath.Join(dir, filepath.FromSlash(thumbnail.URL())))
+}
+
+func TestStaticPath(t *testing.T) {
+	tests := map[string]string{
+		"/":                                    "/posts/",
+		"/posts":                               "/posts/",
+		"/posts?page=1":                        "/posts/",
+		"/posts?page=4":                        "/posts/page/4/",
+		"/posts?page=2&search=go":              "",
+		"/posts/5f1d7f0c8e3a4b2a9c0d1e2f":      "/posts/5f1d7f0c8e3a4b2a9c0d1e2f/",
+		"/posts/5f1d7f0c8e3a4b2a9c0d1e2f/edit": "",
+		"/posts/new":                           "",
+		"/admin/import":                        "",
+		"/static/css/style.css":                "/static/css/style.css",
+		"/feed.xml":                            "/feed.xml",
+		"/media/5f1d7f0c8e3a4b2a9c0d1e2f.png":  "/media/5f1d7f0c8e3a4b2a9c0d1e2f.png",
+	}
+
+	for link, want := range tests {
+		assert.Equal(t, want, staticPath(link), link)
+	}
+}
@@ -0,0 +1,179 @@
+package sitegen
+
+import (
+	"bytes"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gekich/news-app/models"
+	"golang.org/x/net/html"
+)
+
+// postPathPattern matches the path of a single post, by slug or ID
+var postPathPattern = regexp.MustCompile(`^/posts/([a-z0-9]+(?:-[a-z0-9]+)*)/?$`)
+
+// routes under /posts/ that aren't posts
+var reservedPaths = map[string]bool{
+	"new":  true,
+	"seed": true,
+	"page": true,
+}
+
+// files published at the root of the site as-is
+var rootFiles = map[string]bool{
+	"/feed.xml":    true,
+	"/atom.xml":    true,
+	"/sitemap.xml": true,
+}
+
+// IndexPath returns the path of a page of the post index
+func IndexPath(page int) string {
+	if page <= 1 {
+		return "/posts/"
+	}
+	return "/posts/page/" + strconv.Itoa(page) + "/"
+}
+
+// PostPath returns the path of a post's page
+func PostPath(post models.Post) string {
+	return post.Path() + "/"
+}
+
+// staticPath maps a link of the dynamic site to its static counterpart. It
+// returns "" for links that only work against the server, such as forms,
+// search results and the admin pages.
+func staticPath(link string) string {
+	u, err := url.Parse(link)
+	if err != nil || u.Host != "" || !strings.HasPrefix(u.Path, "/") {
+		return ""
+	}
+
+	switch {
+	case u.Path == "/" || u.Path == "/posts" || u.Path == "/posts/":
+		if u.Query().Get("search") != "" {
+			return ""
+		}
+		page, _ := strconv.Atoi(u.Query().Get("page"))
+		return IndexPath(page)
+	case postPathPattern.MatchString(u.Path):
+		ref := postPathPattern.FindStringSubmatch(u.Path)[1]
+		if reservedPaths[ref] {
+			return ""
+		}
+		return "/posts/" + ref + "/"
+	case strings.HasPrefix(u.Path, "/static/"), strings.HasPrefix(u.Path, "/media/"), rootFiles[u.Path]:
+		return u.Path
+	default:
+		return ""
+	}
+}
+
+// rewriteLinks turns a page rendered for the HTMX app into a static page.
+// hx-get links become plain hrefs, and controls that need the server (forms,
+// hx-post/put/delete buttons, admin links) are removed along with the htmx
+// script itself.
+func rewriteLinks(page []byte) ([]byte, error) {
+	doc, err := html.Parse(bytes.NewReader(page))
+	if err != nil {
+		return nil, err
+	}
+
+	var remove []*html.Node
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && !rewriteElement(n) {
+			remove = append(remove, n)
+			return
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+
+	for _, n := range remove {
+		n.Parent.RemoveChild(n)
+	}
+
+	var buf bytes.Buffer
+	if err := html.Render(&buf, doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// rewriteElement rewrites the links of a single element, returning false
+// when the element should be removed
+func rewriteElement(n *html.Node) bool {
+	switch n.Data {
+	case "form":
+		return false
+	case "script":
+		return !strings.Contains(getAttr(n, "src"), "htmx")
+	}
+
+	for _, method := range []string{"hx-post", "hx-put", "hx-patch", "hx-delete"} {
+		if hasAttr(n, method) {
+			return false
+		}
+	}
+
+	link := getAttr(n, "hx-get")
+	if link == "" && n.Data == "a" {
+		link = getAttr(n, "href")
+	}
+
+	if link != "" && (n.Data == "a" || hasAttr(n, "hx-get")) {
+		if strings.HasPrefix(link, "/") {
+			path := staticPath(link)
+			if path == "" {
+				return false
+			}
+			link = path
+		}
+		if n.Data == "a" {
+			setAttr(n, "href", link)
+		}
+	}
+
+	// Drop the remaining hx-* attributes
+	attrs := n.Attr[:0]
+	for _, a := range n.Attr {
+		if !strings.HasPrefix(a.Key, "hx-") {
+			attrs = append(attrs, a)
+		}
+	}
+	n.Attr = attrs
+
+	return true
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func getAttr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func setAttr(n *html.Node, key, value string) {
+	for i, a := range n.Attr {
+		if a.Key == key {
+			n.Attr[i].Val = value
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: value})
+}
@@ -0,0 +1,68 @@
+package sitegen
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// manifestFile records what was built so the next build only rewrites pages
+// whose posts changed. It lives in the output directory.
+const manifestFile = ".sitegen.json"
+
+// manifest is the state of the previous build
+type manifest struct {
+	// Posts maps post IDs to their rendered page
+	Posts map[string]builtPost `json:"posts"`
+	// Pages maps index page paths to a digest of the posts listed on them
+	Pages map[string]string `json:"pages"`
+	// Digest covers every published post, for the feeds and the sitemap
+	Digest string `json:"digest"`
+	// Feeds are the languages that got feeds of their own
+	Feeds []string `json:"feeds,omitempty"`
+	// Sitemaps is the number of /sitemap-{n}.xml files the sitemap is split
+	// into, 0 when it fits into /sitemap.xml
+	Sitemaps int `json:"sitemaps,omitempty"`
+}
+
+// builtPost records the page rendered for a post. Translations is a digest
+// of the translations the page links to.
+type builtPost struct {
+	Path         string    `json:"path"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	Translations string    `json:"translations,omitempty"`
+}
+
+func newManifest() *manifest {
+	return &manifest{Posts: make(map[string]builtPost), Pages: make(map[string]string)}
+}
+
+// loadManifest reads the manifest of the previous build, returning an empty
+// one when the output directory hasn't been built yet
+func loadManifest(dir string) (*manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return newManifest(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	m := newManifest()
+	if err := json.Unmarshal(data, m); err != nil {
+		// A corrupt manifest only costs a full rebuild
+		return newManifest(), nil
+	}
+	return m, nil
+}
+
+// save writes the manifest to the output directory
+func (m *manifest) save(dir string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(dir, manifestFile), data)
+}
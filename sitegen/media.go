@@ -0,0 +1,82 @@
+package sitegen
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/gekich/news-app/media"
+	"github.com/gekich/news-app/models"
+)
+
+// copyMedia writes the files of attachments, and the resized copies of
+// images, into dst, named like their URLs, and removes the files of
+// attachments that are gone. Stored files
+// never change, so files already in dst are kept as they are. It returns
+// the number of files written.
+func copyMedia(ctx context.Context, store media.Store, attachments []models.Attachment, dst string) (int, error) {
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return 0, err
+	}
+
+	// The files to copy by name
+	files := make(map[string]string)
+	for _, attachment := range attachments {
+		files[path.Base(attachment.URL())] = attachment.ID
+		for _, variant := range attachment.Variants {
+			files[path.Base(variant.URL())] = variant.ID
+		}
+	}
+
+	copied := 0
+	for name, id := range files {
+		if _, err := os.Stat(filepath.Join(dst, name)); err == nil {
+			continue
+		}
+		if err := copyMediaFile(ctx, store, id, filepath.Join(dst, name)); err != nil {
+			return copied, err
+		}
+		copied++
+	}
+
+	entries, err := os.ReadDir(dst)
+	if err != nil {
+		return copied, err
+	}
+	for _, entry := range entries {
+		if _, ok := files[entry.Name()]; !ok {
+			if err := os.Remove(filepath.Join(dst, entry.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return copied, err
+			}
+		}
+	}
+	return copied, nil
+}
+
+// copyMediaFile writes a stored file to target, through a temporary file so
+// an interrupted build doesn't leave a truncated one behind
+func copyMediaFile(ctx context.Context, store media.Store, id, target string) error {
+	src, err := store.Open(ctx, id)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".media-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, src)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
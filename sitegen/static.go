@@ -0,0 +1,47 @@
+package sitegen
+
+import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/gekich/news-app/static"
+)
+
+// copyStatic writes the assets into dst under both their plain and their
+// fingerprinted names, so the asset URLs in rendered pages resolve. Only
+// files that are missing or differ are written; old fingerprinted files are
+// kept for pages that weren't rebuilt. It returns the number of files
+// written.
+func copyStatic(assets *static.Assets, dst string) (int, error) {
+	copied := 0
+	for _, name := range assets.Names() {
+		data, err := fs.ReadFile(assets.Files(), name)
+		if err != nil {
+			return copied, err
+		}
+
+		for _, target := range []string{name, assets.Fingerprinted(name)} {
+			written, err := writeIfChanged(filepath.Join(dst, filepath.FromSlash(target)), data)
+			if err != nil {
+				return copied, err
+			}
+			if written {
+				copied++
+			}
+		}
+	}
+	return copied, nil
+}
+
+// writeIfChanged writes data to path unless the file already holds it
+func writeIfChanged(path string, data []byte) (bool, error) {
+	if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, data) {
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return false, err
+	}
+	return true, os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,134 @@
+package sitegen
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/gekich/news-app/models"
+	"github.com/gekich/news-app/repository"
+	"github.com/gekich/news-app/views"
+)
+
+// translations are the published posts of every translation group, and the
+// languages the site has posts in
+type translations struct {
+	groups    map[string][]models.Post
+	languages map[string]bool
+	// fallback is the language of posts without one
+	fallback string
+}
+
+// loadTranslations streams the published posts once to gather the
+// translations their pages link to. Only the fields of the links are kept.
+func loadTranslations(ctx context.Context, src Source, fallback string) (*translations, error) {
+	t := &translations{
+		groups:    make(map[string][]models.Post),
+		languages: make(map[string]bool),
+		fallback:  fallback,
+	}
+
+	err := src.Stream(ctx, repository.PostFilter{Status: models.StatusPublished}, func(post models.Post) error {
+		language := t.language(post)
+		t.languages[language] = true
+		if post.TranslationGroup != "" {
+			t.groups[post.TranslationGroup] = append(t.groups[post.TranslationGroup], models.Post{
+				ID:               post.ID,
+				Slug:             post.Slug,
+				Status:           post.Status,
+				Language:         language,
+				TranslationGroup: post.TranslationGroup,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, posts := range t.groups {
+		sort.Slice(posts, func(i, j int) bool { return posts[i].Language < posts[j].Language })
+	}
+	return t, nil
+}
+
+// language returns the language of a post, the fallback language for posts
+// without one
+func (t *translations) language(post models.Post) string {
+	if post.Language != "" {
+		return post.Language
+	}
+	return t.fallback
+}
+
+// of returns the translations of a post, none when it hasn't been translated
+func (t *translations) of(post models.Post) []models.Post {
+	if post.TranslationGroup == "" {
+		return nil
+	}
+	return t.groups[post.TranslationGroup]
+}
+
+// listed reports whether a post belongs to the lists in language: it is
+// written in it, or in the fallback language and its story has no
+// translation into language
+func (t *translations) listed(post models.Post, language string) bool {
+	switch t.language(post) {
+	case language:
+		return true
+	case t.fallback:
+		for _, translation := range t.of(post) {
+			if translation.Language == language {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// sorted returns the languages the site has posts in
+func (t *translations) sorted() []string {
+	languages := make([]string, 0, len(t.languages))
+	for language := range t.languages {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// page is the view model of a post's page, linking its translations
+func (t *translations) page(post models.Post, channel string) *views.PostPage {
+	post.Language = t.language(post)
+	data := &views.PostPage{Post: post, Translations: t.of(post)}
+	if len(data.Translations) < 2 {
+		return data
+	}
+
+	for _, translation := range data.Translations {
+		link := channel + PostPath(translation)
+		data.Alternates = append(data.Alternates, views.Alternate{Language: translation.Language, URL: link})
+		if translation.Language == t.fallback {
+			data.Alternates = append(data.Alternates, views.Alternate{Language: "x-default", URL: link})
+		}
+	}
+	return data
+}
+
+// digest identifies the translations a post's page links to, so the page is
+// rebuilt when one is added, renamed or removed
+func (t *translations) digest(post models.Post) string {
+	posts := t.of(post)
+	if len(posts) == 0 {
+		return ""
+	}
+
+	h := sha256.New()
+	for _, translation := range posts {
+		fmt.Fprintf(h, "%s %s %s\n", translation.ID.Hex(), translation.Language, PostPath(translation))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
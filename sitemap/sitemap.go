@@ -0,0 +1,142 @@
+package sitemap
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// MaxURLs is the largest number of entries a single sitemap may hold
+const MaxURLs = 50000
+
+// Pages returns the number of sitemaps entries are split into, with at most
+// perPage entries each. An empty sitemap still takes one.
+func Pages(entries, perPage int64) int64 {
+	if entries <= perPage {
+		return 1
+	}
+	return (entries + perPage - 1) / perPage
+}
+
+// PageURL returns the URL of one of the sitemaps of a site at baseURL whose
+// entries are split into several, like /sitemap-2.xml
+func PageURL(baseURL string, page int64) string {
+	return fmt.Sprintf("%s/sitemap-%d.xml", baseURL, page)
+}
+
+// WriteIndex writes the sitemap index of a site at baseURL referring to its
+// sitemaps at PageURL
+func WriteIndex(w io.Writer, baseURL string, pages int64) error {
+	index := NewIndexWriter(w)
+	for page := int64(1); page <= pages; page++ {
+		if err := index.Add(URL{Loc: PageURL(baseURL, page)}); err != nil {
+			return err
+		}
+	}
+	return index.Close()
+}
+
+// URL is a single entry of a sitemap
+type URL struct {
+	Loc     string
+	LastMod time.Time
+}
+
+// Writer streams a sitemap urlset without holding the entries in memory
+type Writer struct {
+	w       *bufio.Writer
+	started bool
+	count   int
+}
+
+// NewWriter returns a writer producing a sitemap on w
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// Add writes an entry
+func (s *Writer) Add(u URL) error {
+	if err := s.start(); err != nil {
+		return err
+	}
+
+	s.w.WriteString("  <url>\n    <loc>")
+	xml.EscapeText(s.w, []byte(u.Loc))
+	s.w.WriteString("</loc>\n")
+	if !u.LastMod.IsZero() {
+		s.w.WriteString("    <lastmod>" + u.LastMod.UTC().Format(time.RFC3339) + "</lastmod>\n")
+	}
+	_, err := s.w.WriteString("  </url>\n")
+	s.count++
+	return err
+}
+
+// Count returns the number of entries written so far
+func (s *Writer) Count() int {
+	return s.count
+}
+
+// Close finishes the document and flushes it
+func (s *Writer) Close() error {
+	if err := s.start(); err != nil {
+		return err
+	}
+	s.w.WriteString("</urlset>\n")
+	return s.w.Flush()
+}
+
+func (s *Writer) start() error {
+	if s.started {
+		return nil
+	}
+	s.started = true
+	_, err := s.w.WriteString(xml.Header + `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` + "\n")
+	return err
+}
+
+// IndexWriter streams a sitemap index pointing at other sitemaps
+type IndexWriter struct {
+	w       *bufio.Writer
+	started bool
+}
+
+// NewIndexWriter returns a writer producing a sitemap index on w
+func NewIndexWriter(w io.Writer) *IndexWriter {
+	return &IndexWriter{w: bufio.NewWriter(w)}
+}
+
+// Add writes a reference to a sitemap. LastMod may be zero.
+func (s *IndexWriter) Add(u URL) error {
+	if err := s.start(); err != nil {
+		return err
+	}
+
+	s.w.WriteString("  <sitemap>\n    <loc>")
+	xml.EscapeText(s.w, []byte(u.Loc))
+	s.w.WriteString("</loc>\n")
+	if !u.LastMod.IsZero() {
+		s.w.WriteString("    <lastmod>" + u.LastMod.UTC().Format(time.RFC3339) + "</lastmod>\n")
+	}
+	_, err := s.w.WriteString("  </sitemap>\n")
+	return err
+}
+
+// Close finishes the document and flushes it
+func (s *IndexWriter) Close() error {
+	if err := s.start(); err != nil {
+		return err
+	}
+	s.w.WriteString("</sitemapindex>\n")
+	return s.w.Flush()
+}
+
+func (s *IndexWriter) start() error {
+	if s.started {
+		return nil
+	}
+	s.started = true
+	_, err := s.w.WriteString(xml.Header + `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` + "\n")
+	return err
+}
@@ -0,0 +1,114 @@
+package slug
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxLength bounds the length of a generated slug, not counting the suffix
+// added to resolve collisions
+const MaxLength = 80
+
+// fallback is used for titles without any transliterable characters
+const fallback = "post"
+
+// reserved are path segments routed to something other than a post
+var reserved = map[string]bool{
+	"new":  true,
+	"seed": true,
+	"page": true,
+}
+
+// objectIDPattern matches slugs that would be mistaken for a post ID
+var objectIDPattern = regexp.MustCompile(`^[0-9a-f]{24}$`)
+
+// transliterations covers lowercase letters that don't decompose into ASCII
+var transliterations = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'ø': "o", 'œ': "oe", 'ł': "l", 'đ': "d", 'ð': "d", 'þ': "th",
+	'ı': "i", '&': "and",
+
+	// Cyrillic
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'ґ': "g", 'д': "d", 'е': "e", 'ё': "yo",
+	'є': "ye", 'ж': "zh", 'з': "z", 'и': "i", 'і': "i", 'ї': "yi", 'й': "y", 'к': "k",
+	'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t",
+	'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "",
+	'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+
+	// Greek
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th",
+	'ι': "i", 'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p",
+	'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t", 'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps",
+	'ω': "o",
+}
+
+// Make turns a title into a lowercase, URL-safe slug of ASCII letters, digits
+// and single hyphens. Accented letters lose their accents and Cyrillic and
+// Greek letters are transliterated; anything else becomes a word break.
+func Make(title string) string {
+	var b strings.Builder
+	hyphen := false
+
+	for _, r := range norm.NFKD.String(strings.ToLower(title)) {
+		var out string
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Combining accents left over from the decomposition
+			continue
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			out = string(r)
+		case r == '\'' || r == '’':
+			// Apostrophes join words: "don't" becomes "dont"
+			continue
+		default:
+			var ok bool
+			if out, ok = transliterations[r]; !ok {
+				hyphen = b.Len() > 0
+				continue
+			}
+		}
+
+		if out == "" {
+			continue
+		}
+		if hyphen {
+			b.WriteByte('-')
+			hyphen = false
+		}
+		b.WriteString(out)
+	}
+
+	s := truncate(b.String(), MaxLength)
+	switch {
+	case s == "":
+		return fallback
+	case reserved[s] || objectIDPattern.MatchString(s):
+		return s + "-" + fallback
+	default:
+		return s
+	}
+}
+
+// WithSuffix returns the n-th alternative of a slug for resolving collisions,
+// n starting at 2
+func WithSuffix(s string, n int) string {
+	if n < 2 {
+		return s
+	}
+	return s + "-" + strconv.Itoa(n)
+}
+
+// truncate shortens a slug to at most max bytes, preferring to cut at a hyphen
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	s = s[:max]
+	if i := strings.LastIndexByte(s, '-'); i > max/2 {
+		s = s[:i]
+	}
+	return strings.Trim(s, "-")
+}
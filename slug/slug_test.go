@@ -0,0 +1,49 @@
+//go:build unit
+
+package slug
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMake(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Hello, World!", "hello-world"},
+		{"  Spaces   everywhere  ", "spaces-everywhere"},
+		{"Don't Panic", "dont-panic"},
+		{"Crème brûlée à la française", "creme-brulee-a-la-francaise"},
+		{"Straße in Łódź", "strasse-in-lodz"},
+		{"Привет, мир", "privet-mir"},
+		{"Ελληνικά νέα", "ellinika-nea"},
+		{"Rock & Roll", "rock-and-roll"},
+		{"COVID-19 update", "covid-19-update"},
+		{"日本語", "post"},
+		{"", "post"},
+		{"New", "new-post"},
+		{"5f1d7f0c8e3a4b2a9c0d1e2f", "5f1d7f0c8e3a4b2a9c0d1e2f-post"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			assert.Equal(t, tt.want, Make(tt.title))
+		})
+	}
+}
+
+func TestMake_Truncates(t *testing.T) {
+	s := Make(strings.Repeat("word ", 40))
+	assert.LessOrEqual(t, len(s), MaxLength)
+	assert.False(t, strings.HasSuffix(s, "-"))
+	assert.True(t, strings.HasSuffix(s, "word"))
+}
+
+func TestWithSuffix(t *testing.T) {
+	assert.Equal(t, "title", WithSuffix("title", 1))
+	assert.Equal(t, "title-3", WithSuffix("title", 3))
+}
@@ -11,3 +11,58 @@ document.body.addEventListener('htmx:afterSwap', function(event) {
         content.classList.add('fade-in');
     }
 });
+
+// Flash messages arrive with the page, or through the "flash" event that
+// HTMX responses trigger with the HX-Trigger header. They are shown as
+// toasts, which disappear after a while or when clicked.
+const toastDuration = 5000;
+
+function dismissToast(toast) {
+    toast.classList.add('toast-hide');
+    setTimeout(function() {
+        toast.remove();
+    }, 300);
+}
+
+function scheduleToast(toast) {
+    toast.addEventListener('click', function() {
+        dismissToast(toast);
+    });
+    setTimeout(function() {
+        dismissToast(toast);
+    }, toastDuration);
+}
+
+function showToast(message) {
+    const toasts = document.getElementById('toasts');
+    if (!toasts) {
+        return;
+    }
+    const toast = document.createElement('div');
+    toast.className = 'toast toast-' + message.level;
+    toast.setAttribute('role', 'status');
+    toast.textContent = message.message;
+    toasts.appendChild(toast);
+    scheduleToast(toast);
+}
+
+document.querySelectorAll('#toasts .toast').forEach(scheduleToast);
+
+document.body.addEventListener('flash', function(event) {
+    (event.detail.messages || []).forEach(showToast);
+});
+
+// The language switcher links to the current page in another locale. HTMX
+// navigation changes the page without rendering the header again, so the
+// links follow the address bar.
+function updateLocaleLinks() {
+    document.querySelectorAll('#locales a[hreflang]').forEach(function(link) {
+        const url = new URL(window.location.href);
+        url.searchParams.set('lang', link.hreflang);
+        link.href = url.pathname + url.search;
+    });
+}
+
+['htmx:pushedIntoHistory', 'htmx:replacedInHistory', 'htmx:historyRestore'].forEach(function(name) {
+    document.body.addEventListener(name, updateLocaleLinks);
+});
@@ -0,0 +1,157 @@
+package static
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"io/fs"
+	"net/http"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gekich/news-app/overlay"
+)
+
+// embedded holds the assets shipped with the binary
+//
+//go:embed css js
+var embedded embed.FS
+
+// URLPrefix is where the router serves the assets
+const URLPrefix = "/static/"
+
+// hashLength is the number of hex digits of the content hash put in
+// fingerprinted names
+const hashLength = 8
+
+// immutable lets browsers and proxies keep fingerprinted assets for a year,
+// since a new version gets a new URL
+const immutable = "public, max-age=31536000, immutable"
+
+// fingerprintPattern splits a fingerprinted name like css/main.0123abcd.css
+var fingerprintPattern = regexp.MustCompile(`^(.*)\.[0-9a-f]{8}(\.[^./]+)$`)
+
+// Files returns the embedded assets, overridden by the files in dir when set
+func Files(dir string) fs.FS {
+	return overlay.New(dir, embedded)
+}
+
+// DevHandler serves files by name without fingerprints, revalidating them
+// on every use so edits show up on reload
+func DevHandler(files fs.FS) http.Handler {
+	server := http.FileServer(http.FS(files))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-cache")
+		server.ServeHTTP(w, r)
+	})
+}
+
+// Assets serves static files under fingerprinted names that change with
+// their contents, like css/main.0123abcd.css for css/main.css
+type Assets struct {
+	files fs.FS
+	// fingerprinted maps names to fingerprinted names
+	fingerprinted map[string]string
+	// names maps fingerprinted names back to names
+	names  map[string]string
+	hashes map[string]string
+}
+
+// NewAssets fingerprints every file in files
+func NewAssets(files fs.FS) (*Assets, error) {
+	a := &Assets{
+		files:         files,
+		fingerprinted: make(map[string]string),
+		names:         make(map[string]string),
+		hashes:        make(map[string]string),
+	}
+
+	err := fs.WalkDir(files, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(files, name)
+		if err != nil {
+			return err
+		}
+
+		sum := sha256.Sum256(data)
+		hash := hex.EncodeToString(sum[:])[:hashLength]
+		ext := path.Ext(name)
+		fingerprinted := strings.TrimSuffix(name, ext) + "." + hash + ext
+
+		a.fingerprinted[name] = fingerprinted
+		a.names[fingerprinted] = name
+		a.hashes[name] = hash
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Path returns the URL of the named asset, fingerprinted when the asset
+// exists. A nil Assets returns the plain URL.
+func (a *Assets) Path(name string) string {
+	name = strings.TrimPrefix(name, "/")
+	if a != nil {
+		if fingerprinted, ok := a.fingerprinted[name]; ok {
+			return URLPrefix + fingerprinted
+		}
+	}
+	return URLPrefix + name
+}
+
+// Names returns the names of the assets in order
+func (a *Assets) Names() []string {
+	names := make([]string, 0, len(a.fingerprinted))
+	for name := range a.fingerprinted {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Fingerprinted returns the fingerprinted name of the named asset
+func (a *Assets) Fingerprinted(name string) string {
+	return a.fingerprinted[name]
+}
+
+// Files returns the file system the assets are read from
+func (a *Assets) Files() fs.FS {
+	return a.files
+}
+
+// Handler serves the assets by name relative to URLPrefix. Fingerprinted
+// names are cached for a year; plain names, and fingerprints of an older
+// version still referenced by a cached page, are revalidated on every use.
+func (a *Assets) Handler() http.Handler {
+	files := http.FileServer(http.FS(a.files))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/")
+
+		if original, ok := a.names[name]; ok {
+			w.Header().Set("Cache-Control", immutable)
+			name = original
+		} else if m := fingerprintPattern.FindStringSubmatch(name); m != nil && a.hashes[m[1]+m[2]] != "" {
+			w.Header().Set("Cache-Control", "no-cache")
+			name = m[1] + m[2]
+		} else {
+			w.Header().Set("Cache-Control", "no-cache")
+		}
+		if hash, ok := a.hashes[name]; ok {
+			w.Header().Set("ETag", `"`+hash+`"`)
+		}
+
+		r2 := new(http.Request)
+		*r2 = *r
+		u := *r.URL
+		u.Path = "/" + name
+		r2.URL = &u
+		files.ServeHTTP(w, r2)
+	})
+}
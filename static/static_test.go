@@ -0,0 +1,83 @@
+//go:build unit
+
+package static
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssets(t *testing.T) {
+	files := fstest.MapFS{
+		"css/main.css": {Data: []byte("body { color: blue; }")},
+		"js/main.js":   {Data: []byte("console.log('hi');")},
+	}
+	assets, err := NewAssets(files)
+	require.NoError(t, err)
+
+	css := assets.Path("css/main.css")
+	assert.Regexp(t, regexp.MustCompile(`^/static/css/main\.[0-9a-f]{8}\.css$`), css)
+	assert.Equal(t, css, assets.Path("/css/main.css"))
+	assert.Equal(t, "/static/missing.css", assets.Path("missing.css"))
+	assert.Equal(t, []string{"css/main.css", "js/main.js"}, assets.Names())
+
+	// The fingerprint follows the contents
+	files["css/main.css"] = &fstest.MapFile{Data: []byte("body { color: red; }")}
+	changed, err := NewAssets(files)
+	require.NoError(t, err)
+	assert.NotEqual(t, css, changed.Path("css/main.css"))
+	assert.Equal(t, assets.Path("js/main.js"), changed.Path("js/main.js"))
+
+	var nilAssets *Assets
+	assert.Equal(t, "/static/css/main.css", nilAssets.Path("css/main.css"))
+}
+
+func TestAssets_Handler(t *testing.T) {
+	files := fstest.MapFS{"css/main.css": {Data: []byte("body { color: blue; }")}}
+	assets, err := NewAssets(files)
+	require.NoError(t, err)
+	handler := http.StripPrefix("/static", assets.Handler())
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	rr := get(assets.Path("css/main.css"))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "body { color: blue; }", rr.Body.String())
+	assert.Equal(t, "public, max-age=31536000, immutable", rr.Header().Get("Cache-Control"))
+	assert.Contains(t, rr.Header().Get("Content-Type"), "text/css")
+	etag := rr.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	rr = get("/static/css/main.css")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "no-cache", rr.Header().Get("Cache-Control"))
+
+	// A page cached before a deploy still gets the stylesheet, but not for good
+	rr = get("/static/css/main.00000000.css")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "no-cache", rr.Header().Get("Cache-Control"))
+
+	req := httptest.NewRequest(http.MethodGet, "/static/css/main.css", nil)
+	req.Header.Set("If-None-Match", etag)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNotModified, rr.Code)
+
+	assert.Equal(t, http.StatusNotFound, get("/static/css/missing.css").Code)
+}
+
+func TestFiles(t *testing.T) {
+	assets, err := NewAssets(Files(""))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"css/main.css", "js/main.js"}, assets.Names())
+}
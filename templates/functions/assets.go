@@ -0,0 +1,9 @@
+package functions
+
+// AssetFuncs returns functions linking static assets. asset maps a name
+// relative to the static directory, like "css/main.css", to its URL.
+func AssetFuncs(asset func(name string) string) map[string]interface{} {
+	return map[string]interface{}{
+		"asset": asset,
+	}
+}
@@ -2,6 +2,11 @@ package functions
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gekich/news-app/media"
+	"github.com/gekich/news-app/models"
 )
 
 // BasicFuncs returns basic utility functions for templates
@@ -10,11 +15,14 @@ func BasicFuncs() map[string]interface{} {
 		"add":      func(a, b int) int { return a + b },
 		"subtract": func(a, b int) int { return a - b },
 		"truncate": func(s string, n int) string {
-			if len(s) <= n {
+			runes := []rune(s)
+			if len(runes) <= n {
 				return s
 			}
-			return s[:n] + "..."
+			return string(runes[:n]) + "..."
 		},
+		"filesize": media.FormatSize,
+		"srcset":   srcset,
 		"dict": func(values ...interface{}) (map[string]interface{}, error) {
 			if len(values)%2 != 0 {
 				return nil, fmt.Errorf("invalid dict call")
@@ -31,3 +39,20 @@ func BasicFuncs() map[string]interface{} {
 		},
 	}
 }
+
+// srcset returns the srcset attribute of an image attachment, listing its
+// resized copies and the image itself by width, or "" for an image without
+// copies
+func srcset(image models.Attachment) string {
+	if len(image.Variants) == 0 {
+		return ""
+	}
+	candidates := make([]string, 0, len(image.Variants)+1)
+	for _, variant := range image.Variants {
+		candidates = append(candidates, variant.URL()+" "+strconv.Itoa(variant.Width)+"w")
+	}
+	if image.Width > 0 {
+		candidates = append(candidates, image.URL()+" "+strconv.Itoa(image.Width)+"w")
+	}
+	return strings.Join(candidates, ", ")
+}
@@ -0,0 +1,15 @@
+package functions
+
+import (
+	"github.com/gekich/news-app/i18n"
+)
+
+// I18nFuncs returns the functions translating a template into the locale of
+// tr: t translates a message key, date and datetime format a time.Time
+func I18nFuncs(tr *i18n.Translator) map[string]interface{} {
+	return map[string]interface{}{
+		"t":        tr.T,
+		"date":     tr.Date,
+		"datetime": tr.DateTime,
+	}
+}
@@ -1,14 +1,32 @@
 package templates
 
 import (
-	"fmt"
+	"embed"
 	"html/template"
+	"io/fs"
 
+	"github.com/gekich/news-app/i18n"
+	"github.com/gekich/news-app/overlay"
+	"github.com/gekich/news-app/static"
 	"github.com/gekich/news-app/templates/functions"
 )
 
-// NewTemplateFuncs creates and returns a new template.FuncMap
-func NewTemplateFuncs() template.FuncMap {
+// embedded holds the templates shipped with the binary. Pages and layouts
+// live one directory below the root.
+//
+//go:embed */*.html
+var embedded embed.FS
+
+// Files returns the embedded templates, overridden by the files in dir when
+// set. An override directory only needs the templates it changes.
+func Files(dir string) fs.FS {
+	return overlay.New(dir, embedded)
+}
+
+// NewTemplateFuncs creates and returns a new template.FuncMap. Asset URLs
+// are fingerprinted by assets, or plain when it is nil. Templates are
+// translated into the default locale.
+func NewTemplateFuncs(assets *static.Assets) template.FuncMap {
 	// Initialize template functions map
 	funcs := template.FuncMap{}
 
@@ -23,31 +41,42 @@ func NewTemplateFuncs() template.FuncMap {
 	for name, fn := range paginationFuncs {
 		funcs[name] = fn
 	}
-	return funcs
-}
 
-// PostTemplates initializes and returns templates for post handling
-func PostTemplates() map[string]*template.Template {
-	return NewPostTemplates("templates")
+	// Add asset functions
+	assetFuncs := functions.AssetFuncs(assets.Path)
+	for name, fn := range assetFuncs {
+		funcs[name] = fn
+	}
+
+	// Add translation functions
+	i18nFuncs := functions.I18nFuncs(i18n.Default().Translator(i18n.DefaultLocale))
+	for name, fn := range i18nFuncs {
+		funcs[name] = fn
+	}
+	return funcs
 }
 
-// NewPostTemplates initializes and returns templates for post handling with a custom base path
-func NewPostTemplates(basePath string) map[string]*template.Template {
-	layout := fmt.Sprintf("%s/layout.html", basePath)
-	partials := []string{
-		fmt.Sprintf("%s/partials/back_button.html", basePath),
-		fmt.Sprintf("%s/partials/post_actions.html", basePath),
-		fmt.Sprintf("%s/partials/pagination.html", basePath),
+// newRegistry parses the templates in files with a copy for every locale
+// of the embedded catalogs
+func newRegistry(files fs.FS, assets *static.Assets) (*Registry, error) {
+	registry, err := NewRegistry(files, NewTemplateFuncs(assets), AppPages...)
+	if err != nil {
+		return nil, err
 	}
 
-	tmpl := map[string]*template.Template{
-		"post_list": template.Must(template.New("layout.html").Funcs(NewTemplateFuncs()).ParseFiles(
-			append([]string{layout, fmt.Sprintf("%s/posts/post_list.html", basePath)}, partials...)...)),
-		"show": template.Must(template.New("layout.html").Funcs(NewTemplateFuncs()).ParseFiles(
-			append([]string{layout, fmt.Sprintf("%s/posts/show.html", basePath)}, partials...)...)),
-		"form": template.Must(template.New("layout.html").Funcs(NewTemplateFuncs()).ParseFiles(
-			append([]string{layout, fmt.Sprintf("%s/posts/form.html", basePath)}, partials...)...)),
+	bundle := i18n.Default()
+	err = registry.Localize(bundle.Locales(), func(locale string) template.FuncMap {
+		return functions.I18nFuncs(bundle.Translator(locale))
+	})
+	if err != nil {
+		return nil, err
 	}
+	return registry, nil
+}
 
-	return tmpl
+// Load parses the embedded templates, overridden by the files in
+// overrideDir when set, and checks that every page of AppPages is there.
+// Registry.In selects the templates of a locale.
+func Load(overrideDir string, assets *static.Assets) (*Registry, error) {
+	return newRegistry(Files(overrideDir), assets)
 }
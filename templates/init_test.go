@@ -5,54 +5,60 @@ package templates
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
 func TestNewTemplateFuncs(t *testing.T) {
-	funcs := NewTemplateFuncs()
+	funcs := NewTemplateFuncs(nil)
 	if len(funcs) == 0 {
 		t.Error("expected to have some template functions, but got none")
 	}
 }
 
-func TestNewPostTemplates(t *testing.T) {
-	// Create a temporary directory for our test templates
-	tmpDir, err := os.MkdirTemp("", "templates")
+func TestLoad_Embedded(t *testing.T) {
+	// Run outside the repository, where no templates directory exists
+	t.Chdir(t.TempDir())
+
+	registry, err := Load("", nil)
 	if err != nil {
-		t.Fatalf("failed to create temp dir: %v", err)
+		t.Fatalf("failed to load templates: %v", err)
 	}
-	defer os.RemoveAll(tmpDir)
-
-	// Create dummy template files
-	createDummyFile := func(path string, content string) {
-		err := os.MkdirAll(filepath.Dir(path), 0755)
-		if err != nil {
-			t.Fatalf("failed to create dir for dummy file: %v", err)
-		}
-		err = os.WriteFile(path, []byte(content), 0644)
-		if err != nil {
-			t.Fatalf("failed to write dummy file: %v", err)
+	for _, page := range AppPages {
+		if !registry.Has(page) {
+			t.Errorf("expected to find page %q in the registry, but it was not there", page)
 		}
 	}
+}
 
-	createDummyFile(filepath.Join(tmpDir, "layout.html"), `{{template "content" .}}`)
-	createDummyFile(filepath.Join(tmpDir, "partials", "back_button.html"), `back`)
-	createDummyFile(filepath.Join(tmpDir, "partials", "post_actions.html"), `actions`)
-	createDummyFile(filepath.Join(tmpDir, "partials", "pagination.html"), `pagination`)
-	createDummyFile(filepath.Join(tmpDir, "posts", "post_list.html"), `{{define "content"}}post list{{end}}`)
-	createDummyFile(filepath.Join(tmpDir, "posts", "show.html"), `{{define "content"}}show post{{end}}`)
-	createDummyFile(filepath.Join(tmpDir, "posts", "form.html"), `{{define "content"}}post form{{end}}`)
+func TestLoad_Override(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, "posts"), 0755); err != nil {
+		t.Fatalf("failed to create override dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "posts", "show.html"), []byte(`{{define "content"}}custom show{{end}}`), 0644); err != nil {
+		t.Fatalf("failed to write override template: %v", err)
+	}
 
-	templates := NewPostTemplates(tmpDir)
+	registry, err := Load(tmpDir, nil)
+	if err != nil {
+		t.Fatalf("failed to load templates: %v", err)
+	}
 
-	if templates == nil {
-		t.Fatal("expected templates to be initialized, but got nil")
+	var show strings.Builder
+	if err := registry.RenderBlock(&show, PostShow, "content", nil); err != nil {
+		t.Fatalf("failed to render show: %v", err)
+	}
+	if show.String() != "custom show" {
+		t.Errorf("expected the override to replace show.html, got %q", show.String())
 	}
 
-	expectedKeys := []string{"post_list", "show", "form"}
-	for _, key := range expectedKeys {
-		if _, ok := templates[key]; !ok {
-			t.Errorf("expected to find key %q in templates map, but it was not there", key)
-		}
+	// The embedded layout still renders around it, with plain asset URLs
+	show.Reset()
+	if err := registry.Render(&show, PostShow, nil); err != nil {
+		t.Fatalf("failed to render the layout: %v", err)
+	}
+	if !strings.Contains(show.String(), "custom show") || !strings.Contains(show.String(), `href="/static/css/main.css"`) {
+		t.Errorf("expected the embedded layout around the override, got %q", show.String())
 	}
 }
@@ -0,0 +1,221 @@
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"text/template/parse"
+)
+
+// Page names a page template by its path under the template root without
+// the extension, like "posts/show" for posts/show.html
+type Page string
+
+// Pages rendered by the application
+const (
+	PostList    Page = "posts/post_list"
+	PostShow    Page = "posts/show"
+	PostForm    Page = "posts/form"
+	AdminImport Page = "admin/import"
+	ErrorPage   Page = "errors/error"
+)
+
+// AppPages are the pages the server renders, which Load requires
+var AppPages = []Page{PostList, PostShow, PostForm, AdminImport, ErrorPage}
+
+// Directories of the template root with a special meaning. Every other
+// directory holds pages.
+const (
+	layoutDir  = "layouts"
+	partialDir = "partials"
+)
+
+// DefaultLayout wraps the pages of directories without a layout of their own
+const DefaultLayout = "default"
+
+// ErrMissingTemplate is returned for pages, layouts and called templates
+// that don't exist
+var ErrMissingTemplate = errors.New("missing template")
+
+// Registry holds the parsed pages. Each page is parsed along with its layout
+// and every partial.
+type Registry struct {
+	pages map[Page]*template.Template
+	// locales holds a copy of the pages for each locale, see Localize
+	locales map[string]*Registry
+}
+
+// NewRegistry discovers and parses the templates in files by convention:
+//
+//   - layouts/NAME.html is a layout, which calls the blocks its pages define
+//   - partials/*.html are shared by every page
+//   - any other DIR/NAME.html is the page DIR/NAME, wrapped in layouts/DIR.html
+//     when it exists and in layouts/default.html otherwise
+//
+// It fails when a page in required is missing, or when a template calls one
+// that no file defines, so that mistakes surface at startup rather than on
+// the first request for the page.
+func NewRegistry(files fs.FS, funcs template.FuncMap, required ...Page) (*Registry, error) {
+	layouts := map[string]string{}
+	var partials, pages []string
+
+	err := fs.WalkDir(files, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(name) != ".html" {
+			return err
+		}
+		switch path.Dir(name) {
+		case layoutDir:
+			layouts[strings.TrimSuffix(path.Base(name), ".html")] = name
+		case partialDir:
+			partials = append(partials, name)
+		default:
+			pages = append(pages, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Registry{pages: make(map[Page]*template.Template, len(pages))}
+	for _, name := range pages {
+		page := Page(strings.TrimSuffix(name, ".html"))
+
+		layout, ok := layouts[path.Dir(name)]
+		if !ok {
+			if layout, ok = layouts[DefaultLayout]; !ok {
+				return nil, fmt.Errorf("%w: no layout for page %s", ErrMissingTemplate, page)
+			}
+		}
+
+		// The layout is parsed first, so executing the set renders it
+		tmpl, err := template.New(path.Base(layout)).Funcs(funcs).ParseFS(files, append(append([]string{layout}, partials...), name)...)
+		if err != nil {
+			return nil, fmt.Errorf("page %s: %w", page, err)
+		}
+		if err := checkCalls(tmpl); err != nil {
+			return nil, fmt.Errorf("page %s: %w", page, err)
+		}
+		r.pages[page] = tmpl
+	}
+
+	for _, page := range required {
+		if !r.Has(page) {
+			return nil, fmt.Errorf("%w: page %s", ErrMissingTemplate, page)
+		}
+	}
+	return r, nil
+}
+
+// NewRegistryFrom wraps templates that are already parsed. Executing a page
+// template renders the page in its layout.
+func NewRegistryFrom(pages map[Page]*template.Template) *Registry {
+	return &Registry{pages: pages}
+}
+
+// Localize makes a copy of the pages for each locale, with the template
+// functions funcs returns for it. In selects the copy of a locale.
+func (r *Registry) Localize(locales []string, funcs func(locale string) template.FuncMap) error {
+	r.locales = make(map[string]*Registry, len(locales))
+	for _, locale := range locales {
+		localized := &Registry{pages: make(map[Page]*template.Template, len(r.pages))}
+		for page, tmpl := range r.pages {
+			clone, err := tmpl.Clone()
+			if err != nil {
+				return fmt.Errorf("page %s: %w", page, err)
+			}
+			localized.pages[page] = clone.Funcs(funcs(locale))
+		}
+		r.locales[locale] = localized
+	}
+	return nil
+}
+
+// In returns the pages of locale, or r itself when the pages weren't
+// localized for it
+func (r *Registry) In(locale string) *Registry {
+	if localized, ok := r.locales[locale]; ok {
+		return localized
+	}
+	return r
+}
+
+// Has reports whether the page exists
+func (r *Registry) Has(page Page) bool {
+	return r.pages[page] != nil
+}
+
+// Pages returns the names of the pages in order
+func (r *Registry) Pages() []Page {
+	pages := make([]Page, 0, len(r.pages))
+	for page := range r.pages {
+		pages = append(pages, page)
+	}
+	sort.Slice(pages, func(i, j int) bool { return pages[i] < pages[j] })
+	return pages
+}
+
+// Render writes the page in its layout
+func (r *Registry) Render(w io.Writer, page Page, data interface{}) error {
+	tmpl, ok := r.pages[page]
+	if !ok {
+		return fmt.Errorf("%w: page %s", ErrMissingTemplate, page)
+	}
+	return tmpl.Execute(w, data)
+}
+
+// RenderBlock writes a single block of the page, like the "content" block
+// swapped in by HTMX requests
+func (r *Registry) RenderBlock(w io.Writer, page Page, block string, data interface{}) error {
+	tmpl, ok := r.pages[page]
+	if !ok {
+		return fmt.Errorf("%w: page %s", ErrMissingTemplate, page)
+	}
+	return tmpl.ExecuteTemplate(w, block, data)
+}
+
+// checkCalls reports {{template}} calls of templates the set doesn't define
+func checkCalls(tmpl *template.Template) error {
+	var missing []string
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		case *parse.IfNode:
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.WithNode:
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.TemplateNode:
+			if defined := tmpl.Lookup(n.Name); defined == nil || defined.Tree == nil {
+				missing = append(missing, n.Name)
+			}
+		}
+	}
+
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			walk(t.Tree.Root)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s called but not defined", ErrMissingTemplate, strings.Join(missing, ", "))
+	}
+	return nil
+}
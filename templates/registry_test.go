@@ -0,0 +1,154 @@
+//go:build unit
+
+package templates
+
+import (
+	"errors"
+	"html/template"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestNewRegistry(t *testing.T) {
+	files := fstest.MapFS{
+		"layouts/default.html": {Data: []byte(`default[{{template "content" .}}]{{template "footer"}}`)},
+		"layouts/admin.html":   {Data: []byte(`admin[{{template "content" .}}]`)},
+		"partials/footer.html": {Data: []byte(`{{define "footer"}}footer{{end}}`)},
+		"posts/show.html":      {Data: []byte(`{{define "content"}}show {{.}}{{end}}`)},
+		"posts/archive.html":   {Data: []byte(`{{define "content"}}archive{{end}}`)},
+		"admin/dashboard.html": {Data: []byte(`{{define "content"}}dashboard{{end}}`)},
+		"posts/notes.txt":      {Data: []byte(`not a template`)},
+		"partials/unused.html": {Data: []byte(`{{define "unused"}}{{end}}`)},
+	}
+
+	registry, err := NewRegistry(files, template.FuncMap{}, PostShow)
+	if err != nil {
+		t.Fatalf("failed to create registry: %v", err)
+	}
+
+	want := []Page{"admin/dashboard", "posts/archive", "posts/show"}
+	if got := registry.Pages(); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected pages %v, got %v", want, got)
+	}
+
+	for _, tt := range []struct {
+		page  Page
+		block string
+		want  string
+	}{
+		{PostShow, "", "default[show hello]footer"},
+		{PostShow, "content", "show hello"},
+		{"posts/archive", "", "default[archive]footer"},
+		// Pages use the layout named after their directory
+		{"admin/dashboard", "", "admin[dashboard]"},
+	} {
+		var out strings.Builder
+		var err error
+		if tt.block == "" {
+			err = registry.Render(&out, tt.page, "hello")
+		} else {
+			err = registry.RenderBlock(&out, tt.page, tt.block, "hello")
+		}
+		if err != nil {
+			t.Errorf("failed to render %s: %v", tt.page, err)
+			continue
+		}
+		if out.String() != tt.want {
+			t.Errorf("expected %s to render %q, got %q", tt.page, tt.want, out.String())
+		}
+	}
+
+	if err := registry.Render(&strings.Builder{}, "posts/missing", nil); !errors.Is(err, ErrMissingTemplate) {
+		t.Errorf("expected ErrMissingTemplate for an unknown page, got %v", err)
+	}
+}
+
+func TestNewRegistry_Errors(t *testing.T) {
+	layout := &fstest.MapFile{Data: []byte(`{{template "content" .}}`)}
+
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		missing bool
+		want    string
+	}{
+		{
+			name:    "required page missing",
+			files:   fstest.MapFS{"layouts/default.html": layout, "posts/show.html": {Data: []byte(`{{define "content"}}{{end}}`)}},
+			missing: true,
+			want:    "page posts/post_list",
+		},
+		{
+			name:    "undefined template called",
+			files:   fstest.MapFS{"layouts/default.html": layout, "posts/show.html": {Data: []byte(`{{define "content"}}{{if .}}{{template "sidebar"}}{{end}}{{end}}`)}},
+			missing: true,
+			want:    "sidebar called but not defined",
+		},
+		{
+			name:    "no layout",
+			files:   fstest.MapFS{"posts/show.html": {Data: []byte(`{{define "content"}}{{end}}`)}},
+			missing: true,
+			want:    "no layout for page posts/show",
+		},
+		{
+			name:  "parse error",
+			files: fstest.MapFS{"layouts/default.html": layout, "posts/show.html": {Data: []byte(`{{define "content"}}{{if}}{{end}}`)}},
+			want:  "page posts/show",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRegistry(tt.files, template.FuncMap{}, PostShow, PostList)
+			if err == nil {
+				t.Fatal("expected an error, got nil")
+			}
+			if errors.Is(err, ErrMissingTemplate) != tt.missing {
+				t.Errorf("expected errors.Is(err, ErrMissingTemplate) to be %v, got %v", tt.missing, err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected the error to mention %q, got %q", tt.want, err.Error())
+			}
+		})
+	}
+}
+
+func TestRegistry_Localize(t *testing.T) {
+	files := fstest.MapFS{
+		"layouts/default.html": {Data: []byte(`{{t "title"}}[{{template "content" .}}]`)},
+		"posts/show.html":      {Data: []byte(`{{define "content"}}{{t "body"}}{{end}}`)},
+	}
+	translate := func(locale string) template.FuncMap {
+		return template.FuncMap{"t": func(key string) string { return locale + ":" + key }}
+	}
+
+	registry, err := NewRegistry(files, translate("default"), PostShow)
+	if err != nil {
+		t.Fatalf("failed to create registry: %v", err)
+	}
+	if err := registry.Localize([]string{"en", "uk"}, translate); err != nil {
+		t.Fatalf("failed to localize registry: %v", err)
+	}
+
+	for _, tt := range []struct {
+		locale string
+		want   string
+	}{
+		{"uk", "uk:title[uk:body]"},
+		{"en", "en:title[en:body]"},
+		// Unknown locales get the templates as they were parsed
+		{"fr", "default:title[default:body]"},
+		{"", "default:title[default:body]"},
+	} {
+		var out strings.Builder
+		if err := registry.In(tt.locale).Render(&out, PostShow, nil); err != nil {
+			t.Errorf("failed to render in %q: %v", tt.locale, err)
+			continue
+		}
+		if out.String() != tt.want {
+			t.Errorf("expected %q in %q, got %q", tt.want, tt.locale, out.String())
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package templates
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+	"sync"
+
+	"github.com/gekich/news-app/static"
+)
+
+// Reloader parses the templates again whenever their files change, so
+// template edits show up without restarting the server. It is meant for
+// development; production parses the templates once at startup.
+type Reloader struct {
+	files  fs.FS
+	assets *static.Assets
+
+	mu sync.Mutex
+	// version describes the files the templates were parsed from
+	version  string
+	registry *Registry
+	err      error
+}
+
+// NewReloader returns a Reloader over the template files
+func NewReloader(files fs.FS, assets *static.Assets) *Reloader {
+	return &Reloader{files: files, assets: assets}
+}
+
+// Templates returns the templates, parsing them first if any template file
+// was added, removed or modified since the last call. A parse error is
+// returned until the files are fixed.
+func (r *Reloader) Templates() (*Registry, error) {
+	version, err := r.fileVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if version != r.version || (r.registry == nil && r.err == nil) {
+		r.registry, r.err = newRegistry(r.files, r.assets)
+		r.version = version
+	}
+	return r.registry, r.err
+}
+
+// fileVersion lists the name, size and modification time of every template
+// file. Checking it costs a directory walk, cheap next to a page render.
+func (r *Reloader) fileVersion() (string, error) {
+	var version strings.Builder
+	err := fs.WalkDir(r.files, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(name, ".html") {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&version, "%s %d %d\n", name, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	return version.String(), err
+}
@@ -0,0 +1,75 @@
+//go:build unit
+
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReloader(t *testing.T) {
+	tmpDir := t.TempDir()
+	show := filepath.Join(tmpDir, "posts", "show.html")
+	if err := os.MkdirAll(filepath.Dir(show), 0755); err != nil {
+		t.Fatalf("failed to create override dir: %v", err)
+	}
+
+	// Each write gets a later modification time, as an editor's save would
+	modified := time.Now()
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(show, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write template: %v", err)
+		}
+		modified = modified.Add(time.Second)
+		if err := os.Chtimes(show, modified, modified); err != nil {
+			t.Fatalf("failed to touch template: %v", err)
+		}
+	}
+	render := func(reloader *Reloader) string {
+		t.Helper()
+		registry, err := reloader.Templates()
+		if err != nil {
+			t.Fatalf("failed to load templates: %v", err)
+		}
+		var out strings.Builder
+		if err := registry.RenderBlock(&out, PostShow, "content", nil); err != nil {
+			t.Fatalf("failed to render show: %v", err)
+		}
+		return out.String()
+	}
+
+	write(`{{define "content"}}first{{end}}`)
+	reloader := NewReloader(Files(tmpDir), nil)
+	if got := render(reloader); got != "first" {
+		t.Errorf("expected %q, got %q", "first", got)
+	}
+
+	first, _ := reloader.Templates()
+	second, _ := reloader.Templates()
+	if first != second {
+		t.Errorf("expected unchanged files not to be parsed again")
+	}
+
+	write(`{{define "content"}}second{{end}}`)
+	if got := render(reloader); got != "second" {
+		t.Errorf("expected the edited template, got %q", got)
+	}
+
+	// A broken template is reported until it is fixed
+	write(`{{define "content"}}{{if}}{{end}}`)
+	if _, err := reloader.Templates(); err == nil || !strings.Contains(err.Error(), "show.html") {
+		t.Errorf("expected a parse error naming show.html, got %v", err)
+	}
+	if _, err := reloader.Templates(); err == nil {
+		t.Errorf("expected the parse error to persist")
+	}
+
+	write(`{{define "content"}}fixed{{end}}`)
+	if got := render(reloader); got != "fixed" {
+		t.Errorf("expected the fixed template, got %q", got)
+	}
+}
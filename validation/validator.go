@@ -1,6 +1,12 @@
 package validation
 
 import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gekich/news-app/i18n"
+	"github.com/gekich/news-app/media"
 	"github.com/gekich/news-app/models"
 	"github.com/go-playground/validator/v10"
 )
@@ -10,21 +16,36 @@ var validate = validator.New()
 
 // PostError stores validation errors for the Post model
 type PostError struct {
-	Title   string
-	Content string
+	Title       string
+	Content     string
+	Status      string
+	Language    string
+	Attachments string
+}
+
+// UploadLimits restricts the files uploaded with a post
+type UploadLimits struct {
+	MaxBytes     int64
+	MaxFiles     int
+	AllowedTypes []string
+	// MaxPixels bounds the size of images, which are decoded to be resized
+	MaxPixels int64
 }
 
-// ValidatePost validates a post model and returns any validation errors
-func ValidatePost(post models.Post) (PostError, bool) {
+// ValidatePost validates a post model and returns any validation errors,
+// translated by tr. A nil tr translates into the default locale.
+func ValidatePost(post models.Post, tr *i18n.Translator) (PostError, bool) {
 	var errors PostError
 	valid := true
 
 	err := validate.Struct(struct {
 		Title   string `validate:"required,min=3,max=100"`
 		Content string `validate:"required,min=10"`
+		Status  string `validate:"omitempty,oneof=published draft"`
 	}{
 		Title:   post.Title,
 		Content: post.Content,
+		Status:  post.Status,
 	})
 
 	if err != nil {
@@ -33,26 +54,65 @@ func ValidatePost(post models.Post) (PostError, bool) {
 			field := err.Field()
 			switch field {
 			case "Title":
-				errors.Title = getErrorMessage(err)
+				errors.Title = getErrorMessage(err, tr)
 			case "Content":
-				errors.Content = getErrorMessage(err)
+				errors.Content = getErrorMessage(err, tr)
+			case "Status":
+				errors.Status = getErrorMessage(err, tr)
 			}
 		}
 	}
 
+	// Posts are written in the languages the site is translated into
+	if post.Language != "" && !slices.Contains(tr.Locales(), post.Language) {
+		valid = false
+		errors.Language = tr.T("validation.oneof", strings.Join(tr.Locales(), " "))
+	}
+
 	return errors, valid
 }
 
+// ValidateUpload checks a file uploaded with a post against limits: its
+// size, and its content type as sniffed from its contents. It returns the
+// error message translated by tr, or "" for a valid file.
+func ValidateUpload(filename string, size int64, contentType string, limits UploadLimits, tr *i18n.Translator) string {
+	if limits.MaxBytes > 0 && size > limits.MaxBytes {
+		return tr.T("validation.file_size", filename, media.FormatSize(limits.MaxBytes))
+	}
+	if !slices.Contains(limits.AllowedTypes, contentType) {
+		return tr.T("validation.file_type", filename)
+	}
+	return ""
+}
+
+// ValidateImage checks the size in pixels of an image uploaded with a post
+// against limits. It returns the error message translated by tr, or "" for
+// a valid image.
+func ValidateImage(filename string, pixels int64, limits UploadLimits, tr *i18n.Translator) string {
+	if limits.MaxPixels > 0 && pixels > limits.MaxPixels {
+		// Limits below a megapixel still read as one
+		return tr.T("validation.image_pixels", filename, int(max(1, limits.MaxPixels/1_000_000)))
+	}
+	return ""
+}
+
 // getErrorMessage returns a human-readable error message based on the validation error
-func getErrorMessage(err validator.FieldError) string {
+func getErrorMessage(err validator.FieldError, tr *i18n.Translator) string {
 	switch err.Tag() {
 	case "required":
-		return "This field is required"
-	case "min":
-		return "This field must be at least " + err.Param() + " characters long"
-	case "max":
-		return "This field must be at most " + err.Param() + " characters long"
+		return tr.T("validation.required")
+	case "min", "max":
+		// The length picks the plural form
+		length, _ := strconv.Atoi(err.Param())
+		return tr.T("validation."+err.Tag(), length)
+	case "oneof":
+		return tr.T("validation.oneof", err.Param())
 	default:
-		return "Invalid input"
+		return tr.T("validation.invalid")
 	}
 }
+
+// Accept returns the allowed types for the accept attribute of file inputs
+func (l UploadLimits) Accept() string {
+	return strings.Join(l.AllowedTypes, ",")
+}
@@ -0,0 +1,157 @@
+// Package views defines the data the page templates render. Each page has
+// its own view model, and every view model embeds the Layout shared by all
+// pages, so templates can only refer to fields that exist.
+package views
+
+import (
+	"github.com/gekich/news-app/importer"
+	"github.com/gekich/news-app/models"
+	"github.com/gekich/news-app/repository"
+	"github.com/gekich/news-app/validation"
+)
+
+// View is the view model of a page. Views embed Layout, which the code
+// rendering the page fills in.
+type View interface {
+	LayoutData() *Layout
+}
+
+// Layout is the data the layout renders around every page
+type Layout struct {
+	// SiteTitle names the site in the title bar and the header
+	SiteTitle string
+	// Flashes are one-off messages shown above the page
+	Flashes []Flash
+	// Locale is the locale the page is translated into, the default locale
+	// when empty. Locales are the ones the user can switch to.
+	Locale  string
+	Locales []LocaleLink
+}
+
+// LayoutData returns the layout of the view embedding it
+func (l *Layout) LayoutData() *Layout {
+	return l
+}
+
+// LocaleLink switches the current page to another locale
+type LocaleLink struct {
+	Locale string
+	// URL is the current page with the locale parameter replaced
+	URL string
+}
+
+// Flash is a one-off message, like the confirmation of a save
+type Flash struct {
+	Level   string
+	Message string
+}
+
+// SortChoice is a sort offered on the post list
+type SortChoice struct {
+	Value string
+	// Label is the message key of the sort's name
+	Label string
+}
+
+// ListOptions are the sort and filters of the post list as they appear in
+// its URL. Dates use the YYYY-MM-DD format.
+type ListOptions struct {
+	Search string
+	Sort   string
+	From   string
+	To     string
+	Tag    string
+	Author string
+	Status string
+	// Language is the language of the list when it isn't the locale of
+	// the page
+	Language string
+}
+
+// Filtered reports whether any filter narrows down the list
+func (o ListOptions) Filtered() bool {
+	return o.Search != "" || o.From != "" || o.To != "" || o.Tag != "" || o.Author != "" || o.Status != "" || o.Language != ""
+}
+
+// ListPage is a page of the post list. The server pages through the list
+// with cursors, while the static site numbers its pages.
+type ListPage struct {
+	Layout
+	Posts  []models.Post
+	Search string
+
+	// Page is the cursor page the posts come from, nil for numbered pages
+	Page *repository.PostPage
+	// Options are the sort and filters of the list, nil when it has none
+	Options *ListOptions
+	Sorts   []SortChoice
+	// Pagination is the pagination style: links, load_more or infinite
+	Pagination string
+	NextURL    string
+	PrevURL    string
+
+	// CurrentPage and TotalPages number the pages of the static site
+	CurrentPage int
+	TotalPages  int
+}
+
+// PostPage shows a single post
+type PostPage struct {
+	Layout
+	Post models.Post
+	// Translations are the posts of the post's translation group, the post
+	// included, ordered by language. Untranslated posts have none.
+	Translations []models.Post
+	// Alternates link the translations from the head of the page
+	Alternates []Alternate
+	// Untranslated are the languages the post can still be translated into
+	Untranslated []string
+}
+
+// Alternate is a version of the page in another language
+type Alternate struct {
+	// Language is the hreflang of the link: a locale, or x-default for the
+	// version in the fallback language
+	Language string
+	// URL is absolute, as search engines expect
+	URL string
+}
+
+// FormPage is the form creating or editing a post
+type FormPage struct {
+	Layout
+	// Title heads the form
+	Title string
+	Post  models.Post
+	// Action is the URL the form is submitted to with Method, post or put
+	Action string
+	Method string
+	// Languages are the ones the post can be written in
+	Languages []string
+	Errors    validation.PostError
+	// Conflict is the saved version of a post that changed while it was
+	// edited, nil otherwise
+	Conflict *models.Post
+	// Upload limits the attachments the form accepts, nil when the server
+	// has nowhere to store them
+	Upload *validation.UploadLimits
+}
+
+// ImportPage is the import form and the report of the last import
+type ImportPage struct {
+	Layout
+	Title string
+	// Report describes the uploaded file, nil before an upload
+	Report   *importer.Report
+	Filename string
+	Error    string
+}
+
+// ErrorPage explains why a request failed
+type ErrorPage struct {
+	Layout
+	// Status is the HTTP status code of the response
+	Status  int
+	Title   string
+	Message string
+}
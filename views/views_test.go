@@ -0,0 +1,301 @@
+//go:build unit
+
+package views
+
+import (
+	"html/template"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gekich/news-app/i18n"
+	"github.com/gekich/news-app/importer"
+	"github.com/gekich/news-app/models"
+	"github.com/gekich/news-app/repository"
+	"github.com/gekich/news-app/templates"
+	"github.com/gekich/news-app/validation"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func testPost() models.Post {
+	created := time.Date(2025, 3, 1, 9, 30, 0, 0, time.UTC)
+	return models.Post{
+		ID:        primitive.NewObjectID(),
+		Title:     "Spring Festival",
+		Slug:      "spring-festival",
+		Content:   strings.Repeat("The festival opens on the square. ", 10),
+		Author:    "Ada",
+		Tags:      []string{"events", "city"},
+		Status:    models.StatusDraft,
+		Version:   3,
+		CreatedAt: created,
+		UpdatedAt: created.Add(time.Hour),
+	}
+}
+
+func testLayout() Layout {
+	return Layout{
+		SiteTitle: "News App",
+		Flashes:   []Flash{{Level: "success", Message: "Post saved"}, {Level: "error", Message: "Import failed"}},
+	}
+}
+
+// viewCases returns view models for every page: the zero value, which a
+// template must cope with as well, and populated ones reaching every branch
+func viewCases() map[templates.Page]map[string]View {
+	post := testPost()
+	saved := testPost()
+	saved.Title = "Spring Festival (updated)"
+	post.Language = "en"
+	translated := testPost()
+	translated.Title = "Весняний фестиваль"
+	translated.Language = "uk"
+	translated.TranslationGroup = post.ID.Hex()
+	attached := testPost()
+	attached.Attachments = []models.Attachment{
+		{ID: "65f1a2b3c4d5e6f708192a3b", Filename: "square.jpg", ContentType: "image/jpeg", Size: 48213},
+		{ID: "65f1a2b3c4d5e6f708192a3c", Filename: "programme.pdf", ContentType: "application/pdf", Size: 1 << 20},
+		{ID: "65f1a2b3c4d5e6f708192a3d", Filename: "harbour.jpg", ContentType: "image/jpeg", Size: 912345, Width: 1600, Height: 1200, Variants: []models.ImageVariant{
+			{ID: "65f1a2b3c4d5e6f708192a3e", ContentType: "image/jpeg", Width: 640, Height: 480},
+		}},
+	}
+	attached.FeaturedImage = "65f1a2b3c4d5e6f708192a3d"
+	upload := &validation.UploadLimits{MaxBytes: 10 << 20, MaxFiles: 10, AllowedTypes: []string{"image/jpeg", "application/pdf"}}
+
+	cursorPage := func(pagination string) View {
+		options := ListOptions{Search: "festival", Sort: repository.SortRelevance, From: "2025-01-01", To: "2025-12-31", Tag: "events", Author: "Ada", Status: models.StatusDraft}
+		return &ListPage{
+			Layout:     testLayout(),
+			Posts:      []models.Post{post, post},
+			Search:     options.Search,
+			Page:       &repository.PostPage{Posts: []models.Post{post, post}, NextCursor: "next", PrevCursor: "prev", Total: 12},
+			Options:    &options,
+			Sorts:      []SortChoice{{Value: repository.SortNewest, Label: "sort.newest"}, {Value: repository.SortRelevance, Label: "sort.relevance"}},
+			Pagination: pagination,
+			NextURL:    "/posts?after=next",
+			PrevURL:    "/posts?before=prev",
+		}
+	}
+
+	return map[templates.Page]map[string]View{
+		templates.PostList: {
+			"zero":      &ListPage{},
+			"links":     cursorPage("links"),
+			"featured":  &ListPage{Layout: testLayout(), Posts: []models.Post{attached}},
+			"load more": cursorPage("load_more"),
+			"infinite":  cursorPage("infinite"),
+			"empty":     &ListPage{Layout: testLayout(), Page: &repository.PostPage{Total: 0}, Options: &ListOptions{Sort: repository.SortNewest}},
+			"numbered":  &ListPage{Layout: testLayout(), Posts: []models.Post{post}, CurrentPage: 2, TotalPages: 9},
+		},
+		templates.PostShow: {
+			"zero": &PostPage{},
+			"post": &PostPage{Layout: testLayout(), Post: post},
+			"translated": &PostPage{
+				Layout:       testLayout(),
+				Post:         translated,
+				Translations: []models.Post{post, translated},
+				Alternates:   []Alternate{{Language: "en", URL: "https://example.com" + post.Path()}, {Language: "x-default", URL: "https://example.com" + post.Path()}},
+				Untranslated: []string{"uk"},
+			},
+			"attachments": &PostPage{Layout: testLayout(), Post: attached},
+		},
+		templates.PostForm: {
+			"zero": &FormPage{},
+			"new":  &FormPage{Layout: testLayout(), Title: "Create New Post", Action: "/posts", Method: "post", Languages: []string{"en", "uk"}},
+			"translation": &FormPage{
+				Layout:    testLayout(),
+				Title:     "New Translation: Ukrainian",
+				Post:      translated,
+				Action:    "/posts",
+				Method:    "post",
+				Languages: []string{"uk"},
+				Errors:    validation.PostError{Language: "The story already has a translation into Ukrainian"},
+			},
+			"invalid": &FormPage{
+				Layout: testLayout(),
+				Title:  "Edit Post",
+				Post:   post,
+				Action: "/posts/" + post.ID.Hex(),
+				Method: "put",
+				Errors: validation.PostError{Title: "Title is required", Content: "Content is too short", Status: "Status is invalid"},
+			},
+			"attachments": &FormPage{
+				Layout: testLayout(),
+				Title:  "Edit Post",
+				Post:   attached,
+				Action: "/posts/" + attached.ID.Hex(),
+				Method: "put",
+				Errors: validation.PostError{Attachments: "notes.txt is not a type of file that can be attached"},
+				Upload: upload,
+			},
+			"conflict": &FormPage{Layout: testLayout(), Title: "Edit Post", Post: post, Action: "/posts/" + post.ID.Hex(), Method: "put", Conflict: &saved},
+		},
+		templates.ErrorPage: {
+			"zero":      &ErrorPage{},
+			"not found": &ErrorPage{Layout: testLayout(), Status: 404, Title: "Page not found", Message: "Post not found"},
+		},
+		templates.AdminImport: {
+			"zero":  &ImportPage{},
+			"error": &ImportPage{Layout: testLayout(), Title: "Import Posts", Error: "unknown format"},
+			"report": &ImportPage{
+				Layout:   testLayout(),
+				Title:    "Import Posts",
+				Filename: "posts.csv",
+				Report: &importer.Report{
+					DryRun:      true,
+					Total:       4,
+					Imported:    2,
+					Failed:      1,
+					Errors:      []importer.RowError{{Row: 3, Field: "title", Message: "Title is required"}},
+					Skipped:     1,
+					SkippedRows: []importer.SkippedRow{{Row: 4, Title: "About", Reason: "page"}},
+				},
+			},
+		},
+	}
+}
+
+func TestTemplates_RenderViews(t *testing.T) {
+	registry, err := templates.Load("", nil)
+	if err != nil {
+		t.Fatalf("failed to load templates: %v", err)
+	}
+
+	cases := viewCases()
+	for _, page := range registry.Pages() {
+		views, ok := cases[page]
+		if !ok {
+			t.Errorf("page %s has no view model cases", page)
+			continue
+		}
+
+		for name, view := range views {
+			for _, locale := range i18n.Default().Locales() {
+				t.Run(string(page)+"/"+name+"/"+locale, func(t *testing.T) {
+					localized := registry.In(locale)
+					if err := localized.Render(io.Discard, page, view); err != nil {
+						t.Errorf("failed to render page: %v", err)
+					}
+					if err := localized.RenderBlock(io.Discard, page, "content", view); err != nil {
+						t.Errorf("failed to render content block: %v", err)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestTemplates_RenderLayoutData(t *testing.T) {
+	registry, err := templates.Load("", nil)
+	if err != nil {
+		t.Fatalf("failed to load templates: %v", err)
+	}
+
+	var buf strings.Builder
+	if err := registry.Render(&buf, templates.PostShow, &PostPage{Layout: testLayout(), Post: testPost()}); err != nil {
+		t.Fatalf("failed to render page: %v", err)
+	}
+
+	for _, want := range []string{"<title>News App</title>", "Post saved", "Import failed"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected the page to contain %q, but it did not", want)
+		}
+	}
+}
+
+func TestTemplates_RenderLocalized(t *testing.T) {
+	registry, err := templates.Load("", nil)
+	if err != nil {
+		t.Fatalf("failed to load templates: %v", err)
+	}
+
+	layout := testLayout()
+	layout.Locale = "uk"
+	layout.Locales = []LocaleLink{{Locale: "en", URL: "/posts?lang=en&tag=go"}, {Locale: "uk", URL: "/posts?lang=uk&tag=go"}}
+	post := testPost()
+
+	tests := []struct {
+		page templates.Page
+		view View
+		want []string
+	}{
+		{templates.PostShow, &PostPage{Layout: layout, Post: post}, []string{
+			`<html lang="uk">`, "Створено: 01 бер. 2025, 09:30", "Чернетка", "Редагувати",
+			`href="/posts?lang=en&amp;tag=go"`,
+		}},
+		{templates.PostShow, viewCases()[templates.PostShow]["translated"], []string{
+			`<link rel="alternate" hreflang="x-default"`, "Читати мовою", "Додати переклад: українська",
+		}},
+		{templates.PostShow, viewCases()[templates.PostShow]["attachments"], []string{
+			"Вкладення", `<img src="/media/65f1a2b3c4d5e6f708192a3b.jpg" alt="square.jpg"`, `href="/media/65f1a2b3c4d5e6f708192a3c.pdf"`, "(1 MB)",
+			`<img src="/media/65f1a2b3c4d5e6f708192a3d.jpg" srcset="/media/65f1a2b3c4d5e6f708192a3e.jpg 640w, /media/65f1a2b3c4d5e6f708192a3d.jpg 1600w" sizes="(min-width: 1280px) 1200px, 100vw" width="1600" height="1200"`,
+		}},
+		{templates.PostForm, viewCases()[templates.PostForm]["attachments"], []string{
+			`enctype="multipart/form-data"`, `?_method=PUT" method="POST"`, `accept="image/jpeg,application/pdf"`,
+			`name="remove_attachment" value="65f1a2b3c4d5e6f708192a3b"`, "До 10 файлів розміром не більше 10 MB кожен",
+			`name="featured_image" value="65f1a2b3c4d5e6f708192a3d" class="mr-1" checked>Головне зображення`,
+		}},
+		{templates.PostList, viewCases()[templates.PostList]["featured"], []string{
+			`<img src="/media/65f1a2b3c4d5e6f708192a3e.jpg" srcset="/media/65f1a2b3c4d5e6f708192a3e.jpg 640w, /media/65f1a2b3c4d5e6f708192a3d.jpg 1600w"`,
+		}},
+		{templates.PostList, viewCases()[templates.PostList]["links"], []string{
+			"Новий допис", "Спочатку нові", "12 дописів", "01 бер. 2025",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.page), func(t *testing.T) {
+			*tt.view.LayoutData() = layout
+
+			var buf strings.Builder
+			if err := registry.In("uk").Render(&buf, tt.page, tt.view); err != nil {
+				t.Fatalf("failed to render page: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("expected the page to contain %q, but it did not", want)
+				}
+			}
+			if strings.Contains(buf.String(), "sort.newest") {
+				t.Error("expected the sort labels to be translated, but they were not")
+			}
+		})
+	}
+}
+
+func TestTemplates_MissingField(t *testing.T) {
+	// Fields that don't exist on the view model fail to render, unlike
+	// missing keys of a map
+	tmpl := template.Must(template.New("show").Parse(`{{define "content"}}{{.Post.Title}} {{.Subtitle}}{{end}}`))
+	registry := templates.NewRegistryFrom(map[templates.Page]*template.Template{templates.PostShow: tmpl})
+
+	err := registry.RenderBlock(io.Discard, templates.PostShow, "content", &PostPage{Post: testPost()})
+	if err == nil {
+		t.Fatal("expected rendering a missing field to fail, but it succeeded")
+	}
+	if !strings.Contains(err.Error(), "Subtitle") {
+		t.Errorf("expected the error to name the field, but got %v", err)
+	}
+}
+
+func TestLayout_LayoutData(t *testing.T) {
+	page := &FormPage{Title: "Edit Post"}
+	*page.LayoutData() = testLayout()
+
+	if page.SiteTitle != "News App" || len(page.Flashes) != 2 {
+		t.Errorf("expected the layout to be filled in through LayoutData, but got %+v", page.Layout)
+	}
+	if page.Title != "Edit Post" {
+		t.Errorf("expected the page title to be kept, but got %q", page.Title)
+	}
+}
+
+func TestListOptions_Filtered(t *testing.T) {
+	if (ListOptions{Sort: repository.SortOldest}).Filtered() {
+		t.Error("expected a sort alone not to count as a filter")
+	}
+	if !(ListOptions{Tag: "events"}).Filtered() {
+		t.Error("expected a tag to count as a filter")
+	}
+}